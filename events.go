@@ -0,0 +1,102 @@
+package away
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// RouteEventType is the type of change made to the routing table.
+type RouteEventType string
+
+const (
+	// RouteRegistered is emitted when a route is added.
+	RouteRegistered RouteEventType = "registered"
+	// RouteRemoved is emitted when a route is removed.
+	RouteRemoved RouteEventType = "removed"
+	// RouteSwapped is emitted when the handler of a route is replaced.
+	RouteSwapped RouteEventType = "swapped"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	// Handler is the name of the handler, such as main.handleReadSong.
+	Handler string
+	// Disabled is true if the route was disabled with SetEnabled.
+	Disabled bool
+}
+
+// RouteEvent describes a change to the routing table.
+type RouteEvent struct {
+	Type  RouteEventType
+	Route RouteInfo
+}
+
+// OnChange adds a listener that is called after a route is registered,
+// removed, or swapped. Listeners are called synchronously in the order they were added.
+func (r *Router) OnChange(fn func(event RouteEvent)) {
+	r.listeners = append(r.listeners, fn)
+}
+
+// emit calls the listeners with an event.
+func (r *Router) emit(typ RouteEventType, route *Route) {
+	if len(r.listeners) == 0 {
+		return
+	}
+
+	event := RouteEvent{Type: typ, Route: route.Info()}
+	for _, fn := range r.listeners {
+		fn(event)
+	}
+}
+
+// Info returns the description of the route.
+func (r *Route) Info() RouteInfo {
+	return RouteInfo{
+		Method:   r.Method(),
+		Pattern:  r.pattern,
+		Handler:  r.HandlerName(),
+		Disabled: r.disabled,
+	}
+}
+
+// Name sets the handler name shown in RouteInfo.
+func (r *Route) Name(name string) *Route {
+	r.configure(func(c *routeConfig) {
+		c.name = name
+	})
+	return r
+}
+
+// HandlerName returns the name set with Name or the name of the handler.
+// Handlers can provide a name with a HandlerName() string method. Otherwise,
+// the name of the function or type is used.
+func (r *Route) HandlerName() string {
+	if name := r.settings().name; name != "" {
+		return name
+	}
+	return handlerName(r.handler)
+}
+
+// handlerName returns the package qualified name of a handler.
+func handlerName(h http.Handler) string {
+	if n, ok := h.(interface{ HandlerName() string }); ok {
+		return n.HandlerName()
+	}
+
+	name := fmt.Sprintf("%T", h)
+	if v := reflect.ValueOf(h); v.Kind() == reflect.Func && !v.IsNil() {
+		if f := runtime.FuncForPC(v.Pointer()); f != nil {
+			name = f.Name()
+		}
+	}
+
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimPrefix(name, "*")
+}
@@ -0,0 +1,66 @@
+package away
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// FuzzMatch matches the path against the pattern and returns an error if an
+// invariant of the matcher doesn't hold:
+//
+//   - matching doesn't panic
+//   - the parameters are the parameter names of the pattern in order, with
+//     the values of the path segments at the same positions
+//   - a route doesn't match a path with fewer segments than the pattern
+//
+// Patterns rejected by ValidatePattern and paths that can't be parsed are
+// ignored. It is the entry point for go test -fuzz and other fuzzers.
+func FuzzMatch(pattern, path string) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("away: panic matching %q against %q: %v", path, pattern, v)
+		}
+	}()
+
+	r := NewRouter()
+	if r.ValidatePattern(pattern) != nil {
+		return nil
+	}
+	u, err := url.Parse(path)
+	if err != nil || !strings.HasPrefix(u.Path, "/") {
+		return nil
+	}
+
+	route := r.newRoute("get", pattern, nil)
+	segs := splitPath(nil, u.Path)
+	ctx, ok := route.match(context.Background(), r, segs)
+	if !ok {
+		return nil
+	}
+
+	if len(segs) < len(route.segs) {
+		return fmt.Errorf("away: %q matched %q with fewer segments", pattern, path)
+	}
+
+	params := ParamList(ctx)
+	i := 0
+	for pos, seg := range route.segs {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		if i >= len(params) {
+			return fmt.Errorf("away: %q matched %q without parameter %v", pattern, path, seg[1:])
+		}
+		if p := params[i]; p.Name != seg[1:] || p.Value != segs[pos] {
+			return fmt.Errorf("away: %q matched %q with parameter %v=%q, want %v=%q", pattern, path, p.Name, p.Value, seg[1:], segs[pos])
+		}
+		i++
+	}
+	if i != len(params) {
+		return fmt.Errorf("away: %q matched %q with %v parameters, want %v", pattern, path, len(params), i)
+	}
+
+	return nil
+}
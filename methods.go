@@ -0,0 +1,95 @@
+package away
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// MethodAny is the method of routes that match every method.
+const MethodAny = "*"
+
+// unmatched returns the handler for a request that no route matched, or nil
+// to use NotFound. Requests with a method that no route is registered for go
+// to NotImplemented, and requests with a path that matches routes of other
+// methods go to MethodNotAllowed with the Allow header set. A path that
+// matches a MethodAny route is never a 405 because every method is allowed;
+// the request wasn't matched because of a condition, so it is a 404.
+func (r *Router) unmatched(w http.ResponseWriter, req *http.Request, segs []string) http.Handler {
+	if r.NotImplemented != nil && !r.knownMethod(req.Method) {
+		r.countUnmatched(req, UnmatchedNotImplemented)
+		return r.NotImplemented
+	}
+
+	if r.MethodNotAllowed != nil && !r.anyMethod(segs) {
+		if allow := r.allowed(segs); len(allow) > 0 {
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			r.countUnmatched(req, UnmatchedMethodNotAllowed)
+			return r.MethodNotAllowed
+		}
+	}
+
+	r.countUnmatched(req, UnmatchedNotFound)
+	return nil
+}
+
+// knownMethod returns true if a route is registered for the method.
+func (r *Router) knownMethod(method string) bool {
+	method = strings.ToLower(method)
+	for _, route := range r.snapshot() {
+		if route.method == method || route.method == MethodAny {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedMethods returns the sorted upper case methods of the routes that
+// match the path, considering parameters and prefixes. Routes registered for
+// MethodAny are not included, so use AnyMethod to check whether every method
+// is allowed. It is used for the Allow header of 405 responses and is useful
+// for CORS preflight and OPTIONS responses.
+func (r *Router) AllowedMethods(path string) []string {
+	return r.allowed(r.pathSegments(path))
+}
+
+// AnyMethod returns true if an enabled MethodAny route matches the path, so
+// requests of every method are allowed, subject to the conditions of the
+// route.
+func (r *Router) AnyMethod(path string) bool {
+	return r.anyMethod(r.pathSegments(path))
+}
+
+// anyMethod returns true if an enabled MethodAny route matches the path.
+func (r *Router) anyMethod(segs []string) bool {
+	for _, route := range r.snapshot() {
+		if route.method != MethodAny || route.disabled {
+			continue
+		}
+		if _, ok := route.match(context.Background(), r, segs); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed returns the sorted methods of the routes that match the path.
+func (r *Router) allowed(segs []string) []string {
+	seen := make(map[string]bool)
+	for _, route := range r.snapshot() {
+		if route.method == MethodAny || route.disabled || seen[route.method] {
+			continue
+		}
+		if _, ok := route.match(context.Background(), r, segs); ok {
+			seen[route.method] = true
+		}
+	}
+
+	methods := make([]string, 0, len(seen))
+	for m := range seen {
+		methods = append(methods, strings.ToUpper(m))
+	}
+	sort.Strings(methods)
+	return methods
+}
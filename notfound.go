@@ -0,0 +1,72 @@
+package away
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// notFoundContextKey is the context key for the details of a request that
+// no route matched.
+type notFoundContextKey struct{}
+
+// defaultNotFoundCandidates is the number of candidates in NotFoundDetails
+// if suggestions are disabled.
+const defaultNotFoundCandidates = 5
+
+// NotFoundDetails describes a request passed to the NotFound handler.
+type NotFoundDetails struct {
+	// Method is the method of the request.
+	Method string
+	// Path is the cleaned path that was matched against the routes.
+	Path string
+	// Candidates are the routes closest to the request, nearest first.
+	Candidates []Suggestion
+}
+
+// notFoundState computes the candidates the first time they are requested.
+type notFoundState struct {
+	router *Router
+	req    *http.Request
+	segs   []string
+
+	once    sync.Once
+	details NotFoundDetails
+}
+
+// NotFoundInfo returns the method, cleaned path, and nearest routes of a
+// request that no route matched, for custom 404 pages and loggers. The
+// candidates are only computed when it is called. Returns false outside of
+// the NotFound handler.
+func NotFoundInfo(ctx context.Context) (NotFoundDetails, bool) {
+	s, ok := ctx.Value(notFoundContextKey{}).(*notFoundState)
+	if !ok {
+		return NotFoundDetails{}, false
+	}
+
+	s.once.Do(func() {
+		max := s.router.suggestions
+		if max <= 0 {
+			max = defaultNotFoundCandidates
+		}
+		s.details.Candidates = s.router.nearest(s.req, s.segs, max)
+	})
+	return s.details, true
+}
+
+// withNotFound returns a copy of the request with the details for
+// NotFoundInfo. The segments are copied because they are reused.
+func (r *Router) withNotFound(req *http.Request, segs []string) *http.Request {
+	segs = append([]string(nil), segs...)
+	s := &notFoundState{
+		router: r,
+		req:    req,
+		segs:   segs,
+		details: NotFoundDetails{
+			Method: req.Method,
+			Path:   "/" + strings.Join(segs, "/"),
+		},
+	}
+	return req.WithContext(context.WithValue(req.Context(), notFoundContextKey{}, s))
+}
@@ -0,0 +1,44 @@
+package away
+
+import "context"
+
+// paramsKey is the context key for the path parameters in pattern order. All
+// parameters are stored under this one unexported key so a parameter name
+// can't collide with a context key set by other packages.
+type paramsKey struct{}
+
+// PathParam is a path parameter. It is named PathParam because Param is the
+// function that returns a parameter value.
+type PathParam struct {
+	Name  string
+	Value string
+}
+
+// withParams returns a context with the path parameters.
+func withParams(ctx context.Context, params []PathParam) context.Context {
+	if len(params) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, paramsKey{}, params)
+}
+
+// Params returns the path parameters from the specified Context by name. A
+// name that appears more than once in the pattern has a value for each
+// occurrence in order, while Param returns the last one.
+func Params(ctx context.Context) map[string][]string {
+	params, _ := ctx.Value(paramsKey{}).([]PathParam)
+	out := make(map[string][]string, len(params))
+	for _, p := range params {
+		out[p.Name] = append(out[p.Name], p.Value)
+	}
+	return out
+}
+
+// ParamList returns the path parameters from the specified Context in the
+// order they appear in the pattern.
+func ParamList(ctx context.Context) []PathParam {
+	params, _ := ctx.Value(paramsKey{}).([]PathParam)
+	out := make([]PathParam, len(params))
+	copy(out, params)
+	return out
+}
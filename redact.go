@@ -0,0 +1,205 @@
+package away
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the values of sensitive params, headers, and query
+// values in logs.
+const Redacted = "REDACTED"
+
+// redactKey is the route value key for the names of sensitive values.
+type redactKey struct{}
+
+// defaultRedacted are the headers that are always redacted.
+var defaultRedacted = map[string]bool{
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// redactSet is a set of names redacted for every route of a router.
+type redactSet struct {
+	mu    sync.RWMutex
+	names map[string]bool
+}
+
+// has returns true if the lower case name is in the set.
+func (s *redactSet) has(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.names[name]
+}
+
+// Redact marks headers and query values with the names as sensitive for
+// every route, including routes registered later, such as the header of an
+// API key. Names are case insensitive.
+func (r *Router) Redact(names ...string) {
+	r.redacted.mu.Lock()
+	defer r.redacted.mu.Unlock()
+	if r.redacted.names == nil {
+		r.redacted.names = make(map[string]bool)
+	}
+	for _, name := range names {
+		if name != "" {
+			r.redacted.names[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// Redact marks path params, headers, and query values with the names as
+// sensitive so logging, audit, and recording mask them. Names are case
+// insensitive.
+func (r *Route) Redact(names ...string) *Route {
+	existing, _ := r.Value(redactKey{}).(map[string]bool)
+	set := make(map[string]bool, len(existing)+len(names))
+	for k := range existing {
+		set[k] = true
+	}
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return r.WithValue(redactKey{}, set)
+}
+
+// IsRedacted returns true if the value with the name is sensitive for the
+// route or its router. The Authorization, Cookie, and Set-Cookie headers are
+// always sensitive. The route can be nil.
+func (r *Route) IsRedacted(name string) bool {
+	name = strings.ToLower(name)
+	if defaultRedacted[name] {
+		return true
+	}
+	if r == nil {
+		return false
+	}
+	if r.redacted != nil && r.redacted.has(name) {
+		return true
+	}
+	set, _ := r.Value(redactKey{}).(map[string]bool)
+	return set[name]
+}
+
+// RedactHeader returns a copy of the header with sensitive values masked.
+func (r *Route) RedactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for name, values := range out {
+		if r.IsRedacted(name) {
+			for i := range values {
+				values[i] = Redacted
+			}
+		}
+	}
+	return out
+}
+
+// RedactURL returns the URL as a string with sensitive path params and
+// query values masked.
+func (r *Route) RedactURL(u *url.URL) string {
+	c := *u
+	changed := false
+	if r != nil {
+		segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for i, seg := range r.segs {
+			if i < len(segs) && strings.HasPrefix(seg, ":") && r.IsRedacted(seg[1:]) {
+				segs[i] = Redacted
+				changed = true
+			}
+		}
+		if changed {
+			c.Path = "/" + strings.Join(segs, "/")
+			c.RawPath = ""
+		}
+	}
+
+	q := u.Query()
+	for name, values := range q {
+		if r.IsRedacted(name) {
+			for i := range values {
+				values[i] = Redacted
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+
+	if len(q) > 0 {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// RedactBody returns the body with the values of sensitive fields masked.
+// JSON objects are redacted at any depth and form bodies by key. Bodies of
+// other content types, or that can't be parsed, such as a truncated sample,
+// are returned unchanged.
+func (r *Route) RedactBody(contentType string, body string) string {
+	if body == "" {
+		return body
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		dec := json.NewDecoder(strings.NewReader(body))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil || !r.redactJSON(v) {
+			return body
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return string(b)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(body)
+		if err != nil {
+			return body
+		}
+		changed := false
+		for name, vs := range values {
+			if r.IsRedacted(name) {
+				for i := range vs {
+					vs[i] = Redacted
+				}
+				changed = true
+			}
+		}
+		if !changed {
+			return body
+		}
+		return values.Encode()
+	}
+	return body
+}
+
+// redactJSON masks the sensitive fields of the decoded JSON value in place
+// and returns true if any were found.
+func (r *Route) redactJSON(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if r.IsRedacted(name) {
+				v[name] = Redacted
+				changed = true
+			} else if r.redactJSON(child) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if r.redactJSON(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// logLevelKey is the route value key for the log level of a route.
+type logLevelKey struct{}
+
+// bodySampleSize is the number of request body bytes logged at debug level.
+const bodySampleSize = 1024
+
+// LogLevel sets the level of the access log entries of the route. Use
+// slog.LevelDebug to silence a noisy route, such as a health check, unless
+// the logger is at debug level, when its entries also include the params,
+// the redacted headers, and a sample of the request body. It is used by the
+// AccessLog middleware.
+func (rt *Route) LogLevel(level slog.Level) *Route {
+	rt.route.WithValue(logLevelKey{}, level)
+	return rt
+}
+
+// AccessLog returns middleware for Use that logs each request with the
+// method, redacted URL, route pattern, status, and duration at the level set
+// with LogLevel or info. Entries at debug level also have the params, the
+// headers, and the first kilobyte of the body the handler read. Params,
+// headers, and the fields of JSON and form body samples marked with Redact
+// are masked. A JSON sample cut off at the kilobyte can't be parsed, so it
+// isn't masked.
+func (m *Mux) AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			level := slog.LevelInfo
+			if route != nil {
+				if l, ok := route.Value(logLevelKey{}).(slog.Level); ok {
+					level = l
+				}
+			}
+			if !logger.Enabled(r.Context(), level) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			verbose := level <= slog.LevelDebug
+			var sample *sampleReader
+			if verbose && r.Body != nil && r.Body != http.NoBody {
+				sample = &sampleReader{ReadCloser: r.Body}
+				r.Body = sample
+			}
+
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("url", route.RedactURL(r.URL)),
+				slog.Int("status", sw.Status()),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if route != nil {
+				attrs = append(attrs, slog.String("pattern", route.Pattern()))
+			}
+			if verbose {
+				params := make([]any, 0)
+				for name, value := range routeParams(r, route) {
+					params = append(params, slog.String(name, value))
+				}
+				headers := make([]any, 0, len(r.Header))
+				for name, values := range route.RedactHeader(r.Header) {
+					headers = append(headers, slog.Any(name, values))
+				}
+				attrs = append(attrs, slog.Group("params", params...), slog.Group("headers", headers...))
+				if sample != nil {
+					attrs = append(attrs, slog.String("body", route.RedactBody(r.Header.Get("Content-Type"), string(sample.sample))))
+				}
+			}
+
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// sampleReader keeps the first bytes read from a request body.
+type sampleReader struct {
+	io.ReadCloser
+	sample []byte
+}
+
+func (s *sampleReader) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if room := bodySampleSize - len(s.sample); room > 0 && n > 0 {
+		if n < room {
+			room = n
+		}
+		s.sample = append(s.sample, p[:room]...)
+	}
+	return n, err
+}
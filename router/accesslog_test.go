@@ -0,0 +1,72 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+
+	mux := New()
+	mux.Use(mux.AccessLog(logger))
+	handler := func(w http.ResponseWriter, r *http.Request) error {
+		ioutil.ReadAll(r.Body)
+		return nil
+	}
+	mux.Get("/health", handler).LogLevel(slog.LevelDebug)
+	mux.Post("/users/{token}", handler).LogLevel(slog.LevelDebug).Redact("token", "password")
+	mux.Get("/users", handler)
+
+	entries := func() []map[string]interface{} {
+		out := make([]map[string]interface{}, 0)
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			entry := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+			out = append(out, entry)
+		}
+		buf.Reset()
+		return out
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users?page=2", nil))
+	logged := entries()
+	if assert.Len(t, logged, 1) {
+		assert.Equal(t, "INFO", logged[0]["level"])
+		assert.Equal(t, "/users?page=2", logged[0]["url"])
+		assert.Equal(t, "/users", logged[0]["pattern"])
+		assert.Equal(t, float64(200), logged[0]["status"])
+		assert.Nil(t, logged[0]["headers"])
+	}
+
+	level.Set(slog.LevelDebug)
+	r := httptest.NewRequest("POST", "/users/secret", strings.NewReader(`{"name":"bob","password":"secret"}`))
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	logged = entries()
+	if assert.Len(t, logged, 1) {
+		assert.Equal(t, "DEBUG", logged[0]["level"])
+		assert.Equal(t, "/users/REDACTED", logged[0]["url"])
+		assert.Equal(t, map[string]interface{}{"token": "REDACTED"}, logged[0]["params"])
+		assert.Equal(t, map[string]interface{}{
+			"Authorization": []interface{}{"REDACTED"},
+			"Content-Type":  []interface{}{"application/json"},
+		}, logged[0]["headers"])
+		assert.Equal(t, `{"name":"bob","password":"REDACTED"}`, logged[0]["body"])
+	}
+}
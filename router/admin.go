@@ -0,0 +1,135 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ambientkit/away"
+	"github.com/ambientkit/away/router/paramconvert"
+)
+
+// ErrAdminRoute is returned when the admin API is asked to disable one of
+// its own routes.
+var ErrAdminRoute = errors.New("router: admin routes can't be disabled")
+
+// adminKey is the route value key for the routes of the admin API.
+type adminKey struct{}
+
+// isAdminRoute returns true if the route is part of the admin API.
+func isAdminRoute(route *away.Route) bool {
+	return route != nil && route.Value(adminKey{}) != nil
+}
+
+// RouteToggle is the body of the admin request to enable or disable a route.
+type RouteToggle struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Enabled bool   `json:"enabled"`
+}
+
+// AdminConfig is the response of the admin config endpoint.
+type AdminConfig struct {
+	// Middleware are the names of the middleware added with Use.
+	Middleware []string `json:"middleware"`
+	// Config is the config set with ApplyConfig.
+	Config Config `json:"config"`
+}
+
+// AdminAPI registers JSON endpoints under the prefix, such as /-/router, for
+// managing the router at runtime. Every endpoint is wrapped in the auth
+// middleware, which must reject requests that aren't from an operator.
+//
+//	GET   {prefix}/routes       lists the routes
+//	PATCH {prefix}/routes       enables or disables a route with a RouteToggle
+//	GET   {prefix}/config       returns the middleware and the Config
+//	PUT   {prefix}/config       applies a Config
+//	PUT   {prefix}/maintenance  turns maintenance mode on or off with
+//	                            {"enabled": true}
+//	GET   {prefix}/inflight     lists the requests being served
+//
+// Maintenance mode and the rest of the Config are enforced by the
+// RuntimeConfig middleware. The admin routes are served in maintenance mode
+// and can't be disabled, so operators can't lock themselves out.
+func (m *Mux) AdminAPI(prefix string, auth func(http.Handler) http.Handler) *Group {
+	if auth == nil {
+		panic("router: AdminAPI requires auth middleware")
+	}
+
+	g := m.Group(prefix).Use(auth)
+
+	g.Get("/routes", func(w http.ResponseWriter, r *http.Request) error {
+		routes := make([]away.RouteInfo, 0)
+		m.Walk(func(route away.RouteInfo) error {
+			routes = append(routes, route)
+			return nil
+		})
+		return m.Respond(w, r, http.StatusOK, routes)
+	}).WithValue(adminKey{}, true)
+
+	g.Patch("/routes", func(w http.ResponseWriter, r *http.Request) error {
+		var toggle RouteToggle
+		if err := m.Bind(r, &toggle); err != nil {
+			return err
+		}
+		if !toggle.Enabled && m.adminRoute(toggle.Method, toggle.Pattern) {
+			return StatusError{Code: http.StatusBadRequest, Err: ErrAdminRoute}
+		}
+		if !m.SetEnabled(toggle.Method, toggle.Pattern, toggle.Enabled) {
+			return StatusError{Code: http.StatusNotFound}
+		}
+		return m.Respond(w, r, http.StatusOK, toggle)
+	}).WithValue(adminKey{}, true)
+
+	g.Get("/config", func(w http.ResponseWriter, r *http.Request) error {
+		return m.Respond(w, r, http.StatusOK, AdminConfig{
+			Middleware: append([]string{}, m.middleware...),
+			Config:     m.Config(),
+		})
+	}).WithValue(adminKey{}, true)
+
+	g.Put("/config", func(w http.ResponseWriter, r *http.Request) error {
+		var cfg Config
+		if err := m.Bind(r, &cfg); err != nil {
+			return err
+		}
+		if err := m.ApplyConfig(cfg); err != nil {
+			return StatusError{Code: http.StatusBadRequest, Err: err}
+		}
+		return m.Respond(w, r, http.StatusOK, m.Config())
+	}).WithValue(adminKey{}, true)
+
+	g.Put("/maintenance", func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := m.Bind(r, &body); err != nil {
+			return err
+		}
+		cfg, err := m.updateConfig(func(cfg *Config) {
+			cfg.Maintenance = body.Enabled
+		})
+		if err != nil {
+			return StatusError{Code: http.StatusBadRequest, Err: err}
+		}
+		return m.Respond(w, r, http.StatusOK, cfg)
+	}).WithValue(adminKey{}, true)
+
+	g.Get("/inflight", func(w http.ResponseWriter, r *http.Request) error {
+		return m.Respond(w, r, http.StatusOK, m.Inflight())
+	}).WithValue(adminKey{}, true)
+
+	return g
+}
+
+// adminRoute returns true if the route with the method and pattern is part
+// of the admin API.
+func (m *Mux) adminRoute(method string, pattern string) bool {
+	pattern = paramconvert.BraceToColon(pattern)
+	for _, route := range m.router.Routes() {
+		if strings.EqualFold(route.Method(), method) && route.Pattern() == pattern {
+			return isAdminRoute(route)
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAPI(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.RuntimeConfig())
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	mux.AdminAPI("/-/router", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer admin" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer admin")
+		r.RemoteAddr = "10.0.0.1:1234"
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/-/router/routes", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = admin("GET", "/-/router/routes", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var routes []away.RouteInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &routes))
+	assert.Len(t, routes, 8)
+	assert.Equal(t, "router.TestAdminAPI.func2", routes[7].Handler)
+
+	// Toggle a route.
+	w = admin("PATCH", "/-/router/routes", `{"method":"GET","pattern":"/users/{id}","enabled":false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, get("/users/1"))
+	admin("PATCH", "/-/router/routes", `{"method":"GET","pattern":"/users/{id}","enabled":true}`)
+	assert.Equal(t, http.StatusOK, get("/users/1"))
+
+	w = admin("PATCH", "/-/router/routes", `{"method":"GET","pattern":"/missing","enabled":true}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Maintenance mode.
+	w = admin("PUT", "/-/router/config", `{"maintenanceAllow":["10.0.0.0/8"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = admin("PUT", "/-/router/maintenance", `{"enabled":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/"))
+	assert.Equal(t, []string{"10.0.0.0/8"}, mux.Config().MaintenanceAllow)
+
+	w = admin("GET", "/-/router/config", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var cfg AdminConfig
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &cfg))
+	assert.True(t, cfg.Config.Maintenance)
+	assert.Len(t, cfg.Middleware, 1)
+
+	admin("PUT", "/-/router/maintenance", `{"enabled":false}`)
+	assert.Equal(t, http.StatusOK, get("/"))
+
+	// The admin API is still served to operators outside the allowed
+	// addresses, so maintenance can be turned off again.
+	outside := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer admin")
+		r.RemoteAddr = "192.0.2.1:1234"
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	w = outside("PUT", "/-/router/maintenance", `{"enabled":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/"))
+	assert.Equal(t, http.StatusOK, outside("GET", "/-/router/routes", "").Code)
+	w = outside("PUT", "/-/router/maintenance", `{"enabled":false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, get("/"))
+
+	// The admin routes can't be disabled.
+	w = admin("PATCH", "/-/router/routes", `{"method":"PATCH","pattern":"/-/router/routes","enabled":false}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusOK, admin("GET", "/-/router/routes", "").Code)
+
+	w = admin("PUT", "/-/router/config", `{"maintenanceAllow":["nope"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Panics(t, func() {
+		mux.AdminAPI("/admin", nil)
+	})
+}
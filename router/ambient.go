@@ -0,0 +1,82 @@
+package router
+
+import (
+	"net/http"
+)
+
+// AmbientRouter is the router interface of Ambient plugins. It is declared
+// here so the adapter doesn't depend on the ambient module and is checked
+// against the signatures used by Ambient.
+type AmbientRouter interface {
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+	Clear(method string, path string)
+	SetNotFound(notFound http.Handler)
+	SetServeHTTP(h func(w http.ResponseWriter, r *http.Request, err error))
+	StatusError(status int, err error) error
+
+	Handle(method string, path string, fn func(http.ResponseWriter, *http.Request) error)
+	Get(path string, fn func(http.ResponseWriter, *http.Request) error)
+	Post(path string, fn func(http.ResponseWriter, *http.Request) error)
+	Patch(path string, fn func(http.ResponseWriter, *http.Request) error)
+	Put(path string, fn func(http.ResponseWriter, *http.Request) error)
+	Head(path string, fn func(http.ResponseWriter, *http.Request) error)
+	Options(path string, fn func(http.ResponseWriter, *http.Request) error)
+	Delete(path string, fn func(http.ResponseWriter, *http.Request) error)
+	Error(status int, w http.ResponseWriter, r *http.Request)
+	Param(r *http.Request, name string) string
+	Wrap(handler http.HandlerFunc) func(w http.ResponseWriter, r *http.Request) (err error)
+}
+
+// Ambient adapts a Mux to the AmbientRouter interface. The route methods
+// don't return the route so they match the Ambient signatures. Use the Mux
+// for route options.
+type Ambient struct {
+	*Mux
+}
+
+var _ AmbientRouter = Ambient{}
+
+// Ambient returns an adapter for the Ambient plugin router interface.
+func (m *Mux) Ambient() Ambient {
+	return Ambient{Mux: m}
+}
+
+// Handle registers a method and pattern.
+func (a Ambient) Handle(method string, path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Handle(method, path, fn)
+}
+
+// Delete registers a pattern.
+func (a Ambient) Delete(path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Delete(path, fn)
+}
+
+// Get registers a pattern.
+func (a Ambient) Get(path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Get(path, fn)
+}
+
+// Head registers a pattern.
+func (a Ambient) Head(path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Head(path, fn)
+}
+
+// Options registers a pattern.
+func (a Ambient) Options(path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Options(path, fn)
+}
+
+// Patch registers a pattern.
+func (a Ambient) Patch(path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Patch(path, fn)
+}
+
+// Post registers a pattern.
+func (a Ambient) Post(path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Post(path, fn)
+}
+
+// Put registers a pattern.
+func (a Ambient) Put(path string, fn func(http.ResponseWriter, *http.Request) error) {
+	a.Mux.Put(path, fn)
+}
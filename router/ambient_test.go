@@ -0,0 +1,65 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmbient(t *testing.T) {
+	var ar AmbientRouter = New().Ambient()
+
+	var status int
+	ar.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		if e, ok := err.(Error); ok {
+			status = e.Status()
+			http.Error(w, e.Message(), status)
+		}
+	})
+	ar.SetNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ar.Error(http.StatusNotFound, w, r)
+	}))
+
+	for _, method := range []string{"DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT"} {
+		register := map[string]func(string, func(http.ResponseWriter, *http.Request) error){
+			"DELETE":  ar.Delete,
+			"GET":     ar.Get,
+			"HEAD":    ar.Head,
+			"OPTIONS": ar.Options,
+			"PATCH":   ar.Patch,
+			"POST":    ar.Post,
+			"PUT":     ar.Put,
+		}[method]
+
+		matched := ""
+		register("/user/{name}", func(w http.ResponseWriter, r *http.Request) error {
+			matched = r.Method + " " + ar.Param(r, "name")
+			return nil
+		})
+		ar.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/user/bob", nil))
+		assert.Equal(t, method+" bob", matched)
+	}
+
+	ar.Handle("GET", "/fail", func(w http.ResponseWriter, r *http.Request) error {
+		return ar.StatusError(http.StatusTeapot, errors.New("fail"))
+	})
+	ar.Get("/wrapped", ar.Wrap(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	w := httptest.NewRecorder()
+	ar.ServeHTTP(w, httptest.NewRequest("GET", "/fail", nil))
+	assert.Equal(t, http.StatusTeapot, status)
+
+	w = httptest.NewRecorder()
+	ar.ServeHTTP(w, httptest.NewRequest("GET", "/wrapped", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	ar.Clear("GET", "/wrapped")
+	w = httptest.NewRecorder()
+	ar.ServeHTTP(w, httptest.NewRequest("GET", "/wrapped", nil))
+	assert.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,226 @@
+package router
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidAPIKey is returned when a request has an API key that isn't in
+// the key store or has expired.
+var ErrInvalidAPIKey = errors.New("router: invalid API key")
+
+// APIKeyHeader is the default request header containing the API key.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyKey is the store key for the API key of the request.
+type apiKeyKey struct{}
+
+// APIKey is an API key in a key store. The secret isn't part of it.
+type APIKey struct {
+	// ID identifies the key in logs and when tracking use. It must not be
+	// the secret.
+	ID string
+	// Principal is the user or client the key belongs to. If nil, the key is
+	// its own principal with the key ID.
+	Principal Principal
+	// Scopes are the permissions granted to the key.
+	Scopes []string
+	// Expires is when the key stops being valid. Zero means never.
+	Expires time.Time
+	// LastUsed is when the key was last used.
+	LastUsed time.Time
+}
+
+// KeyStore looks up API keys.
+type KeyStore interface {
+	// Lookup returns the key for the secret, or nil if there isn't one.
+	Lookup(secret string) (*APIKey, error)
+	// Touch records that the key with the ID was used.
+	Touch(id string, at time.Time) error
+}
+
+// APIKeyConfig contains the settings for the APIKeys middleware.
+type APIKeyConfig struct {
+	// Store looks up the keys.
+	Store KeyStore
+	// Header is the request header containing the key. It defaults to
+	// X-API-Key.
+	Header string
+	// Bearer also reads the key from a bearer token in the Authorization
+	// header. Leave it off if other bearer tokens, such as OpenID Connect
+	// tokens, are used.
+	Bearer bool
+	// Query is the query parameter containing the key. If empty, keys are
+	// only read from headers.
+	Query string
+	// Required rejects requests without a key. Otherwise they are passed on
+	// without a principal so routes can use other authentication.
+	Required bool
+	// OnError is called when the store fails to record the use of a key.
+	// The request is still served.
+	OnError func(err error)
+}
+
+// keyPrincipal is the principal of a key without one.
+type keyPrincipal string
+
+func (p keyPrincipal) ID() string {
+	return string(p)
+}
+
+// APIKeys returns middleware for Use that authenticates requests with an API
+// key from the store. The principal of the key is set with WithPrincipal and
+// the key is returned by CurrentAPIKey, so its scopes can be checked by an
+// Authorizer such as ScopeAuthorizer. Requests with a key that isn't valid,
+// or without a key if it is required, are passed to the ServeHTTP function
+// as a 401 StatusError. A store error is passed as a 500 StatusError. The
+// header and query parameter are redacted on every route so keys don't
+// appear in logs and recordings.
+func (m *Mux) APIKeys(config APIKeyConfig) func(http.Handler) http.Handler {
+	if config.Header == "" {
+		config.Header = APIKeyHeader
+	}
+	m.Redact(config.Header, config.Query)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := apiKeySecret(r, config)
+			if secret == "" {
+				if config.Required {
+					m.serveError(w, r, StatusError{Code: http.StatusUnauthorized, Err: ErrUnauthenticated, ErrorCode: ErrorCodeUnauthenticated})
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := config.Store.Lookup(secret)
+			if err != nil {
+				m.serveError(w, r, StatusError{Code: http.StatusInternalServerError, Err: err})
+				return
+			}
+			now := time.Now()
+			if key == nil || (!key.Expires.IsZero() && !now.Before(key.Expires)) {
+				m.serveError(w, r, StatusError{Code: http.StatusUnauthorized, Err: ErrInvalidAPIKey, ErrorCode: ErrorCodeUnauthenticated})
+				return
+			}
+
+			if err := config.Store.Touch(key.ID, now); err != nil && config.OnError != nil {
+				config.OnError(err)
+			}
+
+			p := key.Principal
+			if p == nil {
+				p = keyPrincipal(key.ID)
+			}
+			r = Set(r, apiKeyKey{}, *key)
+			next.ServeHTTP(w, WithPrincipal(r, p))
+		})
+	}
+}
+
+// apiKeySecret returns the API key of the request from the header, a bearer
+// token if enabled, or the query parameter.
+func apiKeySecret(r *http.Request, config APIKeyConfig) string {
+	if v := r.Header.Get(config.Header); v != "" {
+		return v
+	}
+	if v := r.Header.Get("Authorization"); config.Bearer && len(v) > 7 && strings.EqualFold(v[:7], "bearer ") {
+		return strings.TrimSpace(v[7:])
+	}
+	if config.Query != "" {
+		return r.URL.Query().Get(config.Query)
+	}
+	return ""
+}
+
+// CurrentAPIKey returns the API key the request was authenticated with by
+// the APIKeys middleware.
+func CurrentAPIKey(r *http.Request) (APIKey, bool) {
+	key, ok := Get(r, apiKeyKey{}).(APIKey)
+	return key, ok
+}
+
+// ScopeAuthorizer is an Authorizer that allows requests authenticated with an
+// API key that has all the required scopes. Requests authenticated another
+// way are denied.
+var ScopeAuthorizer = AuthorizerFunc(func(r *http.Request, p Principal, required []string) (bool, error) {
+	key, ok := CurrentAPIKey(r)
+	if !ok {
+		return false, nil
+	}
+	scopes := make(map[string]bool, len(key.Scopes))
+	for _, s := range key.Scopes {
+		scopes[s] = true
+	}
+	for _, v := range required {
+		if !scopes[v] {
+			return false, nil
+		}
+	}
+	return true, nil
+})
+
+// MemoryKeyStore is an in-memory KeyStore. It keeps hashes of the secrets.
+type MemoryKeyStore struct {
+	mu   sync.Mutex
+	keys map[[sha256.Size]byte]*APIKey
+}
+
+// NewMemoryKeyStore returns an in-memory KeyStore.
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{
+		keys: make(map[[sha256.Size]byte]*APIKey),
+	}
+}
+
+// Add adds a key with the secret, replacing any key with the same secret.
+func (s *MemoryKeyStore) Add(secret string, key APIKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key.Scopes = append([]string(nil), key.Scopes...)
+	s.keys[sha256.Sum256([]byte(secret))] = &key
+}
+
+// Remove removes the key with the ID.
+func (s *MemoryKeyStore) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for h, key := range s.keys {
+		if key.ID == id {
+			delete(s.keys, h)
+		}
+	}
+}
+
+// Lookup returns a copy of the key for the secret, or nil if there isn't one.
+func (s *MemoryKeyStore) Lookup(secret string) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[sha256.Sum256([]byte(secret))]
+	if !ok {
+		return nil, nil
+	}
+	k := *key
+	return &k, nil
+}
+
+// Touch sets the last used time of the key with the ID.
+func (s *MemoryKeyStore) Touch(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.ID == id {
+			key.LastUsed = at
+		}
+	}
+	return nil
+}
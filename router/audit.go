@@ -0,0 +1,96 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// auditedKey is the route value key for audited routes.
+type auditedKey struct{}
+
+// AuditRecord describes a request to an audited route.
+type AuditRecord struct {
+	Time      time.Time
+	Principal string
+	Method    string
+	Path      string
+	Pattern   string
+	Params    map[string]string
+	Status    int
+	Duration  time.Duration
+	RequestID string
+}
+
+// AuditSink receives audit records.
+type AuditSink interface {
+	Audit(record AuditRecord)
+}
+
+// AuditSinkFunc is a function that implements AuditSink.
+type AuditSinkFunc func(record AuditRecord)
+
+// Audit calls fn.
+func (fn AuditSinkFunc) Audit(record AuditRecord) {
+	fn(record)
+}
+
+// Audited marks the route so requests to it are sent to the audit sink.
+func (rt *Route) Audited() *Route {
+	rt.route.WithValue(auditedKey{}, true)
+	return rt
+}
+
+// Audit returns middleware for Use that sends a record of each request to a
+// route marked with Audited to the sink. The principal is read with
+// CurrentPrincipal so authentication middleware must run first. The request
+// ID is read from the X-Request-ID request or response header.
+func (m *Mux) Audit(sink AuditSink) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			if route == nil || route.Value(auditedKey{}) == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			record := AuditRecord{
+				Time:      start,
+				Method:    r.Method,
+				Path:      route.RedactURL(&url.URL{Path: r.URL.Path}),
+				Pattern:   route.Pattern(),
+				Params:    routeParams(r, route),
+				Status:    sw.Status(),
+				Duration:  time.Since(start),
+				RequestID: r.Header.Get("X-Request-ID"),
+			}
+			if record.RequestID == "" {
+				record.RequestID = w.Header().Get("X-Request-ID")
+			}
+			if p := CurrentPrincipal(r); p != nil {
+				record.Principal = p.ID()
+			}
+
+			sink.Audit(record)
+		})
+	}
+}
+
+// routeParams returns the values of the parameters in the route pattern with
+// sensitive values redacted.
+func routeParams(r *http.Request, route *away.Route) map[string]string {
+	params := make(map[string]string)
+	for _, p := range away.ParamList(r.Context()) {
+		params[p.Name] = p.Value
+		if route.IsRedacted(p.Name) {
+			params[p.Name] = away.Redacted
+		}
+	}
+	return params
+}
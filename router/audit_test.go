@@ -0,0 +1,61 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	records := make([]AuditRecord, 0)
+	mux.Use(testAuthenticate, mux.Audit(AuditSinkFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})))
+
+	mux.Delete("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return StatusError{Code: http.StatusForbidden}
+	}).Audited()
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	r := httptest.NewRequest("DELETE", "/users/42", nil)
+	r.Header.Set("X-User", "admin")
+	r.Header.Set("X-Request-ID", "req-1")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	assert.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, "1", record.Principal)
+	assert.Equal(t, "DELETE", record.Method)
+	assert.Equal(t, "/users/42", record.Path)
+	assert.Equal(t, "/users/:id", record.Pattern)
+	assert.Equal(t, map[string]string{"id": "42"}, record.Params)
+	assert.Equal(t, http.StatusForbidden, record.Status)
+	assert.Equal(t, "req-1", record.RequestID)
+	assert.False(t, record.Time.IsZero())
+}
+
+func TestAuditRedact(t *testing.T) {
+	mux := New()
+
+	records := make([]AuditRecord, 0)
+	mux.Use(mux.Audit(AuditSinkFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})))
+	mux.Post("/reset/{token}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}).Audited().Redact("token")
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/reset/secret", nil))
+
+	assert.Len(t, records, 1)
+	assert.Equal(t, "/reset/REDACTED", records[0].Path)
+	assert.Equal(t, map[string]string{"token": "REDACTED"}, records[0].Params)
+}
@@ -0,0 +1,104 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+var (
+	// ErrUnauthenticated is returned when a route requires a principal and
+	// the request doesn't have one.
+	ErrUnauthenticated = errors.New("router: authentication required")
+	// ErrForbidden is returned when the principal doesn't have the
+	// permissions required by a route.
+	ErrForbidden = errors.New("router: permission denied")
+)
+
+// principalKey is the context key for the authenticated principal.
+type principalKey struct{}
+
+// requiresKey is the route value key for required permissions.
+type requiresKey struct{}
+
+// Principal is an authenticated user or client.
+type Principal interface {
+	ID() string
+}
+
+// Authorizer decides if a principal has the permissions required by a route.
+type Authorizer interface {
+	Authorize(r *http.Request, p Principal, required []string) (bool, error)
+}
+
+// AuthorizerFunc is a function that implements Authorizer.
+type AuthorizerFunc func(r *http.Request, p Principal, required []string) (bool, error)
+
+// Authorize calls fn.
+func (fn AuthorizerFunc) Authorize(r *http.Request, p Principal, required []string) (bool, error) {
+	return fn(r, p, required)
+}
+
+// WithPrincipal returns a copy of the request with the authenticated
+// principal. It is called by authentication middleware.
+func WithPrincipal(r *http.Request, p Principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+}
+
+// CurrentPrincipal returns the authenticated principal of the request or nil.
+func CurrentPrincipal(r *http.Request) Principal {
+	p, _ := r.Context().Value(principalKey{}).(Principal)
+	return p
+}
+
+// Requires sets the roles or permissions a principal needs to access the
+// route. It is enforced by the Authorize middleware.
+func (rt *Route) Requires(permissions ...string) *Route {
+	existing, _ := rt.route.Value(requiresKey{}).([]string)
+	rt.route.WithValue(requiresKey{}, append(append([]string(nil), existing...), permissions...))
+	return rt
+}
+
+// Required returns the permissions required by a route.
+func Required(route *away.Route) []string {
+	if route == nil {
+		return nil
+	}
+	required, _ := route.Value(requiresKey{}).([]string)
+	return required
+}
+
+// Authorize returns middleware for Use that enforces the permissions set with
+// Requires. Requests without a principal are passed to the ServeHTTP function
+// as a 401 StatusError and requests the authorizer denies as a 403
+// StatusError.
+func (m *Mux) Authorize(a Authorizer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required := Required(away.CurrentRoute(r.Context()))
+			if len(required) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p := CurrentPrincipal(r)
+			if p == nil {
+				m.serveError(w, r, StatusError{Code: http.StatusUnauthorized, Err: ErrUnauthenticated, ErrorCode: ErrorCodeUnauthenticated})
+				return
+			}
+
+			ok, err := a.Authorize(r, p, required)
+			if err != nil {
+				m.serveError(w, r, StatusError{Code: http.StatusInternalServerError, Err: err})
+				return
+			} else if !ok {
+				m.serveError(w, r, StatusError{Code: http.StatusForbidden, Err: ErrForbidden, ErrorCode: ErrorCodeForbidden})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
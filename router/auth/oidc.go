@@ -0,0 +1,431 @@
+// Package auth provides OpenID Connect login for a router.Mux.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ambientkit/away/router"
+)
+
+var (
+	// ErrInvalidState is returned when the login state of a callback is
+	// missing, expired, or doesn't match.
+	ErrInvalidState = errors.New("auth: invalid login state")
+	// ErrInvalidToken is returned when an ID token fails verification.
+	ErrInvalidToken = errors.New("auth: invalid ID token")
+	// ErrProvider is returned when the OpenID provider returns an error.
+	ErrProvider = errors.New("auth: provider error")
+)
+
+// Config contains the settings for OpenID Connect login.
+type Config struct {
+	// Issuer is the URL of the OpenID provider, such as
+	// https://accounts.google.com. The provider configuration is loaded from
+	// Issuer/.well-known/openid-configuration.
+	Issuer string
+	// ClientID and ClientSecret are the credentials of the app registered
+	// with the provider.
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute URL of the callback route, such as
+	// https://example.com/auth/callback. It must be registered with the
+	// provider.
+	RedirectURL string
+	// Scopes are requested in addition to openid. Defaults to profile and
+	// email.
+	Scopes []string
+	// Cookies encrypt the session and login state cookies.
+	Cookies *router.Cookies
+	// SessionName is the name of the session cookie. Defaults to auth.
+	SessionName string
+	// SessionMaxAge is how long a user stays logged in. Defaults to a day.
+	SessionMaxAge time.Duration
+	// Secure only sends the cookies over HTTPS.
+	Secure bool
+	// AfterLogin is the path users are sent to after login if the login
+	// route wasn't given a next path. Defaults to /.
+	AfterLogin string
+	// AfterLogout is the path users are sent to after logout. Defaults to /.
+	AfterLogout string
+	// Client makes the requests to the provider. http.DefaultClient is used
+	// if nil.
+	Client *http.Client
+}
+
+// User is the principal of a logged in user.
+type User struct {
+	Subject string    `json:"sub"`
+	Email   string    `json:"email,omitempty"`
+	Name    string    `json:"name,omitempty"`
+	Expires time.Time `json:"exp"`
+	Scope   []string  `json:"scope,omitempty"`
+}
+
+// ID returns the subject of the user at the provider.
+func (u User) ID() string {
+	return u.Subject
+}
+
+// Scopes returns the scopes granted by the provider, so the user is a
+// router.ScopedPrincipal.
+func (u User) Scopes() []string {
+	return u.Scope
+}
+
+// CurrentUser returns the user set by Authenticate or Require.
+func CurrentUser(r *http.Request) (User, bool) {
+	u, ok := router.CurrentPrincipal(r).(User)
+	return u, ok
+}
+
+// providerConfig is the OpenID provider configuration.
+type providerConfig struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// loginState is stored in a cookie between the login and callback routes.
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+}
+
+// OIDC logs users in with an OpenID provider using the authorization code
+// flow with PKCE.
+type OIDC struct {
+	config    Config
+	provider  providerConfig
+	mux       *router.Mux
+	loginPath string
+	keys      *keySet
+}
+
+// New loads the provider configuration and returns an OIDC.
+func New(ctx context.Context, config Config) (*OIDC, error) {
+	if config.Cookies == nil {
+		return nil, errors.New("auth: Cookies is required")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"profile", "email"}
+	}
+	if config.SessionName == "" {
+		config.SessionName = "auth"
+	}
+	if config.SessionMaxAge <= 0 {
+		config.SessionMaxAge = 24 * time.Hour
+	}
+	if config.AfterLogin == "" {
+		config.AfterLogin = "/"
+	}
+	if config.AfterLogout == "" {
+		config.AfterLogout = "/"
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	o := &OIDC{config: config}
+	issuer := strings.TrimSuffix(config.Issuer, "/")
+	if err := o.getJSON(ctx, issuer+"/.well-known/openid-configuration", &o.provider); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(o.provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: issuer %q doesn't match %q", ErrProvider, o.provider.Issuer, config.Issuer)
+	}
+	o.keys = &keySet{url: o.provider.JWKSURI, get: o.getJSON}
+
+	return o, nil
+}
+
+// Mount registers the routes under the prefix, such as /auth:
+//
+//	GET  {prefix}/login     redirects to the provider, with an optional
+//	                        next query parameter for the path to return to
+//	GET  {prefix}/callback  verifies the login and starts the session
+//	POST {prefix}/logout    ends the session
+func (o *OIDC) Mount(mux *router.Mux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	o.mux = mux
+	o.loginPath = prefix + "/login"
+
+	mux.Get(prefix+"/login", o.login)
+	mux.Get(prefix+"/callback", o.callback)
+	mux.Post(prefix+"/logout", o.logout)
+}
+
+// Authenticate is middleware for Use that sets the user of a valid session
+// as the principal, available via CurrentUser and router.CurrentPrincipal.
+func (o *OIDC) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, o.authenticate(r))
+	})
+}
+
+// Require is middleware for protected groups that requires a logged in
+// user. GET and HEAD requests without one are redirected to the login route
+// and return afterwards. Other requests are passed to the ServeHTTP function
+// of the Mux as a 401 StatusError.
+func (o *OIDC) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = o.authenticate(r)
+		if router.CurrentPrincipal(r) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && o.loginPath != "" {
+			http.Redirect(w, r, o.loginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+			return
+		}
+		err := router.StatusError{Code: http.StatusUnauthorized, Err: router.ErrUnauthenticated, ErrorCode: router.ErrorCodeUnauthenticated}
+		if o.mux == nil {
+			http.Error(w, http.StatusText(err.Code), err.Code)
+			return
+		}
+		o.mux.ServeError(w, r, err)
+	})
+}
+
+// authenticate returns the request with the user of the session as the
+// principal if it doesn't have one.
+func (o *OIDC) authenticate(r *http.Request) *http.Request {
+	if router.CurrentPrincipal(r) != nil {
+		return r
+	}
+
+	value, err := o.config.Cookies.GetEncrypted(r, o.config.SessionName)
+	if err != nil {
+		return r
+	}
+	var u User
+	if err := json.Unmarshal([]byte(value), &u); err != nil || time.Now().After(u.Expires) {
+		return r
+	}
+	return router.WithPrincipal(r, u)
+}
+
+// login redirects to the provider.
+func (o *OIDC) login(w http.ResponseWriter, r *http.Request) error {
+	ls := loginState{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		Next:     localPath(r.URL.Query().Get("next"), o.config.AfterLogin),
+	}
+	b, err := json.Marshal(ls)
+	if err != nil {
+		return err
+	}
+	if err := o.config.Cookies.SetEncrypted(w, &http.Cookie{
+		Name:     o.stateCookie(),
+		Value:    string(b),
+		Path:     "/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   o.config.Secure,
+		SameSite: http.SameSiteLaxMode,
+	}); err != nil {
+		return err
+	}
+
+	challenge := sha256.Sum256([]byte(ls.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.config.ClientID},
+		"redirect_uri":          {o.config.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, o.config.Scopes...), " ")},
+		"state":                 {ls.State},
+		"nonce":                 {ls.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(o.provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, o.provider.AuthorizationEndpoint+sep+q.Encode(), http.StatusFound)
+	return nil
+}
+
+// callback exchanges the code for an ID token and starts the session.
+func (o *OIDC) callback(w http.ResponseWriter, r *http.Request) error {
+	value, err := o.config.Cookies.GetEncrypted(r, o.stateCookie())
+	if err != nil {
+		return router.StatusError{Code: http.StatusBadRequest, Err: ErrInvalidState}
+	}
+	o.config.Cookies.Delete(w, o.stateCookie(), "/")
+
+	var ls loginState
+	q := r.URL.Query()
+	if err := json.Unmarshal([]byte(value), &ls); err != nil || ls.State == "" || q.Get("state") != ls.State {
+		return router.StatusError{Code: http.StatusBadRequest, Err: ErrInvalidState}
+	}
+	if e := q.Get("error"); e != "" {
+		return router.StatusError{Code: http.StatusUnauthorized, Err: fmt.Errorf("%w: %v: %v", ErrProvider, e, q.Get("error_description"))}
+	}
+
+	token, err := o.exchange(r.Context(), q.Get("code"), ls.Verifier)
+	if err != nil {
+		return router.StatusError{Code: http.StatusBadGateway, Err: err}
+	}
+	claims, err := o.verify(r.Context(), token.IDToken, ls.Nonce)
+	if err != nil {
+		return router.StatusError{Code: http.StatusUnauthorized, Err: err}
+	}
+
+	u := User{
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		Name:    claims.Name,
+		Expires: time.Now().Add(o.config.SessionMaxAge),
+		Scope:   strings.Fields(token.Scope),
+	}
+	b, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	if err := o.config.Cookies.SetEncrypted(w, &http.Cookie{
+		Name:     o.config.SessionName,
+		Value:    string(b),
+		Path:     "/",
+		MaxAge:   int(o.config.SessionMaxAge / time.Second),
+		HttpOnly: true,
+		Secure:   o.config.Secure,
+		SameSite: http.SameSiteLaxMode,
+	}); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, ls.Next, http.StatusFound)
+	return nil
+}
+
+// logout ends the session.
+func (o *OIDC) logout(w http.ResponseWriter, r *http.Request) error {
+	o.config.Cookies.Delete(w, o.config.SessionName, "/")
+	http.Redirect(w, r, o.config.AfterLogout, http.StatusSeeOther)
+	return nil
+}
+
+// tokenResponse is the response of the token endpoint.
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+	Scope   string `json:"scope"`
+}
+
+// exchange returns the tokens for an authorization code.
+func (o *OIDC) exchange(ctx context.Context, code string, verifier string) (tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.config.RedirectURL},
+		"client_id":     {o.config.ClientID},
+		"client_secret": {o.config.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.config.Client.Do(req)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return tokenResponse{}, fmt.Errorf("%w: token endpoint: %s", ErrProvider, resp.Status)
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return tokenResponse{}, fmt.Errorf("%w: token endpoint: %v", ErrProvider, err)
+	}
+	if token.IDToken == "" {
+		return tokenResponse{}, fmt.Errorf("%w: token endpoint: no id_token", ErrProvider)
+	}
+	return token, nil
+}
+
+// getJSON decodes the JSON response of a GET request.
+func (o *OIDC) getJSON(ctx context.Context, u string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := o.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s: %s", ErrProvider, u, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dst); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrProvider, u, err)
+	}
+	return nil
+}
+
+// stateCookie returns the name of the login state cookie.
+func (o *OIDC) stateCookie() string {
+	return o.config.SessionName + "_login"
+}
+
+// randomString returns 32 random bytes encoded for a URL.
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// localPath returns p if it is a path on this site, so the login route can't
+// redirect to another site. Otherwise, it returns fallback. Browsers strip
+// tabs and newlines and treat a backslash as a slash, so /\t/evil.com is
+// read as //evil.com; paths with either are rejected.
+func localPath(p string, fallback string) string {
+	if !strings.HasPrefix(p, "/") {
+		return fallback
+	}
+	for _, c := range p {
+		if c < ' ' || c == 0x7f || c == '\\' {
+			return fallback
+		}
+	}
+
+	u, err := url.Parse(p)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil {
+		return fallback
+	}
+	if strings.HasPrefix(u.Path, "//") || strings.HasPrefix(path.Clean(u.Path), "//") {
+		return fallback
+	}
+	return p
+}
@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ambientkit/away/router"
+	"github.com/stretchr/testify/assert"
+)
+
+// testProvider is a fake OpenID provider.
+type testProvider struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	nonce    string
+	verifier string
+	claims   map[string]interface{}
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	p := &testProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "code" || r.Form.Get("client_secret") != "secret" {
+			http.Error(w, "bad code", http.StatusBadRequest)
+			return
+		}
+		p.verifier = r.Form.Get("code_verifier")
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.sign(t), "scope": "openid posts:read"})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// sign returns an ID token for the claims.
+func (p *testProvider) sign(t *testing.T) string {
+	claims := map[string]interface{}{
+		"iss":   p.URL,
+		"sub":   "user1",
+		"aud":   []string{"client"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": p.nonce,
+		"email": "ada@example.com",
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "1"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// serve sends a request with the cookies and adds the response cookies.
+func serve(mux *router.Mux, method, target string, jar map[string]*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for _, c := range jar {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge < 0 {
+			delete(jar, c.Name)
+			continue
+		}
+		jar[c.Name] = c
+	}
+	return w
+}
+
+func TestOIDC(t *testing.T) {
+	p := newTestProvider(t)
+	o, err := New(context.Background(), Config{
+		Issuer:       p.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://example.com/auth/callback",
+		Cookies:      router.NewCookies([]byte("0123456789abcdef0123456789abcdef")),
+	})
+	assert.NoError(t, err)
+
+	var callbackErr error
+	mux := router.New()
+	mux.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		if err != nil {
+			callbackErr = err
+			var se router.StatusError
+			if errors.As(err, &se) {
+				w.WriteHeader(se.Status())
+			}
+		}
+	})
+	mux.Use(o.Authenticate, mux.EnforceScopes())
+	o.Mount(mux, "/auth")
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		if u, ok := CurrentUser(r); ok {
+			w.Write([]byte(u.Email))
+		}
+		return nil
+	})
+	account := mux.Group("/account").Use(o.Require)
+	settings := func(w http.ResponseWriter, r *http.Request) error {
+		u, _ := CurrentUser(r)
+		w.Write([]byte("settings " + u.ID()))
+		return nil
+	}
+	account.Get("/settings", settings)
+	account.Post("/settings", settings)
+	account.Get("/posts", settings).Scopes("posts:read")
+	account.Post("/posts", settings).Scopes("posts:write")
+
+	jar := make(map[string]*http.Cookie)
+
+	// Protected routes redirect to login.
+	w := serve(mux, "GET", "/account/settings", jar)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/auth/login?next=%2Faccount%2Fsettings", w.Header().Get("Location"))
+	w = serve(mux, "POST", "/account/settings", jar)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var authErr router.StatusError
+	if assert.True(t, errors.As(callbackErr, &authErr)) {
+		assert.Equal(t, router.ErrUnauthenticated, authErr.Err)
+		assert.Equal(t, router.ErrorCodeUnauthenticated, authErr.ErrorCode)
+	}
+
+	// Login redirects to the provider.
+	w = serve(mux, "GET", "/auth/login?next=/account/settings", jar)
+	assert.Equal(t, http.StatusFound, w.Code)
+	loc, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, p.URL+"/authorize", loc.Scheme+"://"+loc.Host+loc.Path)
+	q := loc.Query()
+	assert.Equal(t, "client", q.Get("client_id"))
+	assert.Equal(t, "openid profile email", q.Get("scope"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	p.nonce = q.Get("nonce")
+
+	// A callback with the wrong state is rejected.
+	w = serve(mux, "GET", "/auth/callback?code=code&state=wrong", map[string]*http.Cookie{"auth_login": jar["auth_login"]})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var se router.StatusError
+	if assert.True(t, errors.As(callbackErr, &se)) {
+		assert.Equal(t, ErrInvalidState, se.Err)
+	}
+
+	// The callback starts the session.
+	w = serve(mux, "GET", "/auth/callback?code=code&state="+q.Get("state"), jar)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/account/settings", w.Header().Get("Location"))
+	challenge := sha256.Sum256([]byte(p.verifier))
+	assert.Equal(t, q.Get("code_challenge"), base64.RawURLEncoding.EncodeToString(challenge[:]))
+	assert.NotContains(t, jar, "auth_login")
+
+	w = serve(mux, "GET", "/account/settings", jar)
+	assert.Equal(t, "settings user1", w.Body.String())
+	w = serve(mux, "GET", "/account/posts", jar)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(mux, "POST", "/account/posts", jar)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(mux, "GET", "/", jar)
+	assert.Equal(t, "ada@example.com", w.Body.String())
+
+	// Logout ends the session.
+	w = serve(mux, "POST", "/auth/logout", jar)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	w = serve(mux, "GET", "/", jar)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestOIDCVerify(t *testing.T) {
+	p := newTestProvider(t)
+	o, err := New(context.Background(), Config{
+		Issuer:   p.URL,
+		ClientID: "client",
+		Cookies:  router.NewCookies([]byte("0123456789abcdef0123456789abcdef")),
+	})
+	assert.NoError(t, err)
+	p.nonce = "nonce"
+
+	for name, claims := range map[string]map[string]interface{}{
+		"issuer":   {"iss": "https://evil.example.com"},
+		"audience": {"aud": "other"},
+		"expired":  {"exp": time.Now().Add(-time.Hour).Unix()},
+		"nonce":    {"nonce": "replayed"},
+	} {
+		p.claims = claims
+		_, err := o.verify(context.Background(), p.sign(t), "nonce")
+		assert.True(t, errors.Is(err, ErrInvalidToken), name)
+	}
+
+	p.claims = map[string]interface{}{"aud": "client"}
+	c, err := o.verify(context.Background(), p.sign(t), "nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", c.Subject)
+
+	// Tampered payload.
+	token := p.sign(t)
+	_, err = o.verify(context.Background(), token[:len(token)-4]+"AAAA", "nonce")
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+
+	assert.Equal(t, "/", localPath("//evil.example.com", "/"))
+	assert.Equal(t, "/", localPath("https://evil.example.com", "/"))
+	assert.Equal(t, "/", localPath("/\t/evil.example.com", "/"))
+	assert.Equal(t, "/", localPath("/\n/evil.example.com", "/"))
+	assert.Equal(t, "/", localPath("/\\evil.example.com", "/"))
+	assert.Equal(t, "/", localPath("/a\\b", "/"))
+	assert.Equal(t, "/", localPath("/%09/evil.example.com\r", "/"))
+	assert.Equal(t, "/a?b=c", localPath("/a?b=c", "/"))
+	assert.Equal(t, "/a/b#c", localPath("/a/b#c", "/"))
+}
+
+func TestKeySetOutage(t *testing.T) {
+	var fetches int
+	ks := &keySet{get: func(ctx context.Context, u string, dst interface{}) error {
+		fetches++
+		return ErrProvider
+	}}
+
+	_, err := ks.key(context.Background(), "a")
+	assert.True(t, errors.Is(err, ErrProvider))
+	_, err = ks.key(context.Background(), "b")
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+	assert.Equal(t, 1, fetches)
+}
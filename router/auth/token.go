@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockSkew is the difference allowed between the clocks of the provider
+// and the server when checking the expiry of an ID token.
+const clockSkew = time.Minute
+
+// claims are the verified claims of an ID token.
+type claims struct {
+	Issuer   string   `json:"iss"`
+	Subject  string   `json:"sub"`
+	Audience audience `json:"aud"`
+	Expiry   int64    `json:"exp"`
+	Nonce    string   `json:"nonce"`
+	Email    string   `json:"email"`
+	Name     string   `json:"name"`
+}
+
+// audience is the aud claim, which is a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// verify checks the signature and claims of an RS256 ID token.
+func (o *OIDC) verify(ctx context.Context, raw string, nonce string) (claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return claims{}, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims{}, err
+	}
+	if header.Alg != "RS256" {
+		return claims{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	key, err := o.keys.key(ctx, header.Kid)
+	if err != nil {
+		return claims{}, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return claims{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return claims{}, err
+	}
+	switch {
+	case strings.TrimSuffix(c.Issuer, "/") != strings.TrimSuffix(o.provider.Issuer, "/"):
+		return claims{}, fmt.Errorf("%w: issuer %q", ErrInvalidToken, c.Issuer)
+	case !c.Audience.contains(o.config.ClientID):
+		return claims{}, fmt.Errorf("%w: audience %q", ErrInvalidToken, c.Audience)
+	case time.Now().Add(-clockSkew).After(time.Unix(c.Expiry, 0)):
+		return claims{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+	case c.Nonce != nonce:
+		return claims{}, fmt.Errorf("%w: nonce doesn't match", ErrInvalidToken)
+	case c.Subject == "":
+		return claims{}, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	return c, nil
+}
+
+// contains returns true if the audience includes the client ID.
+func (a audience) contains(clientID string) bool {
+	for _, v := range a {
+		if v == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSegment decodes a base64url JSON segment of a token.
+func decodeSegment(seg string, dst interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	return nil
+}
+
+// keySet caches the signing keys of the provider.
+type keySet struct {
+	url string
+	get func(ctx context.Context, u string, dst interface{}) error
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// minKeyRefresh is the minimum time between loading the keys, so tokens with
+// unknown key IDs don't cause a request to the provider each.
+const minKeyRefresh = time.Minute
+
+// key returns the RSA key with the ID, loading the keys again if it isn't
+// known so rotated keys are picked up.
+func (ks *keySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if key, ok := ks.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(ks.fetched) < minKeyRefresh {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+
+	// The attempt is recorded first so a provider outage doesn't cause a
+	// request for each token either.
+	ks.fetched = time.Now()
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := ks.get(ctx, ks.url, &set); err != nil {
+		return nil, err
+	}
+
+	ks.keys = make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) > 4 {
+			continue
+		}
+		ks.keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,80 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPrincipal struct {
+	id    string
+	roles []string
+}
+
+func (p testPrincipal) ID() string {
+	return p.id
+}
+
+// testAuthenticate sets the principal from the X-User header.
+func testAuthenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-User") {
+		case "admin":
+			r = WithPrincipal(r, testPrincipal{id: "1", roles: []string{"admin", "editor"}})
+		case "editor":
+			r = WithPrincipal(r, testPrincipal{id: "2", roles: []string{"editor"}})
+		case "broken":
+			r = WithPrincipal(r, testPrincipal{id: "3"})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var testRoleAuthorizer = AuthorizerFunc(func(r *http.Request, p Principal, required []string) (bool, error) {
+	if p.ID() == "3" {
+		return false, errors.New("lookup failed")
+	}
+	roles := make(map[string]bool)
+	for _, role := range p.(testPrincipal).roles {
+		roles[role] = true
+	}
+	for _, v := range required {
+		if !roles[v] {
+			return false, nil
+		}
+	}
+	return true, nil
+})
+
+func TestAuthorize(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(testAuthenticate, mux.Authorize(testRoleAuthorizer))
+
+	h := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux.Get("/public", h)
+	mux.Get("/admin", h).Requires("admin").Requires("editor")
+	mux.Get("/edit", h).Requires("editor")
+
+	for _, v := range []struct {
+		user string
+		path string
+		code int
+	}{
+		{"", "/public", http.StatusOK},
+		{"", "/admin", http.StatusUnauthorized},
+		{"editor", "/admin", http.StatusForbidden},
+		{"editor", "/edit", http.StatusOK},
+		{"admin", "/admin", http.StatusOK},
+		{"broken", "/edit", http.StatusInternalServerError},
+	} {
+		r := httptest.NewRequest("GET", v.path, nil)
+		r.Header.Set("X-User", v.user)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, v.code, w.Code, v.user+" "+v.path)
+	}
+}
@@ -0,0 +1,181 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrBodyTooLarge is returned when a request body is larger than the
+	// maximum size.
+	ErrBodyTooLarge = errors.New("router: request body too large")
+	// ErrUnsupportedMediaType is returned when a request body isn't in a
+	// supported format.
+	ErrUnsupportedMediaType = errors.New("router: unsupported media type")
+)
+
+// JSONOptions contains the settings for decoding JSON request bodies.
+type JSONOptions struct {
+	// DisallowUnknownFields rejects objects with keys that don't match a
+	// field in the destination.
+	DisallowUnknownFields bool
+	// MaxDepth is the maximum nesting of objects and arrays. Zero means no
+	// limit.
+	MaxDepth int
+	// MaxBytes is the maximum size of the body. Zero means no limit.
+	MaxBytes int64
+	// UseNumber decodes numbers into interface{} values as json.Number
+	// instead of float64.
+	UseNumber bool
+}
+
+// DefaultJSONOptions are the JSON options of a new Mux.
+var DefaultJSONOptions = JSONOptions{
+	MaxDepth: 32,
+	MaxBytes: 1 << 20,
+}
+
+// SetJSONOptions sets the options used by Bind.
+func (m *Mux) SetJSONOptions(options JSONOptions) {
+	m.jsonOptions = options
+}
+
+// Bind decodes a request body into dst. JSON bodies, and bodies without a
+// Content-Type, are decoded with the JSON options. Other bodies are decoded
+// with the codec registered for the Content-Type. A body that is too large
+// returns a 413 StatusError, a body without a codec returns a 415
+// StatusError, and a body that can't be decoded returns a 400 StatusError.
+func (m *Mux) Bind(r *http.Request, dst interface{}) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return StatusError{Code: http.StatusUnsupportedMediaType, Err: ErrUnsupportedMediaType}
+		}
+		if mediaType != "application/json" && !hasJSONSuffix(mediaType) {
+			codec := m.codec(mediaType)
+			if codec == nil {
+				return StatusError{Code: http.StatusUnsupportedMediaType, Err: ErrUnsupportedMediaType}
+			}
+
+			b, err := readBody(r.Body, m.jsonOptions.MaxBytes)
+			if err != nil {
+				return err
+			}
+			if err := codec.Unmarshal(b, dst); err != nil {
+				return StatusError{Code: http.StatusBadRequest, Err: err}
+			}
+			return nil
+		}
+	}
+
+	return decodeJSON(r.Body, dst, m.jsonOptions)
+}
+
+// errSingleJSONValue is returned for a JSON body with data after the value.
+var errSingleJSONValue = errors.New("router: body must contain a single JSON value")
+
+// readBody reads a request body up to maxBytes. Zero means no limit.
+func readBody(body io.Reader, maxBytes int64) ([]byte, error) {
+	if body == nil {
+		return nil, StatusError{Code: http.StatusBadRequest, Err: io.EOF}
+	}
+
+	if maxBytes > 0 {
+		body = io.LimitReader(body, maxBytes+1)
+	}
+
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, readError(err)
+	}
+	if maxBytes > 0 && int64(len(b)) > maxBytes {
+		return nil, StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrBodyTooLarge, ErrorCode: ErrorCodeBodyTooLarge}
+	}
+
+	return b, nil
+}
+
+// readError returns a 400 StatusError for an error reading a request body,
+// unless the error has a status, such as the 408 of BodyTimeout. Bodies
+// over the limit of an http.MaxBytesReader, such as the one set by
+// RuntimeConfig, are a 413 StatusError.
+func readError(err error) error {
+	var e Error
+	if errors.As(err, &e) {
+		return err
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrBodyTooLarge, ErrorCode: ErrorCodeBodyTooLarge}
+	}
+	return StatusError{Code: http.StatusBadRequest, Err: err}
+}
+
+// decodeJSON decodes a JSON body with the options.
+func decodeJSON(body io.Reader, dst interface{}, options JSONOptions) error {
+	b, err := readBody(body, options.MaxBytes)
+	if err != nil {
+		return err
+	}
+
+	if options.MaxDepth > 0 {
+		if err := checkJSONDepth(b, options.MaxDepth); err != nil {
+			return StatusError{Code: http.StatusBadRequest, Err: err}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if options.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if options.UseNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(dst); err != nil {
+		return StatusError{Code: http.StatusBadRequest, Err: err}
+	}
+	if dec.More() {
+		return StatusError{Code: http.StatusBadRequest, Err: errSingleJSONValue}
+	}
+
+	return nil
+}
+
+// checkJSONDepth returns an error if objects and arrays are nested deeper
+// than max.
+func checkJSONDepth(b []byte, max int) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > max {
+				return fmt.Errorf("router: JSON nested deeper than %v levels", max)
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
+
+// hasJSONSuffix returns true for structured syntax JSON media types such as
+// application/problem+json.
+func hasJSONSuffix(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	Name string `json:"name"`
+}
+
+func bindRequest(body string, contentType string) *http.Request {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func statusCode(err error) int {
+	if se, ok := err.(StatusError); ok {
+		return se.Code
+	}
+	return 0
+}
+
+func TestBind(t *testing.T) {
+	mux := New()
+
+	u := testUser{}
+	assert.NoError(t, mux.Bind(bindRequest(`{"name":"john","extra":1}`, "application/json; charset=utf-8"), &u))
+	assert.Equal(t, "john", u.Name)
+
+	assert.NoError(t, mux.Bind(bindRequest(`{"name":"jane"}`, ""), &u))
+	assert.Equal(t, "jane", u.Name)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, statusCode(mux.Bind(bindRequest(`{}`, "text/plain"), &u)))
+	assert.Equal(t, http.StatusBadRequest, statusCode(mux.Bind(bindRequest(`{"name":`, ""), &u)))
+	assert.Equal(t, http.StatusBadRequest, statusCode(mux.Bind(bindRequest(`{} {}`, ""), &u)))
+}
+
+func TestBindOptions(t *testing.T) {
+	mux := New()
+	mux.SetJSONOptions(JSONOptions{
+		DisallowUnknownFields: true,
+		MaxDepth:              2,
+		MaxBytes:              20,
+		UseNumber:             true,
+	})
+
+	u := testUser{}
+	assert.Equal(t, http.StatusBadRequest, statusCode(mux.Bind(bindRequest(`{"extra":1}`, ""), &u)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, statusCode(mux.Bind(bindRequest(`{"name":"a very long name"}`, ""), &u)))
+	assert.Equal(t, http.StatusBadRequest, statusCode(mux.Bind(bindRequest(`[[[1]]]`, ""), &[]interface{}{})))
+
+	v := map[string]interface{}{}
+	assert.NoError(t, mux.Bind(bindRequest(`{"n":[1]}`, ""), &v))
+	assert.Equal(t, []interface{}{json.Number("1")}, v["n"])
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// bufferedBodyKey is the store key for the buffered request body.
+type bufferedBodyKey struct{}
+
+// BufferBody reads the request body up to maxBytes, replaces r.Body with a
+// reader of the bytes, and returns them, so middleware such as signature
+// verification and logging can read the body and still leave it for the
+// next consumer. The bytes are kept in the request store, so later calls
+// return them and reset r.Body to the start without reading again. Zero
+// maxBytes means no limit. A body that is too large returns a 413
+// StatusError.
+func BufferBody(r *http.Request, maxBytes int64) ([]byte, error) {
+	if b, ok := Get(r, bufferedBodyKey{}).([]byte); ok {
+		r.Body = ioutil.NopCloser(bytes.NewReader(b))
+		return b, nil
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return []byte{}, nil
+	}
+
+	b, err := readBody(r.Body, maxBytes)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	Set(r, bufferedBodyKey{}, b)
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
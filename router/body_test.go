@@ -0,0 +1,62 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferBody(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	var logged string
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := BufferBody(r, 1024)
+			if err != nil {
+				mux.serveError(w, r, err)
+				return
+			}
+			assert.Equal(t, `{"name":"ada"}`, string(b))
+			next.ServeHTTP(w, r.WithContext(r.Context()))
+		})
+	}, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The body was read by the previous middleware.
+			b, _ := BufferBody(r, 1024)
+			logged = string(b)
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.Post("/users", func(w http.ResponseWriter, r *http.Request) error {
+		var user struct {
+			Name string `json:"name"`
+		}
+		if err := mux.Bind(r, &user); err != nil {
+			return err
+		}
+		w.Write([]byte(user.Name))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"ada"}`)))
+	assert.Equal(t, "ada", w.Body.String())
+	assert.Equal(t, `{"name":"ada"}`, logged)
+
+	b, err := BufferBody(httptest.NewRequest("POST", "/", strings.NewReader("too large")), 4)
+	assert.Nil(t, b)
+	var se StatusError
+	if assert.True(t, errors.As(err, &se)) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, se.Status())
+	}
+
+	b, err = BufferBody(httptest.NewRequest("GET", "/", nil), 4)
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+}
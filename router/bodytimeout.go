@@ -0,0 +1,97 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// ErrBodyTimeout is returned when a request body isn't read before the body
+// timeout of the route.
+var ErrBodyTimeout = errors.New("router: request body read timed out")
+
+// bodyTimeoutKey is the route value key for the body timeout of a route.
+type bodyTimeoutKey struct{}
+
+// BodyTimeout sets the time the client has to send the request body of the
+// route, such as a long one for an upload route. Zero disables it for the
+// route. It is enforced by the BodyTimeout middleware.
+func (rt *Route) BodyTimeout(d time.Duration) *Route {
+	rt.route.WithValue(bodyTimeoutKey{}, d)
+	return rt
+}
+
+// BodyTimeout returns middleware for Use that limits the time the client has
+// to send the request body to the timeout of the route or d, so slow clients
+// can't hold a handler, without the coarse server ReadTimeout that would
+// also cut off slow uploads. Reads after the deadline return a 408
+// StatusError with ErrBodyTimeout, which Bind and DecodeForm pass on, and the
+// connection is closed after the response. Zero d only limits routes with a
+// BodyTimeout.
+func (m *Mux) BodyTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := d
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				if v, ok := route.Value(bodyTimeoutKey{}).(time.Duration); ok {
+					timeout = v
+				}
+			}
+			if timeout <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body := &timeoutBody{
+				ReadCloser: r.Body,
+				deadline:   time.Now().Add(timeout),
+				w:          w,
+			}
+			// The connection deadline interrupts blocked reads. Writers that
+			// don't support it, such as a ResponseRecorder, only get the
+			// deadline checked on each read.
+			rc := http.NewResponseController(w)
+			if rc.SetReadDeadline(body.deadline) == nil {
+				defer rc.SetReadDeadline(time.Time{})
+			}
+
+			r.Body = body
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// timeoutBody is a request body with a read deadline.
+type timeoutBody struct {
+	io.ReadCloser
+	deadline time.Time
+	w        http.ResponseWriter
+	once     sync.Once
+}
+
+// Read reads from the body or returns the timeout error after the
+// deadline.
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	if !time.Now().Before(b.deadline) {
+		return 0, b.timeout()
+	}
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		return n, b.timeout()
+	}
+	return n, err
+}
+
+// timeout returns the timeout error and asks for the connection to be
+// closed, since the rest of the body is unread.
+func (b *timeoutBody) timeout() error {
+	b.once.Do(func() {
+		b.w.Header().Set("Connection", "close")
+	})
+	return StatusError{Code: http.StatusRequestTimeout, Err: ErrBodyTimeout, ErrorCode: ErrorCodeBodyTimeout}
+}
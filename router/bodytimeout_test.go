@@ -0,0 +1,97 @@
+package router
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyTimeout(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.BodyTimeout(50 * time.Millisecond))
+	mux.Post("/api", func(w http.ResponseWriter, r *http.Request) error {
+		var v map[string]string
+		if err := mux.Bind(r, &v); err != nil {
+			return err
+		}
+		w.Write([]byte(v["name"]))
+		return nil
+	})
+	mux.Post("/upload", func(w http.ResponseWriter, r *http.Request) error {
+		var form struct {
+			File string `form:"file"`
+		}
+		if err := mux.DecodeForm(r, &form); err != nil {
+			return err
+		}
+		w.Write([]byte(form.File))
+		return nil
+	}).BodyTimeout(time.Second)
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	// A client that stops sending the body gets a 408.
+	c, err := net.Dial("tcp", s.Listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c.Close()
+	fmt.Fprint(c, "POST /api HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 100\r\n\r\n{\"name\":")
+	start := time.Now()
+	resp, err := http.ReadResponse(bufio.NewReader(c), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+		assert.True(t, resp.Close)
+		assert.True(t, time.Since(start) < time.Second)
+	}
+
+	// The upload route has a longer timeout.
+	c2, err := net.Dial("tcp", s.Listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c2.Close()
+	fmt.Fprint(c2, "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 8\r\n\r\nfile")
+	time.Sleep(100 * time.Millisecond)
+	fmt.Fprint(c2, "=abc")
+	resp, err = http.ReadResponse(bufio.NewReader(c2), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		b, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, "abc", string(b))
+	}
+
+	// Fast clients aren't affected.
+	resp, err = http.Post(s.URL+"/api", "application/json", strings.NewReader(`{"name":"ada"}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+}
+
+func TestBodyTimeoutRecorder(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.BodyTimeout(time.Nanosecond))
+	mux.Post("/", func(w http.ResponseWriter, r *http.Request) error {
+		_, err := readBody(r.Body, 0)
+		assert.True(t, errors.Is(err, ErrBodyTimeout))
+		return err
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("body")))
+	assert.Equal(t, http.StatusRequestTimeout, w.Code)
+	assert.Equal(t, "close", w.Header().Get("Connection"))
+}
@@ -0,0 +1,259 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a circuit breaker rejects a request.
+var ErrCircuitOpen = errors.New("router: circuit open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed allows all requests.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects all requests.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen allows a limited number of probe requests.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerConfig contains the settings for a circuit breaker.
+type BreakerConfig struct {
+	// Window is the period requests are counted over. Defaults to 10s.
+	Window time.Duration
+	// MinRequests is the number of requests needed in a window before the
+	// breaker can open. Defaults to 10.
+	MinRequests int
+	// ErrorRate is the share of failed requests (0-1) that opens the
+	// breaker. Defaults to 0.5.
+	ErrorRate float64
+	// Latency counts requests slower than this as failures. Optional.
+	Latency time.Duration
+	// Cooldown is how long the breaker stays open before probing. Defaults
+	// to 30s.
+	Cooldown time.Duration
+	// Probes is the number of successful probe requests needed to close the
+	// breaker. Defaults to 1.
+	Probes int
+}
+
+// BreakerStats describes the current state of a circuit breaker.
+type BreakerStats struct {
+	Name     string       `json:"name"`
+	State    BreakerState `json:"state"`
+	Requests int          `json:"requests"`
+	Failures int          `json:"failures"`
+	OpenedAt time.Time    `json:"openedAt,omitempty"`
+}
+
+// Breaker stops calling a handler while it is failing.
+type Breaker struct {
+	name   string
+	config BreakerConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+// NewBreaker returns a circuit breaker registered with the mux under name so
+// its state is available from Breakers.
+func (m *Mux) NewBreaker(name string, config BreakerConfig) *Breaker {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.ErrorRate <= 0 {
+		config.ErrorRate = 0.5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.Probes <= 0 {
+		config.Probes = 1
+	}
+
+	b := &Breaker{
+		name:   name,
+		config: config,
+		now:    time.Now,
+		state:  BreakerClosed,
+	}
+
+	m.breakersMu.Lock()
+	if m.breakers == nil {
+		m.breakers = make(map[string]*Breaker)
+	}
+	m.breakers[name] = b
+	m.breakersMu.Unlock()
+
+	return b
+}
+
+// Breakers returns the stats of every circuit breaker on the mux.
+func (m *Mux) Breakers() []BreakerStats {
+	m.breakersMu.Lock()
+	defer m.breakersMu.Unlock()
+
+	out := make([]BreakerStats, 0, len(m.breakers))
+	for _, b := range m.breakers {
+		out = append(out, b.Stats())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Wrap returns a handler that is rejected with a 503 StatusError while the
+// breaker is open. Handler errors with a status of 500 or above, panics and
+// slow requests count as failures.
+func (b *Breaker) Wrap(fn func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) (err error) {
+		if wait, ok := b.allow(); !ok {
+			SetRetryAfter(w, wait)
+			return StatusError{Code: http.StatusServiceUnavailable, Err: ErrCircuitOpen}
+		}
+
+		start := b.now()
+		defer func() {
+			// A panic is recorded as a failure, so a panicking probe doesn't
+			// leave the breaker half open, and then passed on.
+			v := recover()
+			failed := v != nil || isServerError(err) ||
+				(b.config.Latency > 0 && b.now().Sub(start) > b.config.Latency)
+			b.record(failed)
+			if v != nil {
+				panic(v)
+			}
+		}()
+
+		return fn(w, r)
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	return b.Stats().State
+}
+
+// Stats returns the current state and counters of the breaker.
+func (b *Breaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	return BreakerStats{
+		Name:     b.name,
+		State:    b.state,
+		Requests: b.requests,
+		Failures: b.failures,
+		OpenedAt: b.openedAt,
+	}
+}
+
+// allow returns true if a request may be served or how long until the
+// breaker allows requests again.
+func (b *Breaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	switch b.state {
+	case BreakerOpen:
+		return b.openedAt.Add(b.config.Cooldown).Sub(b.now()), false
+	case BreakerHalfOpen:
+		if b.probes >= b.config.Probes {
+			return time.Second, false
+		}
+		b.probes++
+	}
+
+	return 0, true
+}
+
+// record counts the result of a request.
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		if failed {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.Probes {
+			b.state = BreakerClosed
+			b.resetWindow()
+		}
+		return
+	}
+
+	b.requests++
+	if failed {
+		b.failures++
+	}
+
+	if b.requests >= b.config.MinRequests &&
+		float64(b.failures)/float64(b.requests) >= b.config.ErrorRate {
+		b.open()
+	}
+}
+
+// advance moves the breaker to the next state based on the time.
+func (b *Breaker) advance() {
+	now := b.now()
+	switch b.state {
+	case BreakerOpen:
+		if !now.Before(b.openedAt.Add(b.config.Cooldown)) {
+			b.state = BreakerHalfOpen
+			b.probes = 0
+			b.successes = 0
+		}
+	case BreakerClosed:
+		if now.Sub(b.windowStart) >= b.config.Window {
+			b.resetWindow()
+		}
+	}
+}
+
+// open trips the breaker.
+func (b *Breaker) open() {
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+}
+
+// resetWindow starts a new counting window.
+func (b *Breaker) resetWindow() {
+	b.windowStart = b.now()
+	b.requests = 0
+	b.failures = 0
+}
+
+// isServerError returns true if the error should be treated as a server
+// failure.
+func isServerError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var e Error
+	if errors.As(err, &e) {
+		return e.Status() >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	now := time.Unix(1000, 0)
+	b := mux.NewBreaker("payments", BreakerConfig{
+		MinRequests: 4,
+		ErrorRate:   0.5,
+		Cooldown:    10 * time.Second,
+	})
+	b.now = func() time.Time { return now }
+
+	fail := true
+	calls := 0
+	mux.Get("/pay", b.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		calls++
+		if fail {
+			return errors.New("downstream failed")
+		}
+		return nil
+	}))
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/pay", nil))
+		return w
+	}
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, http.StatusInternalServerError, serve().Code)
+	}
+	assert.Equal(t, BreakerOpen, b.State())
+
+	w := serve()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+	assert.Equal(t, 4, calls)
+
+	// Failed probe opens the breaker again.
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.Equal(t, http.StatusInternalServerError, serve().Code)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	// Successful probe closes the breaker.
+	now = now.Add(10 * time.Second)
+	fail = false
+	assert.Equal(t, http.StatusOK, serve().Code)
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.Equal(t, http.StatusOK, serve().Code)
+
+	assert.Equal(t, []BreakerStats{{
+		Name:     "payments",
+		State:    BreakerClosed,
+		Requests: 1,
+		OpenedAt: now.Add(-10 * time.Second),
+	}}, mux.Breakers())
+}
+
+func TestBreakerPanic(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := New().NewBreaker("panics", BreakerConfig{MinRequests: 1, ErrorRate: 0.5, Cooldown: time.Second})
+	b.now = func() time.Time { return now }
+
+	explode := b.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+	serve := func() {
+		defer func() { recover() }()
+		explode(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	serve()
+	assert.Equal(t, BreakerOpen, b.State())
+
+	// A panicking probe opens the breaker again instead of leaving it half
+	// open.
+	now = now.Add(time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.Panics(t, func() {
+		explode(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+	assert.Equal(t, BreakerOpen, b.State())
+}
+
+func TestBreakerClientErrors(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	b := mux.NewBreaker("users", BreakerConfig{MinRequests: 2})
+
+	mux.Get("/user", b.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		return StatusError{Code: http.StatusNotFound}
+	}))
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/user", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestBreakerLatency(t *testing.T) {
+	mux := New()
+	now := time.Unix(1000, 0)
+	b := mux.NewBreaker("slow", BreakerConfig{MinRequests: 1, Latency: time.Second})
+	b.now = func() time.Time { return now }
+
+	mux.Get("/slow", b.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		now = now.Add(2 * time.Second)
+		return nil
+	}))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, BreakerOpen, b.State())
+}
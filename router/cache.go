@@ -0,0 +1,46 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+// cacheControlKey is the route value key for the Cache-Control policy.
+type cacheControlKey struct{}
+
+// CacheControl sets the Cache-Control header value for responses of the
+// route, such as "public, max-age=300". It is set by the CacheControl
+// middleware.
+func (rt *Route) CacheControl(value string) *Route {
+	rt.route.WithValue(cacheControlKey{}, value)
+	return rt
+}
+
+// NoStore sets the Cache-Control header value of the route to "no-store".
+func (rt *Route) NoStore() *Route {
+	return rt.CacheControl("no-store")
+}
+
+// CacheControl returns middleware for Use that sets the Cache-Control header
+// declared with Route.CacheControl or Group.CacheControl before the handler
+// runs. Routes without a policy get the default, which may be empty to leave
+// the header unset. Handlers can still override the header.
+func (m *Mux) CacheControl(defaultValue string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := defaultValue
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				if v, ok := route.Value(cacheControlKey{}).(string); ok {
+					value = v
+				}
+			}
+
+			if value != "" {
+				w.Header().Set("Cache-Control", value)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl(t *testing.T) {
+	mux := New()
+	mux.Use(mux.CacheControl("no-cache"))
+
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	mux.Get("/", ok)
+	mux.Get("/logo.png", ok).CacheControl("public, max-age=300")
+	mux.Get("/override", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Cache-Control", "private")
+		return nil
+	}).NoStore()
+
+	api := mux.Group("/api").NoStore()
+	api.Get("/users", ok)
+	api.Group("/public").CacheControl("public, max-age=60").Get("/stats", ok)
+
+	for path, want := range map[string]string{
+		"/":                 "no-cache",
+		"/logo.png":         "public, max-age=300",
+		"/override":         "private",
+		"/api/users":        "no-store",
+		"/api/public/stats": "public, max-age=60",
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, want, w.Header().Get("Cache-Control"), path)
+	}
+}
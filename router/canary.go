@@ -0,0 +1,136 @@
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// Stickiness determines how a client is assigned to a canary variant.
+type Stickiness int
+
+const (
+	// StickyNone assigns each request to a variant at random.
+	StickyNone Stickiness = iota
+	// StickyCookie assigns a client to a variant and remembers it in a cookie.
+	StickyCookie
+	// StickyIP assigns a client to a variant using a hash of the client IP.
+	StickyIP
+)
+
+const (
+	// VariantStable is the name of the stable canary variant.
+	VariantStable = "stable"
+	// VariantCanary is the name of the canary variant.
+	VariantCanary = "canary"
+)
+
+// CanaryStats contains the number of requests served by each variant.
+type CanaryStats struct {
+	Stable uint64
+	Canary uint64
+}
+
+// Canary routes a share of the traffic for a path to a canary handler.
+type Canary struct {
+	percent    int
+	stickiness Stickiness
+	cookie     string
+	observe    func(r *http.Request, variant string)
+
+	stable atomic.Uint64
+	canary atomic.Uint64
+}
+
+// Canary registers a route for all methods that sends percent (0-100) of the
+// requests to the canary handler and the rest to the stable handler.
+func (m *Mux) Canary(path string, stable, canary func(http.ResponseWriter, *http.Request) error, percent int, stickiness Stickiness) *Canary {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+
+	c := &Canary{
+		percent:    percent,
+		stickiness: stickiness,
+		cookie:     fmt.Sprintf("away_canary_%x", h.Sum32()),
+	}
+
+	m.handle("*", path, func(w http.ResponseWriter, r *http.Request) error {
+		if c.variant(w, r) == VariantCanary {
+			return canary(w, r)
+		}
+		return stable(w, r)
+	})
+
+	return c
+}
+
+// Observe sets a function that is called with the variant that served each
+// request.
+func (c *Canary) Observe(fn func(r *http.Request, variant string)) *Canary {
+	c.observe = fn
+	return c
+}
+
+// Stats returns the number of requests served by each variant.
+func (c *Canary) Stats() CanaryStats {
+	return CanaryStats{
+		Stable: c.stable.Load(),
+		Canary: c.canary.Load(),
+	}
+}
+
+// variant selects and records the variant for the request.
+func (c *Canary) variant(w http.ResponseWriter, r *http.Request) string {
+	variant := c.choose(w, r)
+	if variant == VariantCanary {
+		c.canary.Add(1)
+	} else {
+		c.stable.Add(1)
+	}
+
+	if c.observe != nil {
+		c.observe(r, variant)
+	}
+
+	return variant
+}
+
+// choose selects the variant for the request based on the stickiness.
+func (c *Canary) choose(w http.ResponseWriter, r *http.Request) string {
+	switch c.stickiness {
+	case StickyCookie:
+		if cookie, err := r.Cookie(c.cookie); err == nil &&
+			(cookie.Value == VariantStable || cookie.Value == VariantCanary) {
+			return cookie.Value
+		}
+		variant := c.pick(rand.Intn(100))
+		http.SetCookie(w, &http.Cookie{
+			Name:     c.cookie,
+			Value:    variant,
+			Path:     "/",
+			HttpOnly: true,
+		})
+		return variant
+	case StickyIP:
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		h := fnv.New32a()
+		h.Write([]byte(host))
+		return c.pick(int(h.Sum32() % 100))
+	default:
+		return c.pick(rand.Intn(100))
+	}
+}
+
+// pick returns the variant for a number between 0 and 99.
+func (c *Canary) pick(n int) string {
+	if n < c.percent {
+		return VariantCanary
+	}
+	return VariantStable
+}
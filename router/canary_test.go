@@ -0,0 +1,86 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func canaryHandlers(served *string) (func(http.ResponseWriter, *http.Request) error, func(http.ResponseWriter, *http.Request) error) {
+	return func(w http.ResponseWriter, r *http.Request) error {
+			*served = VariantStable
+			return nil
+		}, func(w http.ResponseWriter, r *http.Request) error {
+			*served = VariantCanary
+			return nil
+		}
+}
+
+func TestCanaryPercent(t *testing.T) {
+	served := ""
+	stable, canary := canaryHandlers(&served)
+
+	mux := New()
+	all := mux.Canary("/all", stable, canary, 100, StickyNone)
+	none := mux.Canary("/none", stable, canary, 0, StickyNone)
+
+	for i := 0; i < 10; i++ {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/all", nil))
+		assert.Equal(t, VariantCanary, served)
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/none", nil))
+		assert.Equal(t, VariantStable, served)
+	}
+
+	assert.Equal(t, CanaryStats{Canary: 10}, all.Stats())
+	assert.Equal(t, CanaryStats{Stable: 10}, none.Stats())
+}
+
+func TestCanaryCookie(t *testing.T) {
+	served := ""
+	stable, canary := canaryHandlers(&served)
+
+	mux := New()
+	observed := make([]string, 0)
+	mux.Canary("/page", stable, canary, 50, StickyCookie).Observe(func(r *http.Request, variant string) {
+		observed = append(observed, variant)
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/page", nil))
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	first := served
+
+	for i := 0; i < 10; i++ {
+		r := httptest.NewRequest("GET", "/page", nil)
+		r.AddCookie(cookies[0])
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, first, served)
+		assert.Len(t, w.Result().Cookies(), 0)
+	}
+
+	assert.Len(t, observed, 11)
+}
+
+func TestCanaryIP(t *testing.T) {
+	served := ""
+	stable, canary := canaryHandlers(&served)
+
+	mux := New()
+	mux.Canary("/page", stable, canary, 50, StickyIP)
+
+	r := httptest.NewRequest("GET", "/page", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	first := served
+
+	for i := 0; i < 10; i++ {
+		r.RemoteAddr = fmt.Sprintf("10.0.0.1:%d", 2000+i)
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, first, served)
+	}
+}
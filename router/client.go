@@ -0,0 +1,662 @@
+package router
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrClientRoute is returned by GenerateClient and GenerateTypeScript when a
+// route marked with Client can't be called by a generated client.
+var ErrClientRoute = errors.New("router: invalid client route")
+
+// clientKey is the route value key for the client method of a route.
+type clientKey struct{}
+
+// clientMethod is the client method of a route.
+type clientMethod struct {
+	name     string
+	request  reflect.Type
+	response reflect.Type
+}
+
+// clientEndpoint is a route with a client method.
+type clientEndpoint struct {
+	clientMethod
+	method  string
+	pattern string
+	segs    []string
+}
+
+// Client includes the route in the clients written by GenerateClient and
+// GenerateTypeScript as a method with the name, such as GetUser. The request
+// is sent as the JSON body and the response is decoded from the JSON body.
+// Only the types of request and response are used. Use nil for a route
+// without a request or response body.
+func (rt *Route) Client(name string, request, response interface{}) *Route {
+	rt.route.WithValue(clientKey{}, clientMethod{
+		name:     name,
+		request:  reflect.TypeOf(request),
+		response: reflect.TypeOf(response),
+	})
+	return rt
+}
+
+// clientEndpoints returns the routes marked with Client sorted by name.
+func (m *Mux) clientEndpoints() ([]clientEndpoint, error) {
+	endpoints := make([]clientEndpoint, 0)
+	names := make(map[string]bool)
+
+	for _, route := range m.router.Routes() {
+		cm, ok := route.Value(clientKey{}).(clientMethod)
+		if !ok {
+			continue
+		}
+
+		pattern := route.Pattern()
+		switch {
+		case !token.IsIdentifier(cm.name) || !token.IsExported(cm.name):
+			return nil, fmt.Errorf("%w: %s %s: name %q is not an exported identifier", ErrClientRoute, route.Method(), pattern, cm.name)
+		case names[cm.name]:
+			return nil, fmt.Errorf("%w: %s %s: duplicate name %s", ErrClientRoute, route.Method(), pattern, cm.name)
+		case route.Method() == "*":
+			return nil, fmt.Errorf("%w: %s: route matches all methods", ErrClientRoute, pattern)
+		case strings.HasSuffix(pattern, "..."):
+			return nil, fmt.Errorf("%w: %s %s: prefix patterns are not supported", ErrClientRoute, route.Method(), pattern)
+		}
+		names[cm.name] = true
+
+		segs := make([]string, 0)
+		for _, seg := range strings.Split(strings.Trim(pattern, "/"), "/") {
+			if seg != "" {
+				segs = append(segs, seg)
+			}
+		}
+
+		endpoints = append(endpoints, clientEndpoint{
+			clientMethod: cm,
+			method:       route.Method(),
+			pattern:      pattern,
+			segs:         segs,
+		})
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].name < endpoints[j].name
+	})
+	return endpoints, nil
+}
+
+// params returns the names of the path parameters of the endpoint.
+func (e clientEndpoint) params() []string {
+	params := make([]string, 0)
+	for _, seg := range e.segs {
+		if strings.HasPrefix(seg, ":") {
+			params = append(params, seg[1:])
+		}
+	}
+	return params
+}
+
+// display returns the pattern with the parameters in braces.
+func (e clientEndpoint) display() string {
+	segs := make([]string, len(e.segs))
+	for i, seg := range e.segs {
+		if strings.HasPrefix(seg, ":") {
+			seg = "{" + seg[1:] + "}"
+		}
+		segs[i] = seg
+	}
+	return e.joinPath(segs)
+}
+
+// joinPath joins the segments into a path with the trailing slash of the
+// pattern.
+func (e clientEndpoint) joinPath(segs []string) string {
+	p := "/" + strings.Join(segs, "/")
+	if len(segs) > 0 && strings.HasSuffix(e.pattern, "/") {
+		p += "/"
+	}
+	return p
+}
+
+// GenerateClient writes the source of a Go package named pkg with a typed
+// client for the routes marked with Client. The request and response types
+// are imported from their packages, so they can't be declared in package
+// main. Run it from a program called by go:generate so the client is
+// regenerated whenever the routes change:
+//
+//	//go:generate go run ./internal/genclient
+func (m *Mux) GenerateClient(w io.Writer, pkg string) error {
+	endpoints, err := m.clientEndpoints()
+	if err != nil {
+		return err
+	}
+
+	im := newGoImports()
+	var methods bytes.Buffer
+	for _, e := range endpoints {
+		if err := writeGoMethod(&methods, im, e); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by away. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	for _, p := range im.list() {
+		alias := im.aliases[p]
+		if alias == path.Base(p) {
+			fmt.Fprintf(&buf, "\t%q\n", p)
+		} else {
+			fmt.Fprintf(&buf, "\t%s %q\n", alias, p)
+		}
+	}
+	buf.WriteString(")\n")
+	buf.WriteString(goClientBase)
+	buf.Write(methods.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// writeGoMethod writes the client method of an endpoint.
+func writeGoMethod(buf *bytes.Buffer, im *goImports, e clientEndpoint) error {
+	args := []string{"ctx context.Context"}
+	taken := map[string]bool{"c": true, "ctx": true, "in": true, "out": true, "err": true}
+	idents := make(map[string]string)
+	for _, p := range e.params() {
+		ident := lowerIdent(p)
+		for taken[ident] {
+			ident += "Param"
+		}
+		taken[ident] = true
+		idents[p] = ident
+		args = append(args, ident+" string")
+	}
+
+	in := "nil"
+	if e.request != nil {
+		t := e.request
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		expr, err := im.typeExpr(t)
+		if err != nil {
+			return err
+		}
+		if t.Kind() == reflect.Struct {
+			expr = "*" + expr
+		}
+		args = append(args, "in "+expr)
+		in = "in"
+	}
+
+	segs := make([]string, 0, len(e.segs))
+	for _, seg := range e.segs {
+		if strings.HasPrefix(seg, ":") {
+			im.use("net/url")
+			seg = `" + url.PathEscape(` + idents[seg[1:]] + `) + "`
+		}
+		segs = append(segs, seg)
+	}
+	pathExpr := strings.Replace(`"`+e.joinPath(segs)+`"`, ` + ""`, "", -1)
+
+	fmt.Fprintf(buf, "\n// %s calls %s %s.\n", e.name, e.method, e.display())
+	if e.response == nil {
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) error {\n", e.name, strings.Join(args, ", "))
+		fmt.Fprintf(buf, "\treturn c.do(ctx, %q, %s, %s, nil)\n}\n", e.method, pathExpr, in)
+		return nil
+	}
+
+	t := e.response
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	expr, err := im.typeExpr(t)
+	if err != nil {
+		return err
+	}
+	result, zero, ret := expr, "nil", "out"
+	if t.Kind() == reflect.Struct {
+		result, ret = "*"+expr, "&out"
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Map, reflect.Interface:
+	default:
+		zero = "out"
+	}
+
+	fmt.Fprintf(buf, "func (c *Client) %s(%s) (%s, error) {\n", e.name, strings.Join(args, ", "), result)
+	fmt.Fprintf(buf, "\tvar out %s\n", expr)
+	fmt.Fprintf(buf, "\tif err := c.do(ctx, %q, %s, %s, &out); err != nil {\n\t\treturn %s, err\n\t}\n", e.method, pathExpr, in, zero)
+	fmt.Fprintf(buf, "\treturn %s, nil\n}\n", ret)
+	return nil
+}
+
+// goClientBase is the client type shared by the generated methods.
+const goClientBase = `
+// Client calls the API.
+type Client struct {
+	// BaseURL is the URL of the API, such as https://example.com.
+	BaseURL string
+	// HTTPClient sends the requests. http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+}
+
+// New returns a client for the API at the base URL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Error is returned for responses with a status code that isn't 2xx.
+type Error struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// do sends a request with in as the JSON body and decodes the JSON response
+// into out.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return &Error{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+`
+
+// goImports assigns unique names to the packages used by a generated client.
+type goImports struct {
+	aliases map[string]string
+	used    map[string]bool
+	names   map[string]bool
+}
+
+// newGoImports returns the imports used by the client base.
+func newGoImports() *goImports {
+	im := &goImports{
+		aliases: make(map[string]string),
+		used:    make(map[string]bool),
+		names:   make(map[string]bool),
+	}
+	for _, p := range []string{"bytes", "context", "encoding/json", "fmt", "io", "net/http", "strings"} {
+		im.use(p)
+	}
+	// Reserve url so a later parameter doesn't import another package as url.
+	im.alias("net/url")
+	return im
+}
+
+// alias returns the name of the package in the generated client.
+func (im *goImports) alias(p string) string {
+	if a, ok := im.aliases[p]; ok {
+		return a
+	}
+
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, path.Base(p))
+	if base == "" || !token.IsIdentifier(base) || token.IsKeyword(base) {
+		base = "pkg_" + base
+	}
+
+	a := base
+	for i := 2; im.names[a]; i++ {
+		a = fmt.Sprintf("%s%d", base, i)
+	}
+	im.names[a] = true
+	im.aliases[p] = a
+	return a
+}
+
+// use marks the package as imported and returns its name.
+func (im *goImports) use(p string) string {
+	im.used[p] = true
+	return im.alias(p)
+}
+
+// list returns the sorted paths of the imported packages.
+func (im *goImports) list() []string {
+	paths := make([]string, 0, len(im.used))
+	for p := range im.used {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// typeExpr returns the Go expression for the type.
+func (im *goImports) typeExpr(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		switch t.PkgPath() {
+		case "":
+			return t.Name(), nil
+		case "main":
+			return "", fmt.Errorf("%w: type %s is declared in package main", ErrClientRoute, t)
+		}
+		return im.use(t.PkgPath()) + "." + t.Name(), nil
+	}
+
+	var prefix string
+	switch t.Kind() {
+	case reflect.Ptr:
+		prefix = "*"
+	case reflect.Slice:
+		prefix = "[]"
+	case reflect.Array:
+		prefix = fmt.Sprintf("[%d]", t.Len())
+	case reflect.Map:
+		key, err := im.typeExpr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		prefix = "map[" + key + "]"
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+		fallthrough
+	default:
+		return "", fmt.Errorf("%w: unnamed type %s", ErrClientRoute, t)
+	}
+
+	elem, err := im.typeExpr(t.Elem())
+	if err != nil {
+		return "", err
+	}
+	return prefix + elem, nil
+}
+
+// lowerIdent returns the name as a lower camel case identifier.
+func lowerIdent(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = sb.Len() > 0
+		case sb.Len() == 0:
+			sb.WriteRune(unicode.ToLower(r))
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	ident := sb.String()
+	switch {
+	case ident == "":
+		ident = "param"
+	case token.IsKeyword(ident):
+		ident += "Param"
+	case !token.IsIdentifier(ident):
+		ident = "p" + ident
+	}
+	return ident
+}
+
+// GenerateTypeScript writes a TypeScript module with a Client class and
+// interfaces for the request and response types of the routes marked with
+// Client. The method names start with a lower case letter.
+func (m *Mux) GenerateTypeScript(w io.Writer) error {
+	endpoints, err := m.clientEndpoints()
+	if err != nil {
+		return err
+	}
+
+	ts := &tsTypes{names: make(map[reflect.Type]string), taken: make(map[string]bool)}
+	var methods bytes.Buffer
+	for _, e := range endpoints {
+		args := make([]string, 0)
+		for _, p := range e.params() {
+			args = append(args, lowerIdent(p)+": string")
+		}
+		body := ""
+		if e.request != nil {
+			args = append(args, "body: "+ts.typeExpr(e.request))
+			body = ", body"
+		}
+		result := "void"
+		if e.response != nil {
+			result = ts.typeExpr(e.response)
+		}
+
+		segs := make([]string, 0, len(e.segs))
+		for _, seg := range e.segs {
+			if strings.HasPrefix(seg, ":") {
+				seg = "${encodeURIComponent(" + lowerIdent(seg[1:]) + ")}"
+			}
+			segs = append(segs, seg)
+		}
+
+		name := lowerIdent(e.name)
+		fmt.Fprintf(&methods, "\n  /** %s %s */\n", e.method, e.display())
+		fmt.Fprintf(&methods, "  %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), result)
+		fmt.Fprintf(&methods, "    return this.do(%q, `%s`%s);\n  }\n", e.method, e.joinPath(segs), body)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by away. DO NOT EDIT.\n")
+	for _, decl := range ts.decls {
+		buf.WriteString("\n" + decl)
+	}
+	buf.WriteString(tsClientBase)
+	buf.Write(methods.Bytes())
+	buf.WriteString("}\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// tsClientBase is the start of the generated TypeScript client class.
+const tsClientBase = `
+export class APIError extends Error {
+  constructor(public status: number, public body: string) {
+    super(` + "`${status}: ${body}`" + `);
+  }
+}
+
+export class Client {
+  constructor(private baseURL: string, private fetchFn: typeof fetch = fetch) {}
+
+  private async do<T>(method: string, path: string, body?: unknown): Promise<T> {
+    const headers: Record<string, string> = { Accept: "application/json" };
+    const init: RequestInit = { method, headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+      init.body = JSON.stringify(body);
+    }
+    const resp = await this.fetchFn(this.baseURL.replace(/\/$/, "") + path, init);
+    if (!resp.ok) {
+      throw new APIError(resp.status, await resp.text());
+    }
+    if (resp.status === 204) {
+      return undefined as T;
+    }
+    return (await resp.json()) as T;
+  }
+`
+
+// tsTypes declares TypeScript interfaces for named struct types.
+type tsTypes struct {
+	names map[reflect.Type]string
+	taken map[string]bool
+	decls []string
+}
+
+var (
+	tsTimeType        = reflect.TypeOf(time.Time{})
+	tsBytesType       = reflect.TypeOf([]byte(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeExpr returns the TypeScript type for the Go type using the JSON
+// encoding of the type.
+func (ts *tsTypes) typeExpr(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == tsTimeType || t == tsBytesType:
+		return "string"
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return "unknown"
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		elem := ts.typeExpr(t.Elem())
+		if strings.ContainsAny(elem, " |") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + ts.typeExpr(t.Elem()) + ">"
+	case reflect.Struct:
+		if t.Name() == "" {
+			return ts.object(t, "")
+		}
+		return ts.declare(t)
+	}
+	return "unknown"
+}
+
+// declare adds an interface for the named struct type and returns its name.
+func (ts *tsTypes) declare(t reflect.Type) string {
+	if name, ok := ts.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	for i := 2; ts.taken[name]; i++ {
+		name = fmt.Sprintf("%s%d", t.Name(), i)
+	}
+	ts.taken[name] = true
+	ts.names[t] = name
+
+	decl := "export interface " + name + " " + ts.object(t, "") + "\n"
+	ts.decls = append(ts.decls, decl)
+	return name
+}
+
+// object returns the TypeScript object type for the fields of a struct.
+func (ts *tsTypes) object(t reflect.Type, indent string) string {
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	ts.fields(&sb, t, indent+"  ")
+	sb.WriteString(indent + "}")
+	return sb.String()
+}
+
+// fields writes the JSON fields of a struct, flattening embedded structs like
+// encoding/json.
+func (ts *tsTypes) fields(sb *strings.Builder, t reflect.Type, indent string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				ts.fields(sb, ft, indent)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		optional := f.Type.Kind() == reflect.Ptr
+		for _, p := range parts[1:] {
+			if p == "omitempty" {
+				optional = true
+			}
+		}
+
+		if !token.IsIdentifier(name) {
+			name = fmt.Sprintf("%q", name)
+		}
+		if optional {
+			name += "?"
+		}
+		fmt.Fprintf(sb, "%s%s: %s;\n", indent, name, ts.typeExpr(f.Type))
+	}
+}
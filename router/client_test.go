@@ -0,0 +1,100 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ClientUser struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Email   *string   `json:"email"`
+	Tags    []string  `json:"tags,omitempty"`
+	Created time.Time `json:"created"`
+	secret  string
+}
+
+type ClientNewUser struct {
+	Name string `json:"name"`
+}
+
+func clientMux() *Mux {
+	mux := New()
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	mux.Get("/users", ok).Client("ListUsers", nil, []ClientUser{})
+	mux.Get("/users/{id}", ok).Client("GetUser", nil, ClientUser{})
+	mux.Post("/users", ok).Client("CreateUser", ClientNewUser{}, &ClientUser{})
+	mux.Delete("/users/{id}/tags/{type}", ok).Client("DeleteTag", nil, nil)
+	mux.Get("/health", ok)
+	return mux
+}
+
+func TestGenerateClient(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, clientMux().GenerateClient(&buf, "client"))
+	src := buf.String()
+
+	assert.True(t, strings.HasPrefix(src, "// Code generated by away. DO NOT EDIT.\n\npackage client\n"))
+	assert.Contains(t, src, `"github.com/ambientkit/away/router"`)
+	assert.Contains(t, src, `"net/url"`)
+	assert.Contains(t, src, "// CreateUser calls POST /users.\n"+
+		"func (c *Client) CreateUser(ctx context.Context, in *router.ClientNewUser) (*router.ClientUser, error) {")
+	assert.Contains(t, src, "func (c *Client) DeleteTag(ctx context.Context, id string, typeParam string) error {\n"+
+		`	return c.do(ctx, "DELETE", "/users/"+url.PathEscape(id)+"/tags/"+url.PathEscape(typeParam), nil, nil)`)
+	assert.Contains(t, src, "func (c *Client) GetUser(ctx context.Context, id string) (*router.ClientUser, error) {")
+	assert.Contains(t, src, "func (c *Client) ListUsers(ctx context.Context) ([]router.ClientUser, error) {")
+	assert.NotContains(t, src, "/health")
+
+	assert.Less(t, strings.Index(src, "CreateUser("), strings.Index(src, "ListUsers("))
+}
+
+func TestGenerateClientInvalid(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	mux := New()
+	mux.Get("/a", ok).Client("getA", nil, nil)
+	assert.True(t, errors.Is(mux.GenerateClient(&bytes.Buffer{}, "client"), ErrClientRoute))
+
+	mux = New()
+	mux.Get("/a", ok).Client("Get", nil, nil)
+	mux.Get("/b", ok).Client("Get", nil, nil)
+	assert.True(t, errors.Is(mux.GenerateClient(&bytes.Buffer{}, "client"), ErrClientRoute))
+
+	mux = New()
+	mux.Get("/a", ok).Client("Get", nil, struct{ A int }{})
+	assert.True(t, errors.Is(mux.GenerateClient(&bytes.Buffer{}, "client"), ErrClientRoute))
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, clientMux().GenerateTypeScript(&buf))
+	src := buf.String()
+
+	assert.Contains(t, src, "export interface ClientUser {\n"+
+		"  id: string;\n"+
+		"  name: string;\n"+
+		"  email?: string;\n"+
+		"  tags?: string[];\n"+
+		"  created: string;\n"+
+		"}\n")
+	assert.Contains(t, src, "export interface ClientNewUser {\n  name: string;\n}\n")
+	assert.Contains(t, src, "  /** GET /users/{id} */\n"+
+		"  getUser(id: string): Promise<ClientUser> {\n"+
+		"    return this.do(\"GET\", `/users/${encodeURIComponent(id)}`);\n"+
+		"  }\n")
+	assert.Contains(t, src, "  createUser(body: ClientNewUser): Promise<ClientUser> {\n"+
+		"    return this.do(\"POST\", `/users`, body);\n")
+	assert.Contains(t, src, "  listUsers(): Promise<ClientUser[]> {")
+	assert.Contains(t, src, "  deleteTag(id: string, typeParam: string): Promise<void> {")
+	assert.Equal(t, 1, strings.Count(src, "export interface ClientUser "))
+}
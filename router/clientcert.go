@@ -0,0 +1,127 @@
+package router
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+// ErrClientCertificate is returned when a route requires a client
+// certificate and the request doesn't have a valid one.
+var ErrClientCertificate = errors.New("router: valid client certificate required")
+
+// clientCertKey is the route value key for routes that require a client
+// certificate.
+type clientCertKey struct{}
+
+// clientCertContextKey is the context key for the verified client
+// certificate.
+type clientCertContextKey struct{}
+
+// ClientCertConfig contains the settings for verifying client certificates.
+type ClientCertConfig struct {
+	// Roots verifies the certificate chain. If nil, the chains verified
+	// during the TLS handshake are required, so the server must set
+	// ClientCAs.
+	Roots *x509.CertPool
+	// Subjects are the allowed subject common names. Empty allows any
+	// verified certificate.
+	Subjects []string
+	// Verify is an optional check of the verified certificate.
+	Verify func(cert *x509.Certificate) error
+}
+
+// RequireClientCert marks the route so requests need a valid client
+// certificate. It is enforced by the ClientCerts middleware.
+func (rt *Route) RequireClientCert() *Route {
+	rt.route.WithValue(clientCertKey{}, true)
+	return rt
+}
+
+// ClientCertificate returns the verified client certificate of the request
+// or nil.
+func ClientCertificate(r *http.Request) *x509.Certificate {
+	cert, _ := r.Context().Value(clientCertContextKey{}).(*x509.Certificate)
+	return cert
+}
+
+// ClientSubject returns the subject of the verified client certificate or an
+// empty string.
+func ClientSubject(r *http.Request) string {
+	if cert := ClientCertificate(r); cert != nil {
+		return cert.Subject.String()
+	}
+	return ""
+}
+
+// ClientCerts returns middleware for Use that verifies client certificates
+// and makes them available with ClientCertificate. Requests to routes marked
+// with RequireClientCert without a valid certificate are passed to the
+// ServeHTTP function as a 401 StatusError.
+func (m *Mux) ClientCerts(config ClientCertConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cert, err := config.verify(r)
+			if err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), clientCertContextKey{}, cert))
+			}
+
+			route := away.CurrentRoute(r.Context())
+			if route != nil && route.Value(clientCertKey{}) != nil && err != nil {
+				m.serveError(w, r, StatusError{Code: http.StatusUnauthorized, Err: err})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// verify returns the verified client certificate of the request.
+func (c ClientCertConfig) verify(r *http.Request) (*x509.Certificate, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, ErrClientCertificate
+	}
+	cert := r.TLS.PeerCertificates[0]
+
+	if c.Roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, ic := range r.TLS.PeerCertificates[1:] {
+			intermediates.AddCert(ic)
+		}
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:         c.Roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			return nil, ErrClientCertificate
+		}
+	} else if len(r.TLS.VerifiedChains) == 0 {
+		return nil, ErrClientCertificate
+	}
+
+	if len(c.Subjects) > 0 {
+		allowed := false
+		for _, s := range c.Subjects {
+			if s == cert.Subject.CommonName {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, ErrClientCertificate
+		}
+	}
+
+	if c.Verify != nil {
+		if err := c.Verify(cert); err != nil {
+			return nil, ErrClientCertificate
+		}
+	}
+
+	return cert, nil
+}
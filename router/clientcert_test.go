@@ -0,0 +1,107 @@
+package router
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCert returns a certificate signed by parent, or self-signed if parent
+// is nil.
+func testCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func TestClientCerts(t *testing.T) {
+	ca, caKey := testCert(t, "ca", nil, nil)
+	client, _ := testCert(t, "billing", ca, caKey)
+	other, _ := testCert(t, "billing", nil, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	mux := New()
+	mux.Use(mux.ClientCerts(ClientCertConfig{Roots: roots, Subjects: []string{"billing"}}))
+	mux.Get("/internal", func(w http.ResponseWriter, r *http.Request) (err error) {
+		w.Write([]byte(ClientSubject(r)))
+		return nil
+	}).RequireClientCert()
+	mux.Get("/public", func(w http.ResponseWriter, r *http.Request) (err error) {
+		w.Write([]byte("public " + ClientSubject(r)))
+		return nil
+	})
+
+	for _, tc := range []struct {
+		path   string
+		certs  []*x509.Certificate
+		status int
+		body   string
+	}{
+		{"/internal", []*x509.Certificate{client}, 200, "CN=billing"},
+		{"/internal", nil, 401, ""},
+		{"/internal", []*x509.Certificate{other}, 401, ""},
+		{"/public", nil, 200, "public "},
+		{"/public", []*x509.Certificate{client}, 200, "public CN=billing"},
+	} {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		if tc.certs != nil {
+			r.TLS = &tls.ConnectionState{PeerCertificates: tc.certs}
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		if tc.status == 200 {
+			assert.Equal(t, tc.body, w.Body.String(), tc.path)
+		}
+	}
+}
+
+func TestClientCertsSubjects(t *testing.T) {
+	ca, caKey := testCert(t, "ca", nil, nil)
+	client, _ := testCert(t, "reports", ca, caKey)
+
+	mux := New()
+	mux.Use(mux.ClientCerts(ClientCertConfig{Subjects: []string{"billing"}}))
+	mux.Get("/internal", func(w http.ResponseWriter, r *http.Request) (err error) {
+		return nil
+	}).RequireClientCert()
+
+	r := httptest.NewRequest("GET", "/internal", nil)
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{client},
+		VerifiedChains:   [][]*x509.Certificate{{client, ca}},
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, 401, w.Code)
+}
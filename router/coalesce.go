@@ -0,0 +1,137 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/ambientkit/away"
+)
+
+// coalesceKey is the route value key for coalesced routes.
+type coalesceKey struct{}
+
+// coalescedCall is a request being served for all identical requests.
+type coalescedCall struct {
+	done chan struct{}
+	resp *bufferWriter
+}
+
+// Coalesce marks a GET or HEAD route so concurrent identical requests share
+// one execution of the handler. It is enforced by the Coalesce middleware.
+func (rt *Route) Coalesce() *Route {
+	rt.route.WithValue(coalesceKey{}, true)
+	return rt
+}
+
+// Coalesce returns middleware for Use that runs the handler once for
+// concurrent identical GET and HEAD requests to a route marked with
+// Coalesce and sends the buffered response to each of them, to protect
+// expensive endpoints during cache stampedes. Requests are identical if
+// they match the same route and have the same key. If key is nil, the key
+// is the request URI with the Authorization and Cookie headers so responses
+// aren't shared between users. Responses that set a cookie, or that were
+// served for a request canceled by its client, aren't shared; the waiting
+// requests run the handler themselves instead.
+func (m *Mux) Coalesce(key func(r *http.Request) string) func(http.Handler) http.Handler {
+	if key == nil {
+		key = func(r *http.Request) string {
+			return r.URL.RequestURI() + "\x00" + r.Header.Get("Authorization") + "\x00" + r.Header.Get("Cookie")
+		}
+	}
+
+	var mu sync.Mutex
+	calls := make(map[string]*coalescedCall)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			if route == nil || route.Value(coalesceKey{}) == nil ||
+				(r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			k := r.Method + " " + route.Pattern() + "\x00" + key(r)
+			mu.Lock()
+			if c, ok := calls[k]; ok {
+				mu.Unlock()
+				select {
+				case <-c.done:
+				case <-r.Context().Done():
+					return
+				}
+				if c.resp == nil {
+					// The response can't be shared so serve the request alone.
+					next.ServeHTTP(w, r)
+					return
+				}
+				c.resp.writeTo(w)
+				return
+			}
+			c := &coalescedCall{done: make(chan struct{})}
+			calls[k] = c
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				delete(calls, k)
+				mu.Unlock()
+				close(c.done)
+			}()
+
+			bw := &bufferWriter{header: make(http.Header)}
+			next.ServeHTTP(bw, r)
+			if shareable(bw, r) {
+				c.resp = bw
+			}
+			bw.writeTo(w)
+		})
+	}
+}
+
+// shareable returns true if the response of the request can be sent to the
+// requests coalesced onto it. A Set-Cookie header, such as a new session
+// for an anonymous user, belongs to one client, and the response to a
+// canceled request may be incomplete.
+func shareable(bw *bufferWriter, r *http.Request) bool {
+	return r.Context().Err() == nil && len(bw.header.Values("Set-Cookie")) == 0
+}
+
+// bufferWriter buffers a response so it can be written more than once.
+type bufferWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferWriter) WriteHeader(status int) {
+	if w.status == 0 && !informational(status) {
+		w.status = status
+	}
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// writeTo writes the buffered response.
+func (w *bufferWriter) writeTo(rw http.ResponseWriter) {
+	h := rw.Header()
+	for k, v := range w.header {
+		h[k] = append([]string(nil), v...)
+	}
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	rw.WriteHeader(status)
+	rw.Write(w.body.Bytes())
+}
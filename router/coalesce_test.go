@@ -0,0 +1,117 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalesce(t *testing.T) {
+	mux := New()
+	mux.Use(mux.Coalesce(nil))
+
+	var calls int32
+	release := make(chan struct{})
+	mux.Get("/report", func(w http.ResponseWriter, r *http.Request) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("X-Report", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("report"))
+		return nil
+	}).Coalesce()
+
+	const n = 5
+	var wg sync.WaitGroup
+	recorders := make([]*httptest.ResponseRecorder, n)
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+		}(recorders[i])
+	}
+
+	for atomic.LoadInt32(&calls) == 0 {
+		runtime.Gosched()
+	}
+	// Give the other requests time to join the call in flight.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, w := range recorders {
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "report", w.Body.String())
+		assert.Equal(t, "1", w.Header().Get("X-Report"))
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/report", nil))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCoalesceNotShared(t *testing.T) {
+	for name, leader := range map[string]func(w http.ResponseWriter, r *http.Request){
+		"set-cookie": func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "leader"})
+		},
+		"canceled": func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mux := New()
+			mux.Use(mux.Coalesce(nil))
+
+			var calls int32
+			release := make(chan struct{})
+			mux.Get("/report", func(w http.ResponseWriter, r *http.Request) error {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					<-release
+					leader(w, r)
+				}
+				w.Write([]byte("report"))
+				return nil
+			}).Coalesce()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r := httptest.NewRequest("GET", "/report", nil).WithContext(ctx)
+				mux.ServeHTTP(httptest.NewRecorder(), r)
+			}()
+			for atomic.LoadInt32(&calls) == 0 {
+				runtime.Gosched()
+			}
+
+			follower := httptest.NewRecorder()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mux.ServeHTTP(follower, httptest.NewRequest("GET", "/report", nil))
+			}()
+			time.Sleep(50 * time.Millisecond)
+			if name == "canceled" {
+				cancel()
+			}
+			close(release)
+			wg.Wait()
+			cancel()
+
+			assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+			assert.Equal(t, "report", follower.Body.String())
+			assert.Empty(t, follower.Header().Values("Set-Cookie"))
+		})
+	}
+}
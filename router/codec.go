@@ -0,0 +1,225 @@
+package router
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned when no registered codec matches the Accept
+// header.
+var ErrNotAcceptable = errors.New("router: not acceptable")
+
+// Codec encodes and decodes values for a media type. Codecs are registered
+// with Mux.RegisterCodec, such as msgpack.Codec of router/msgpack for
+// application/x-msgpack and ProtobufCodec for application/x-protobuf. Other
+// formats can be added by wrapping the Marshal and Unmarshal functions of
+// their packages.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes and decodes JSON.
+type JSONCodec struct{}
+
+// Marshal returns the JSON encoding of v.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// XMLCodec encodes and decodes XML.
+type XMLCodec struct{}
+
+// Marshal returns the XML encoding of v.
+func (XMLCodec) Marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+// Unmarshal decodes XML into v.
+func (XMLCodec) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+// ProtobufCodec encodes and decodes protocol buffers messages that have
+// Marshal and Unmarshal methods, such as those generated by gogo/protobuf,
+// or MarshalVT and UnmarshalVT methods, such as those generated by
+// vtprotobuf. Messages generated by google.golang.org/protobuf only have
+// reflection methods, so register a codec that wraps proto.Marshal and
+// proto.Unmarshal for them instead.
+type ProtobufCodec struct{}
+
+// Marshal returns the protocol buffers encoding of v.
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case interface{ MarshalVT() ([]byte, error) }:
+		return m.MarshalVT()
+	case interface{ Marshal() ([]byte, error) }:
+		return m.Marshal()
+	}
+	return nil, fmt.Errorf("router: protobuf: %T has no Marshal method", v)
+}
+
+// Unmarshal decodes protocol buffers into v.
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	switch m := v.(type) {
+	case interface{ UnmarshalVT([]byte) error }:
+		return m.UnmarshalVT(data)
+	case interface{ Unmarshal([]byte) error }:
+		return m.Unmarshal(data)
+	}
+	return fmt.Errorf("router: protobuf: %T has no Unmarshal method", v)
+}
+
+// registeredCodec is a codec and its media type.
+type registeredCodec struct {
+	mediaType string
+	codec     Codec
+}
+
+// RegisterCodec sets the codec for a media type used by Respond and Bind.
+// A new Mux has a JSON codec which is used when the client accepts any
+// media type.
+func (m *Mux) RegisterCodec(mediaType string, codec Codec) {
+	mediaType = strings.ToLower(mediaType)
+	for i, rc := range m.codecs {
+		if rc.mediaType == mediaType {
+			m.codecs[i].codec = codec
+			return
+		}
+	}
+	m.codecs = append(m.codecs, registeredCodec{mediaType: mediaType, codec: codec})
+}
+
+// Respond encodes v with the codec that best matches the Accept header and
+// writes it with the status code. If no codec matches, a 406 StatusError is
+// returned.
+func (m *Mux) Respond(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+	rc, ok := m.negotiate(r.Header.Get("Accept"))
+	if !ok {
+		return StatusError{Code: http.StatusNotAcceptable, Err: ErrNotAcceptable}
+	}
+
+	b, err := rc.codec.Marshal(v)
+	if err != nil {
+		return StatusError{Code: http.StatusInternalServerError, Err: err}
+	}
+
+	w.Header().Set("Content-Type", rc.mediaType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	_, err = w.Write(b)
+	return err
+}
+
+// codec returns the codec for a media type or nil.
+func (m *Mux) codec(mediaType string) Codec {
+	mediaType = strings.ToLower(mediaType)
+	for _, rc := range m.codecs {
+		if rc.mediaType == mediaType {
+			return rc.codec
+		}
+	}
+	return nil
+}
+
+// acceptRange is a media range from an Accept header.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// negotiate returns the codec that best matches the Accept header.
+func (m *Mux) negotiate(accept string) (registeredCodec, bool) {
+	if len(m.codecs) == 0 {
+		return registeredCodec{}, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return m.codecs[0], true
+	}
+
+	ranges := parseAccept(accept)
+	for _, ar := range ranges {
+		if ar.q <= 0 {
+			continue
+		}
+		for _, rc := range m.codecs {
+			if mediaMatch(ar.mediaType, rc.mediaType) && !excluded(ranges, rc.mediaType) {
+				return rc, true
+			}
+		}
+	}
+
+	return registeredCodec{}, false
+}
+
+// parseAccept returns the media ranges of an Accept header sorted by
+// quality and then specificity.
+func parseAccept(accept string) []acceptRange {
+	out := make([]acceptRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		out = append(out, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].q != out[j].q {
+			return out[i].q > out[j].q
+		}
+		return specificity(out[i].mediaType) > specificity(out[j].mediaType)
+	})
+
+	return out
+}
+
+// excluded returns true if the media type is explicitly refused with q=0.
+func excluded(ranges []acceptRange, mediaType string) bool {
+	for _, ar := range ranges {
+		if ar.q <= 0 && ar.mediaType == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaMatch returns true if a media range matches a media type.
+func mediaMatch(mediaRange string, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return false
+}
+
+// specificity ranks exact media types above partial and full wildcards.
+func specificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,127 @@
+package router
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away/router/msgpack"
+	"github.com/stretchr/testify/assert"
+)
+
+// textCodec encodes a testUser as its name.
+type textCodec struct{}
+
+func (textCodec) Marshal(v interface{}) ([]byte, error) {
+	u, ok := v.(testUser)
+	if !ok {
+		return nil, errors.New("unsupported type")
+	}
+	return []byte(u.Name), nil
+}
+
+func (textCodec) Unmarshal(data []byte, v interface{}) error {
+	u, ok := v.(*testUser)
+	if !ok {
+		return errors.New("unsupported type")
+	}
+	u.Name = string(data)
+	return nil
+}
+
+// testMessage is a protocol buffers message with a single string field.
+type testMessage struct {
+	Name string
+}
+
+func (m *testMessage) Marshal() ([]byte, error) {
+	return append([]byte{0x0a, byte(len(m.Name))}, m.Name...), nil
+}
+
+func (m *testMessage) Unmarshal(data []byte) error {
+	if len(data) < 2 || data[0] != 0x0a || int(data[1]) != len(data)-2 {
+		return errors.New("invalid message")
+	}
+	m.Name = string(data[2:])
+	return nil
+}
+
+func TestRespond(t *testing.T) {
+	mux := New()
+	mux.RegisterCodec("application/x-test", textCodec{})
+
+	for _, tc := range []struct {
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"", 200, "application/json", `{"name":"john"}`},
+		{"*/*", 200, "application/json", `{"name":"john"}`},
+		{"application/x-test", 200, "application/x-test", "john"},
+		{"application/json;q=0.5, application/x-test", 200, "application/x-test", "john"},
+		{"application/*;q=0.9, application/json;q=0", 200, "application/x-test", "john"},
+		{"text/html", 406, "", ""},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+
+		err := mux.Respond(w, r, 200, testUser{Name: "john"})
+		if tc.status != 200 {
+			assert.Equal(t, tc.status, statusCode(err), tc.accept)
+			continue
+		}
+		assert.NoError(t, err, tc.accept)
+		assert.Equal(t, tc.contentType, w.Header().Get("Content-Type"), tc.accept)
+		assert.Equal(t, tc.body, strings.TrimSpace(w.Body.String()), tc.accept)
+	}
+}
+
+func TestBindCodec(t *testing.T) {
+	mux := New()
+	mux.RegisterCodec("application/x-test", textCodec{})
+
+	u := testUser{}
+	assert.NoError(t, mux.Bind(bindRequest("jane", "application/x-test"), &u))
+	assert.Equal(t, "jane", u.Name)
+
+	err := mux.Bind(bindRequest("jane", "application/x-unknown"), &u)
+	assert.Equal(t, 415, statusCode(err))
+
+	mux.SetJSONOptions(JSONOptions{MaxBytes: 2})
+	err = mux.Bind(bindRequest("jane", "application/x-test"), &u)
+	assert.Equal(t, 413, statusCode(err))
+}
+
+func TestMsgpackAndProtobufCodecs(t *testing.T) {
+	mux := New()
+	mux.RegisterCodec(msgpack.MediaType, msgpack.Codec{})
+	mux.RegisterCodec("application/x-protobuf", ProtobufCodec{})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/x-msgpack")
+	assert.NoError(t, mux.Respond(w, r, 200, testUser{Name: "john"}))
+	assert.Equal(t, "application/x-msgpack", w.Header().Get("Content-Type"))
+	assert.Equal(t, "\x81\xa4name\xa4john", w.Body.String())
+
+	u := testUser{}
+	assert.NoError(t, mux.Bind(bindRequest("\x81\xa4name\xa4jane", "application/x-msgpack"), &u))
+	assert.Equal(t, "jane", u.Name)
+
+	w = httptest.NewRecorder()
+	r.Header.Set("Accept", "application/x-protobuf")
+	assert.NoError(t, mux.Respond(w, r, 200, &testMessage{Name: "john"}))
+	assert.Equal(t, "\x0a\x04john", w.Body.String())
+
+	m := testMessage{}
+	assert.NoError(t, mux.Bind(bindRequest("\x0a\x04jane", "application/x-protobuf"), &m))
+	assert.Equal(t, "jane", m.Name)
+
+	err := mux.Respond(httptest.NewRecorder(), r, 200, testUser{Name: "john"})
+	assert.Equal(t, 500, statusCode(err))
+}
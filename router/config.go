@@ -0,0 +1,242 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// ErrMaintenance is returned for requests rejected in maintenance mode.
+var ErrMaintenance = errors.New("router: down for maintenance")
+
+// Config contains the operational settings enforced by the RuntimeConfig
+// middleware. It can be replaced with ApplyConfig while the server runs, such
+// as on SIGHUP or from an admin endpoint.
+type Config struct {
+	// MaxBodyBytes is the maximum size of request bodies. Larger requests
+	// are rejected with a 413 StatusError. Zero means no limit.
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+	// RateLimit is the number of requests a client IP can make in each
+	// RateWindow. Zero means no limit.
+	RateLimit int `json:"rateLimit"`
+	// RateWindow is the length of a rate limit window. Defaults to a minute.
+	RateWindow time.Duration `json:"rateWindow"`
+	// CORSOrigins are the origins allowed to make cross-origin requests,
+	// such as https://example.com, or * for any origin.
+	CORSOrigins []string `json:"corsOrigins"`
+	// Maintenance rejects requests with a 503 StatusError except from the
+	// addresses in MaintenanceAllow.
+	Maintenance bool `json:"maintenance"`
+	// MaintenanceAllow are the IP addresses or CIDR ranges, such as
+	// 10.0.0.0/8, that are served in maintenance mode.
+	MaintenanceAllow []string `json:"maintenanceAllow"`
+}
+
+// runtimeConfig is an applied Config.
+type runtimeConfig struct {
+	Config
+	allow []*net.IPNet
+	rates *rateWindow
+}
+
+// rateWindow counts the requests of each client IP in a fixed window.
+type rateWindow struct {
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+// ApplyConfig validates the config and swaps it in atomically. Requests in
+// flight finish with the previous config. Rate limit counts start over.
+func (m *Mux) ApplyConfig(cfg Config) error {
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+	return m.applyConfig(cfg)
+}
+
+// updateConfig applies a change to the current config.
+func (m *Mux) updateConfig(fn func(cfg *Config)) (Config, error) {
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+
+	cfg := m.Config()
+	fn(&cfg)
+	if err := m.applyConfig(cfg); err != nil {
+		return Config{}, err
+	}
+	return m.Config(), nil
+}
+
+// applyConfig swaps in the config. The config lock must be held.
+func (m *Mux) applyConfig(cfg Config) error {
+	if cfg.RateWindow <= 0 {
+		cfg.RateWindow = time.Minute
+	}
+	cfg.CORSOrigins = append([]string(nil), cfg.CORSOrigins...)
+	cfg.MaintenanceAllow = append([]string(nil), cfg.MaintenanceAllow...)
+
+	rc := &runtimeConfig{
+		Config: cfg,
+		rates:  &rateWindow{counts: make(map[string]int)},
+	}
+	for _, a := range cfg.MaintenanceAllow {
+		if !strings.Contains(a, "/") {
+			if ip := net.ParseIP(a); ip != nil && ip.To4() != nil {
+				a += "/32"
+			} else {
+				a += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(a)
+		if err != nil {
+			return fmt.Errorf("router: maintenance allow %q: %w", a, err)
+		}
+		rc.allow = append(rc.allow, n)
+	}
+
+	m.config.Store(rc)
+	return nil
+}
+
+// Config returns the config set with ApplyConfig.
+func (m *Mux) Config() Config {
+	rc, ok := m.config.Load().(*runtimeConfig)
+	if !ok {
+		return Config{}
+	}
+	cfg := rc.Config
+	cfg.CORSOrigins = append([]string(nil), cfg.CORSOrigins...)
+	cfg.MaintenanceAllow = append([]string(nil), cfg.MaintenanceAllow...)
+	return cfg
+}
+
+// RuntimeConfig returns middleware for Use that enforces the config set with
+// ApplyConfig, reading the current config for each request. It handles
+// maintenance mode, CORS headers and preflight requests, the rate limit,
+// and the body size limit, in that order. The AdminAPI routes are served in
+// maintenance mode.
+func (m *Mux) RuntimeConfig() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rc, ok := m.config.Load().(*runtimeConfig)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := clientIP(r)
+			if rc.Maintenance && !rc.allowed(ip) && !isAdminRoute(away.CurrentRoute(r.Context())) {
+				m.serveError(w, r, StatusError{Code: http.StatusServiceUnavailable, Err: ErrMaintenance})
+				return
+			}
+
+			if origin := r.Header.Get("Origin"); origin != "" && len(rc.CORSOrigins) > 0 {
+				w.Header().Add("Vary", "Origin")
+				if rc.corsAllowed(origin) {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					if method := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && method != "" {
+						w.Header().Set("Access-Control-Allow-Methods", strings.Join(m.preflightMethods(r.URL.Path, method), ", "))
+						if h := r.Header.Get("Access-Control-Request-Headers"); h != "" {
+							w.Header().Set("Access-Control-Allow-Headers", h)
+						}
+						w.WriteHeader(http.StatusNoContent)
+						return
+					}
+				}
+			}
+
+			if rc.RateLimit > 0 {
+				remaining, reset := rc.rates.take(ip, rc.RateLimit, rc.RateWindow)
+				SetRateLimitHeaders(w, rc.RateLimit, remaining, reset)
+				if remaining < 0 {
+					m.serveError(w, r, TooManyRequests(w, reset))
+					return
+				}
+			}
+
+			if rc.MaxBodyBytes > 0 && r.Body != nil {
+				if r.ContentLength > rc.MaxBodyBytes {
+					m.serveError(w, r, StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrBodyTooLarge, ErrorCode: ErrorCodeBodyTooLarge})
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, rc.MaxBodyBytes)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowed returns true if the IP is served in maintenance mode.
+func (rc *runtimeConfig) allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range rc.allow {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsAllowed returns true if the origin can make cross-origin requests.
+func (rc *runtimeConfig) corsAllowed(origin string) bool {
+	for _, o := range rc.CORSOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// take counts a request from the client and returns the number of requests
+// left in the window, which is negative if the limit is exceeded, and the
+// time until the window resets.
+func (rw *rateWindow) take(client string, limit int, window time.Duration) (int, time.Duration) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rw.start) >= window {
+		rw.start = now
+		rw.counts = make(map[string]int)
+	}
+	rw.counts[client]++
+	return limit - rw.counts[client], rw.start.Add(window).Sub(now)
+}
+
+// clientIP returns the IP address of the client without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// preflightMethods returns the methods allowed for a CORS preflight request
+// for the method. Paths with a route for away.MethodAny allow the requested
+// method, which is listed instead of "*" because browsers don't accept the
+// wildcard for requests with credentials.
+func (m *Mux) preflightMethods(path string, method string) []string {
+	methods := m.AllowedMethods(path)
+	if !m.AnyMethod(path) {
+		return methods
+	}
+
+	method = strings.ToUpper(method)
+	for _, v := range methods {
+		if v == method {
+			return methods
+		}
+	}
+	return append(methods, method)
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyConfig(t *testing.T) {
+	mux := New()
+	mux.Use(mux.RuntimeConfig())
+	mux.Post("/upload", func(w http.ResponseWriter, r *http.Request) error {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return StatusError{Code: http.StatusRequestEntityTooLarge, Err: err}
+		}
+		w.Write(b)
+		return nil
+	})
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	// No config.
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/upload", strings.NewReader("hello")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+
+	// Body size.
+	assert.NoError(t, mux.ApplyConfig(Config{MaxBodyBytes: 4}))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/upload", strings.NewReader("hello")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// Chunked bodies are cut off at the limit.
+	var bindErr error
+	mux.Post("/bind", func(w http.ResponseWriter, r *http.Request) error {
+		var v map[string]string
+		bindErr = mux.Bind(r, &v)
+		return bindErr
+	})
+	r := httptest.NewRequest("POST", "/bind", strings.NewReader(`{"a":"hello"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.ContentLength = -1
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	var se StatusError
+	if assert.ErrorAs(t, bindErr, &se) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, se.Code)
+	}
+	assert.ErrorIs(t, bindErr, ErrBodyTooLarge)
+	assert.Equal(t, ErrorCodeBodyTooLarge, ErrorCode(bindErr))
+
+	// Maintenance.
+	assert.NoError(t, mux.ApplyConfig(Config{Maintenance: true, MaintenanceAllow: []string{"10.0.0.0/8", "::1"}}))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	for _, addr := range []string{"10.1.2.3:1234", "[::1]:1234"} {
+		w = httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, addr)
+	}
+
+	assert.Error(t, mux.ApplyConfig(Config{MaintenanceAllow: []string{"nope"}}))
+	assert.True(t, mux.Config().Maintenance)
+
+	// Rate limit.
+	assert.NoError(t, mux.ApplyConfig(Config{RateLimit: 2}))
+	for i, status := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, status, w.Code, i)
+	}
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// Applying a config resets the counts.
+	assert.NoError(t, mux.ApplyConfig(Config{RateLimit: 2}))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestApplyConfigCORS(t *testing.T) {
+	mux := New()
+	mux.Use(mux.RuntimeConfig())
+	mux.Get("/items", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	mux.Post("/items", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	assert.NoError(t, mux.ApplyConfig(Config{CORSOrigins: []string{"https://example.com"}}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/items", nil)
+	r.Header.Set("Origin", "https://example.com")
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/items", nil)
+	r.Header.Set("Origin", "https://evil.com")
+	mux.ServeHTTP(w, r)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("OPTIONS", "/items", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.Header.Set("Access-Control-Request-Method", "POST")
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "POST")
+
+	// Routes for every method allow the requested method.
+	mux.Handle("*", "/uploads/{name}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	mux.Get("/uploads/{name}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	assert.True(t, mux.AnyMethod("/uploads/a"))
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("OPTIONS", "/uploads/a", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.Header.Set("Access-Control-Request-Method", "put")
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+
+	// Reload.
+	assert.NoError(t, mux.ApplyConfig(Config{CORSOrigins: []string{"*"}}))
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/items", nil)
+	r.Header.Set("Origin", "https://evil.com")
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, "https://evil.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
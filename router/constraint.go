@@ -0,0 +1,102 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"regexp/syntax"
+	"sync"
+
+	"github.com/ambientkit/away"
+)
+
+// ErrUnsafeConstraint is returned when a constraint pattern is too complex
+// to match within the budget.
+var ErrUnsafeConstraint = errors.New("router: unsafe constraint pattern")
+
+const (
+	// maxConstraintInsts is the largest compiled program allowed for a
+	// constraint pattern.
+	maxConstraintInsts = 1000
+	// constraintBudget bounds the work to match a parameter, measured as the
+	// parameter length times the program size. Go regular expressions run in
+	// linear time so this bounds the match time. Longer values don't match.
+	constraintBudget = 1 << 18
+)
+
+// constraint is a compiled constraint pattern.
+type constraint struct {
+	re    *regexp.Regexp
+	insts int
+}
+
+// constraints caches compiled constraint patterns so routes that share a
+// pattern compile it once.
+var constraints = struct {
+	sync.Mutex
+	m map[string]*constraint
+}{m: make(map[string]*constraint)}
+
+// compileConstraint returns the compiled constraint for the pattern. The
+// pattern must match the whole parameter value.
+func compileConstraint(pattern string) (*constraint, error) {
+	constraints.Lock()
+	defer constraints.Unlock()
+
+	if c, ok := constraints.m[pattern]; ok {
+		return c, nil
+	}
+
+	anchored := `^(?:` + pattern + `)$`
+	parsed, err := syntax.Parse(anchored, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	if len(prog.Inst) > maxConstraintInsts {
+		return nil, fmt.Errorf("%w %q: %d instructions exceeds %d", ErrUnsafeConstraint, pattern, len(prog.Inst), maxConstraintInsts)
+	}
+
+	re, err := regexp.Compile(anchored)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &constraint{re: re, insts: len(prog.Inst)}
+	constraints.m[pattern] = c
+	return c, nil
+}
+
+// match returns true if the value matches within the budget.
+func (c *constraint) match(value string) bool {
+	if len(value)*c.insts > constraintBudget {
+		return false
+	}
+	return c.re.MatchString(value)
+}
+
+// Constrain only matches the route if every value of the path parameter
+// matches the regular expression, such as `[0-9]+`. The pattern must match
+// the whole value. Otherwise, the next candidate route is tried. The pattern
+// is compiled once when the route is registered and Constrain panics if it
+// is invalid or too complex. Values too long to match within the time budget
+// don't match.
+func (rt *Route) Constrain(param string, pattern string) *Route {
+	c, err := compileConstraint(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return rt.When(func(r *http.Request) bool {
+		for _, p := range away.ParamList(r.Context()) {
+			if p.Name == param && !c.match(p.Value) {
+				return false
+			}
+		}
+		return true
+	})
+}
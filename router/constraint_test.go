@@ -0,0 +1,58 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstrain(t *testing.T) {
+	mux := New()
+	matched := ""
+	handler := func(name string) func(http.ResponseWriter, *http.Request) error {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			matched = name
+			return nil
+		}
+	}
+	mux.Get("/users/{id}", handler("id")).Constrain("id", `[0-9]+`)
+	mux.Get("/users/{name}", handler("name")).Constrain("name", `[a-z]+`)
+
+	for path, want := range map[string]string{
+		"/users/42":                             "id",
+		"/users/bob":                            "name",
+		"/users/4b":                             "",
+		"/users/" + strings.Repeat("1", 100000): "",
+	} {
+		matched = ""
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		if len(path) > 20 {
+			path = path[:20]
+		}
+		assert.Equal(t, want, matched, path)
+	}
+}
+
+func TestCompileConstraint(t *testing.T) {
+	c1, err := compileConstraint(`[0-9]+`)
+	assert.NoError(t, err)
+	c2, err := compileConstraint(`[0-9]+`)
+	assert.NoError(t, err)
+	assert.Same(t, c1, c2)
+
+	_, err = compileConstraint(`(`)
+	assert.Error(t, err)
+
+	_, err = compileConstraint(`(a{1,50}){1,20}`)
+	assert.True(t, errors.Is(err, ErrUnsafeConstraint))
+
+	assert.Panics(t, func() {
+		New().Get("/{id}", func(w http.ResponseWriter, r *http.Request) error {
+			return nil
+		}).Constrain("id", `(`)
+	})
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// consumesKey is the route value key for the allowed request content types.
+type consumesKey struct{}
+
+// Consumes sets the media types the route accepts for request bodies, such
+// as "application/json" or "image/*". It is enforced by the
+// EnforceContentType middleware.
+func (rt *Route) Consumes(mediaTypes ...string) *Route {
+	existing, _ := rt.route.Value(consumesKey{}).([]string)
+	out := append([]string(nil), existing...)
+	for _, mt := range mediaTypes {
+		out = append(out, strings.ToLower(mt))
+	}
+	rt.route.WithValue(consumesKey{}, out)
+	return rt
+}
+
+// EnforceContentType returns middleware for Use that rejects POST, PUT,
+// PATCH, and DELETE requests with a body whose Content-Type isn't allowed
+// by the route. Routes without Consumes allow the defaults. If neither is
+// set, any content type is allowed. Only requests without a body and
+// without a Content-Type header are let through unchecked, so an empty
+// form post can't skip the check. Rejected requests are passed to the
+// ServeHTTP function as a 415 StatusError.
+func (m *Mux) EnforceContentType(defaults ...string) func(http.Handler) http.Handler {
+	lower := make([]string, len(defaults))
+	for i, mt := range defaults {
+		lower[i] = strings.ToLower(mt)
+	}
+	defaults = lower
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed := defaults
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				if consumes, ok := route.Value(consumesKey{}).([]string); ok {
+					allowed = consumes
+				}
+			}
+
+			if len(allowed) == 0 || !unsafeMethod(r.Method) || (noBody(r) && r.Header.Get("Content-Type") == "") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || !mediaAllowed(allowed, mediaType) {
+				m.serveError(w, r, StatusError{Code: http.StatusUnsupportedMediaType, Err: ErrUnsupportedMediaType})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// noBody returns true if the request has no body. A chunked body has a
+// ContentLength of -1 and counts as a body.
+func noBody(r *http.Request) bool {
+	return r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0
+}
+
+// unsafeMethod returns true for methods that change state.
+func unsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// mediaAllowed returns true if the media type matches one of the allowed
+// media ranges.
+func mediaAllowed(allowed []string, mediaType string) bool {
+	for _, a := range allowed {
+		if mediaMatch(a, mediaType) {
+			return true
+		}
+	}
+	return false
+}
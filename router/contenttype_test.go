@@ -0,0 +1,63 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnforceContentType(t *testing.T) {
+	mux := New()
+	mux.Use(mux.EnforceContentType("application/json"))
+
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	mux.Post("/api/users", ok)
+	mux.Get("/api/users", ok)
+	mux.Delete("/api/users", ok)
+	mux.Post("/upload", ok).Consumes("image/*", "multipart/form-data")
+
+	for _, tc := range []struct {
+		method      string
+		path        string
+		body        string
+		contentType string
+		status      int
+	}{
+		{"POST", "/api/users", `{}`, "application/json; charset=utf-8", 200},
+		{"POST", "/api/users", `a=1`, "application/x-www-form-urlencoded", 415},
+		{"POST", "/api/users", `a=1`, "", 415},
+		{"POST", "/api/users", ``, "", 200},
+		{"POST", "/api/users", ``, "application/x-www-form-urlencoded", 415},
+		{"DELETE", "/api/users", ``, "text/plain", 415},
+		{"GET", "/api/users", `a=1`, "text/plain", 200},
+		{"POST", "/upload", `png`, "image/png", 200},
+		{"POST", "/upload", `{}`, "application/json", 415},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.path+" "+tc.contentType)
+	}
+
+	// A chunked body has an unknown length.
+	r := httptest.NewRequest("POST", "/api/users", strings.NewReader("a=1"))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestEnforceContentTypeDefaultsCopied(t *testing.T) {
+	defaults := []string{"Application/JSON"}
+	New().EnforceContentType(defaults...)
+	assert.Equal(t, []string{"Application/JSON"}, defaults)
+}
@@ -0,0 +1,201 @@
+package router
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidCookie is returned when a cookie fails verification or
+// decryption.
+var ErrInvalidCookie = errors.New("router: invalid cookie")
+
+// ErrCookieTooLarge is returned by SetEncrypted for a cookie that browsers
+// would drop because it is larger than maxCookieSize.
+var ErrCookieTooLarge = errors.New("router: cookie is too large")
+
+// maxCookieSize is the size of the largest cookie browsers must accept.
+const maxCookieSize = 4096
+
+// Cookies sets and reads cookies that are signed with HMAC-SHA256 or
+// encrypted with AES-GCM.
+type Cookies struct {
+	signKeys    [][]byte
+	encryptKeys [][]byte
+}
+
+// NewCookies returns cookie helpers for the keys. The first key is used to
+// sign and encrypt new cookies. All keys are tried when reading cookies so
+// old keys can be kept while rotating to a new key. It panics if there are
+// no keys or a key is empty.
+func NewCookies(keys ...[]byte) *Cookies {
+	if len(keys) == 0 {
+		panic("router: NewCookies requires a key")
+	}
+
+	c := &Cookies{}
+	for _, key := range keys {
+		if len(key) == 0 {
+			panic("router: NewCookies requires non-empty keys")
+		}
+		c.signKeys = append(c.signKeys, deriveKey(key, "sign"))
+		c.encryptKeys = append(c.encryptKeys, deriveKey(key, "encrypt"))
+	}
+	return c
+}
+
+// SetSigned sets a cookie whose value can be read by the client but not
+// changed.
+func (c *Cookies) SetSigned(w http.ResponseWriter, cookie *http.Cookie) {
+	value := base64.RawURLEncoding.EncodeToString([]byte(cookie.Value))
+	sig := base64.RawURLEncoding.EncodeToString(sign(c.signKeys[0], cookie.Name, value))
+
+	signed := *cookie
+	signed.Value = value + "." + sig
+	http.SetCookie(w, &signed)
+}
+
+// GetSigned returns the value of a signed cookie.
+func (c *Cookies) GetSigned(r *http.Request, name string) (string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	return c.verify(name, cookie.Value)
+}
+
+// verify returns the value of a signed cookie value.
+func (c *Cookies) verify(name string, signed string) (string, error) {
+	parts := strings.SplitN(signed, ".", 2)
+	if len(parts) != 2 {
+		return "", ErrInvalidCookie
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range c.signKeys {
+		if hmac.Equal(sig, sign(key, name, parts[0])) {
+			value, err := base64.RawURLEncoding.DecodeString(parts[0])
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(value), nil
+		}
+	}
+
+	return "", ErrInvalidCookie
+}
+
+// SetEncrypted sets a cookie whose value can't be read or changed by the
+// client. It returns ErrCookieTooLarge without setting the cookie if it is
+// larger than browsers accept.
+func (c *Cookies) SetEncrypted(w http.ResponseWriter, cookie *http.Cookie) error {
+	value, err := c.encrypt(cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+
+	encrypted := *cookie
+	encrypted.Value = value
+	if len(encrypted.String()) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	http.SetCookie(w, &encrypted)
+	return nil
+}
+
+// GetEncrypted returns the value of an encrypted cookie.
+func (c *Cookies) GetEncrypted(r *http.Request, name string) (string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	return c.decrypt(name, cookie.Value)
+}
+
+// encrypt returns the encrypted cookie value.
+func (c *Cookies) encrypt(name string, value string) (string, error) {
+	aead, err := newAEAD(c.encryptKeys[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt returns the value of an encrypted cookie value.
+func (c *Cookies) decrypt(name string, encrypted string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range c.encryptKeys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return "", err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+
+	return "", ErrInvalidCookie
+}
+
+// Delete removes a cookie from the client.
+func (c *Cookies) Delete(w http.ResponseWriter, name string, path string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   name,
+		Path:   path,
+		MaxAge: -1,
+	})
+}
+
+// deriveKey returns a key for a single purpose so the same secret isn't used
+// for signing and encryption.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// sign returns the signature of a cookie name and value.
+func sign(key []byte, name string, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{'|'})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// newAEAD returns an AES-256-GCM cipher for the key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
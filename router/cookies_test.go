@@ -0,0 +1,81 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cookieRequest returns a request with the cookies set on the recorder.
+func cookieRequest(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestSignedCookie(t *testing.T) {
+	c := NewCookies([]byte("secret"))
+
+	w := httptest.NewRecorder()
+	c.SetSigned(w, &http.Cookie{Name: "user", Value: "john smith", Path: "/"})
+	v, err := c.GetSigned(cookieRequest(w), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "john smith", v)
+
+	// Tampered value.
+	r := httptest.NewRequest("GET", "/", nil)
+	value := w.Result().Cookies()[0].Value
+	r.AddCookie(&http.Cookie{Name: "user", Value: "YWRtaW4" + value[len("am9obiBzbWl0aA"):]})
+	_, err = c.GetSigned(r, "user")
+	assert.Equal(t, ErrInvalidCookie, err)
+
+	// Value moved to a different cookie name.
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "other", Value: value})
+	_, err = c.GetSigned(r, "other")
+	assert.Equal(t, ErrInvalidCookie, err)
+
+	_, err = c.GetSigned(httptest.NewRequest("GET", "/", nil), "user")
+	assert.Equal(t, http.ErrNoCookie, err)
+}
+
+func TestEncryptedCookie(t *testing.T) {
+	c := NewCookies([]byte("secret"))
+
+	w := httptest.NewRecorder()
+	assert.NoError(t, c.SetEncrypted(w, &http.Cookie{Name: "session", Value: "id=5"}))
+	assert.NotContains(t, w.Result().Cookies()[0].Value, "id=5")
+
+	v, err := c.GetEncrypted(cookieRequest(w), "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "id=5", v)
+
+	_, err = NewCookies([]byte("other")).GetEncrypted(cookieRequest(w), "session")
+	assert.Equal(t, ErrInvalidCookie, err)
+}
+
+func TestCookieKeyRotation(t *testing.T) {
+	old := NewCookies([]byte("old"))
+	rotated := NewCookies([]byte("new"), []byte("old"))
+
+	w := httptest.NewRecorder()
+	old.SetSigned(w, &http.Cookie{Name: "a", Value: "signed"})
+	assert.NoError(t, old.SetEncrypted(w, &http.Cookie{Name: "b", Value: "encrypted"}))
+	r := cookieRequest(w)
+
+	v, err := rotated.GetSigned(r, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "signed", v)
+	v, err = rotated.GetEncrypted(r, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "encrypted", v)
+}
+
+func TestNewCookiesRequiresKeys(t *testing.T) {
+	assert.Panics(t, func() { NewCookies() })
+	assert.Panics(t, func() { NewCookies([]byte("secret"), nil) })
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// noDeadlineKey is the route value key for routes without a request
+// deadline.
+type noDeadlineKey struct{}
+
+// SetRequestDeadline sets a deadline on the context of every request before
+// the handler runs so calls that use the context time out. Unlike the server
+// write timeout, the handler can still write an error response. Zero
+// disables the deadline.
+func (m *Mux) SetRequestDeadline(d time.Duration) {
+	m.requestDeadline = d
+}
+
+// NoDeadline excludes the route from the request deadline, for long lived
+// responses such as server-sent events and websockets.
+func (rt *Route) NoDeadline() *Route {
+	rt.route.WithValue(noDeadlineKey{}, true)
+	return rt
+}
+
+// withDeadline returns a handler function that runs fn with the request
+// deadline.
+func (m *Mux) withDeadline(fn func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if m.requestDeadline <= 0 {
+			return fn(w, r)
+		}
+		if route := away.CurrentRoute(r.Context()); route != nil && route.Value(noDeadlineKey{}) != nil {
+			return fn(w, r)
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), m.requestDeadline)
+		defer cancel()
+		return fn(w, r.WithContext(ctx))
+	}
+}
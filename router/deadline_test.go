@@ -0,0 +1,35 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	mux := New()
+	mux.SetRequestDeadline(time.Minute)
+
+	var deadline time.Time
+	var ok bool
+	fn := func(w http.ResponseWriter, r *http.Request) error {
+		deadline, ok = r.Context().Deadline()
+		return nil
+	}
+	mux.Get("/", fn)
+	mux.Get("/events", fn).NoDeadline()
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/events", nil))
+	assert.False(t, ok)
+
+	mux.SetRequestDeadline(0)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.False(t, ok)
+}
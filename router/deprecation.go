@@ -0,0 +1,88 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// deprecatedKey is the route value key for the deprecation of a route.
+type deprecatedKey struct{}
+
+// deprecation is the retirement plan of a deprecated route.
+type deprecation struct {
+	sunset time.Time
+	link   string
+}
+
+// DeprecatedUse describes a request to a deprecated route.
+type DeprecatedUse struct {
+	Time      time.Time
+	Method    string
+	Pattern   string
+	Sunset    time.Time
+	Principal string
+	ClientIP  string
+	UserAgent string
+}
+
+// Deprecated marks the route as deprecated. The sunset is the time the route
+// stops responding and may be zero if it isn't known. The link is the URL of
+// documentation about the deprecation and may be empty. The headers are set
+// by the Deprecation middleware.
+func (rt *Route) Deprecated(sunset time.Time, link string) *Route {
+	rt.route.WithValue(deprecatedKey{}, deprecation{sunset: sunset, link: link})
+	return rt
+}
+
+// Deprecation returns middleware for Use that sets the Deprecation, Sunset,
+// and Link headers on responses of routes marked with Deprecated. If observe
+// is not nil, it is called for each request to a deprecated route so the
+// remaining clients can be found. The principal is read with
+// CurrentPrincipal so authentication middleware must run first.
+func (m *Mux) Deprecation(observe func(use DeprecatedUse)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			d, ok := route.Value(deprecatedKey{}).(deprecation)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Deprecation", "true")
+			if !d.sunset.IsZero() {
+				w.Header().Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.link != "" {
+				w.Header().Add("Link", "<"+d.link+`>; rel="deprecation"`)
+			}
+
+			if observe != nil {
+				use := DeprecatedUse{
+					Time:      time.Now(),
+					Method:    r.Method,
+					Pattern:   route.Pattern(),
+					Sunset:    d.sunset,
+					ClientIP:  r.RemoteAddr,
+					UserAgent: r.UserAgent(),
+				}
+				if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+					use.ClientIP = host
+				}
+				if p := CurrentPrincipal(r); p != nil {
+					use.Principal = p.ID()
+				}
+				observe(use)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
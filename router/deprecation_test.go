@@ -0,0 +1,51 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecation(t *testing.T) {
+	mux := New()
+	var uses []DeprecatedUse
+	mux.Use(mux.Deprecation(func(use DeprecatedUse) {
+		uses = append(uses, use)
+	}))
+
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mux.Get("/v1/users", ok).Deprecated(sunset, "https://example.com/docs/v2")
+	mux.Get("/v1/teams", ok).Deprecated(time.Time{}, "")
+	mux.Get("/v2/users", ok)
+
+	r := httptest.NewRequest("GET", "/v1/users", nil)
+	r.Header.Set("User-Agent", "client/1.0")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/docs/v2>; rel="deprecation"`, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/v1/teams", nil))
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+	assert.Empty(t, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/v2/users", nil))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	if assert.Len(t, uses, 2) {
+		assert.Equal(t, "/v1/users", uses[0].Pattern)
+		assert.Equal(t, sunset, uses[0].Sunset)
+		assert.Equal(t, "192.0.2.1", uses[0].ClientIP)
+		assert.Equal(t, "client/1.0", uses[0].UserAgent)
+	}
+}
@@ -0,0 +1,144 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DownloadOptions are the settings of SendFile.
+type DownloadOptions struct {
+	// Inline asks the browser to display the file instead of saving it.
+	Inline bool
+	// Filename is the name the browser saves the file as. It defaults to the
+	// base name of the file.
+	Filename string
+	// CacheControl is the Cache-Control header of the response, if set.
+	CacheControl string
+}
+
+// SendFile writes the file name from fsys to the response as a download. It
+// sets Content-Type from the file extension, or by sniffing the content,
+// and a Content-Disposition header with the filename encoded for non-ASCII
+// names. Range requests and If-None-Match, If-Modified-Since and the other
+// conditional headers are handled by http.ServeContent when the file is
+// seekable, using a weak ETag from the size and modification time unless
+// one is already set. A missing file or a directory returns a 404
+// StatusError.
+func (m *Mux) SendFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, options DownloadOptions) error {
+	if !fs.ValidPath(name) {
+		return StatusError{Code: http.StatusNotFound, Err: fs.ErrInvalid}
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return StatusError{Code: http.StatusNotFound, Err: err}
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return StatusError{Code: http.StatusInternalServerError, Err: err}
+	} else if fi.IsDir() {
+		return StatusError{Code: http.StatusNotFound, Err: nil}
+	}
+
+	filename := options.Filename
+	if filename == "" {
+		filename = path.Base(name)
+	}
+	disposition := "attachment"
+	if options.Inline {
+		disposition = "inline"
+	}
+
+	h := w.Header()
+	h.Set("Content-Disposition", contentDisposition(disposition, filename))
+	h.Set("X-Content-Type-Options", "nosniff")
+	if options.CacheControl != "" {
+		h.Set("Cache-Control", options.CacheControl)
+	}
+	if h.Get("ETag") == "" {
+		h.Set("ETag", fmt.Sprintf(`W/"%x-%x"`, fi.Size(), fi.ModTime().UnixNano()))
+	}
+	if h.Get("Content-Type") == "" {
+		if ct := mime.TypeByExtension(path.Ext(filename)); ct != "" {
+			h.Set("Content-Type", ct)
+		}
+	}
+
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, filename, fi.ModTime(), rs)
+		return nil
+	}
+
+	// Without Seek the file is streamed whole and sniffed from its start.
+	var body io.Reader = f
+	if h.Get("Content-Type") == "" {
+		buf := make([]byte, 512)
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return StatusError{Code: http.StatusInternalServerError, Err: err}
+		}
+		h.Set("Content-Type", http.DetectContentType(buf[:n]))
+		body = io.MultiReader(bytes.NewReader(buf[:n]), f)
+	}
+	h.Set("Last-Modified", fi.ModTime().UTC().Format(http.TimeFormat))
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// contentDisposition returns a Content-Disposition header value with an
+// ASCII filename for old clients and an RFC 6266 filename* parameter when
+// the name isn't plain ASCII.
+func contentDisposition(disposition string, filename string) string {
+	ascii := true
+	var fallback strings.Builder
+	for _, c := range filename {
+		switch {
+		case c == '"' || c == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(c)
+		case c < ' ' || c == 0x7f:
+			fallback.WriteByte('_')
+		case c > 0x7f:
+			ascii = false
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(c)
+		}
+	}
+
+	v := disposition + `; filename="` + fallback.String() + `"`
+	if ascii {
+		return v
+	}
+
+	var encoded strings.Builder
+	for _, b := range []byte(filename) {
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return v + "; filename*=UTF-8''" + encoded.String()
+}
+
+// isAttrChar returns true if the byte can appear unencoded in an RFC 8187
+// extended parameter value.
+func isAttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"reports/q1.csv": {Data: []byte("a,b\n1,2\n"), ModTime: time.Unix(100, 0)},
+		"blob":           {Data: []byte("<html><body>hi</body></html>")},
+	}
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Get("/download/{name}", func(w http.ResponseWriter, r *http.Request) error {
+		options := DownloadOptions{CacheControl: "private, max-age=60"}
+		if r.URL.Query().Get("inline") != "" {
+			options.Inline = true
+			options.Filename = "Prüfbericht \"Q1\".csv"
+		}
+		return mux.SendFile(w, r, fsys, "reports/"+mux.Param(r, "name"), options)
+	})
+
+	serve := func(target string, headers ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", target, nil)
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/download/q1.csv")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a,b\n1,2\n", w.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="q1.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = serve("/download/q1.csv", "Range", "bytes=4-")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "1,2\n", w.Body.String())
+	assert.Equal(t, "bytes 4-7/8", w.Header().Get("Content-Range"))
+
+	w = serve("/download/q1.csv", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	w = serve("/download/q1.csv", "If-Modified-Since", time.Unix(200, 0).UTC().Format(http.TimeFormat))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve("/download/q1.csv?inline=1")
+	assert.Equal(t, `inline; filename="Pr_fbericht \"Q1\".csv"; filename*=UTF-8''Pr%C3%BCfbericht%20%22Q1%22.csv`, w.Header().Get("Content-Disposition"))
+
+	w = serve("/download/missing.csv")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	err := mux.SendFile(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), fsys, "../blob", DownloadOptions{})
+	assert.Equal(t, http.StatusNotFound, err.(StatusError).Code)
+	err = mux.SendFile(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), fsys, "reports", DownloadOptions{})
+	assert.Equal(t, http.StatusNotFound, err.(StatusError).Code)
+}
+
+// streamFS is a file system with files that can't seek.
+type streamFS struct{ fstest.MapFS }
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.MapFS.Open(name)
+	return struct{ fs.File }{f}, err
+}
+
+func TestSendFileNotSeekable(t *testing.T) {
+	fsys := streamFS{fstest.MapFS{"blob": {Data: []byte("<html><body>hi</body></html>")}}}
+
+	w := httptest.NewRecorder()
+	err := New().SendFile(w, httptest.NewRequest("GET", "/", nil), fsys, "blob", DownloadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "<html><body>hi</body></html>", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+}
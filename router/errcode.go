@@ -0,0 +1,161 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// errorCode is a registered error code.
+type errorCode struct {
+	status  int
+	message string
+	err     error
+}
+
+var (
+	errorCodesMu sync.RWMutex
+	errorCodes   = map[string]errorCode{}
+)
+
+// The error codes registered by default. Use them instead of string
+// literals so a typo can't produce an unregistered code.
+const (
+	ErrorCodeBadRequest        = "bad_request"
+	ErrorCodeUnauthenticated   = "unauthenticated"
+	ErrorCodeForbidden         = "forbidden"
+	ErrorCodeInsufficientScope = "insufficient_scope"
+	ErrorCodeNotFound          = "not_found"
+	ErrorCodeBodyTimeout       = "body_timeout"
+	ErrorCodeConflict          = "conflict"
+	ErrorCodeBodyTooLarge      = "body_too_large"
+	ErrorCodeTooManyRequests   = "too_many_requests"
+	ErrorCodeInternal          = "internal"
+)
+
+func init() {
+	RegisterErrorCode(ErrorCodeBadRequest, http.StatusBadRequest, "The request is invalid.")
+	RegisterErrorCode(ErrorCodeUnauthenticated, http.StatusUnauthorized, "Authentication is required.")
+	RegisterErrorCode(ErrorCodeForbidden, http.StatusForbidden, "You don't have permission to do this.")
+	RegisterErrorCode(ErrorCodeInsufficientScope, http.StatusForbidden, "The token is missing a required scope.")
+	RegisterErrorCode(ErrorCodeNotFound, http.StatusNotFound, "The resource was not found.")
+	RegisterErrorCode(ErrorCodeBodyTimeout, http.StatusRequestTimeout, "The request body took too long to send.")
+	RegisterErrorCode(ErrorCodeConflict, http.StatusConflict, "The request conflicts with the current state.")
+	RegisterErrorCode(ErrorCodeBodyTooLarge, http.StatusRequestEntityTooLarge, "The request body is too large.")
+	RegisterErrorCode(ErrorCodeTooManyRequests, http.StatusTooManyRequests, "Too many requests.")
+	RegisterErrorCode(ErrorCodeInternal, http.StatusInternalServerError, "Something went wrong.")
+}
+
+// RegisterErrorCode registers a machine-readable error code, such as
+// user_not_found, with its default status and user friendly message, so
+// clients can branch on stable codes instead of messages. Registering a code
+// again replaces it.
+func RegisterErrorCode(code string, status int, message string) {
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+
+	ec, ok := errorCodes[code]
+	if !ok {
+		ec.err = errors.New("router: " + code)
+	}
+	ec.status = status
+	ec.message = message
+	errorCodes[code] = ec
+}
+
+// lookupErrorCode returns the registered error code. Unregistered codes are
+// internal errors.
+func lookupErrorCode(code string) errorCode {
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
+
+	ec, ok := errorCodes[code]
+	if !ok {
+		return errorCode{
+			status:  http.StatusInternalServerError,
+			message: errorCodes[ErrorCodeInternal].message,
+			err:     errors.New("router: " + code),
+		}
+	}
+	return ec
+}
+
+// NewError returns a StatusError with the code and its registered status and
+// message. The error of codes registered with RegisterErrorCode is the same
+// for each call, so errors.Is can compare them. Unregistered codes are 500
+// errors.
+func NewError(code string) StatusError {
+	ec := lookupErrorCode(code)
+	return StatusError{
+		Code:      ec.status,
+		Err:       ec.err,
+		Friendly:  ec.message,
+		ErrorCode: code,
+	}
+}
+
+// ErrorCode returns the machine-readable code of the error, or "" if it
+// doesn't have one.
+func ErrorCode(err error) string {
+	var se StatusError
+	if errors.As(err, &se) {
+		return se.ErrorCode
+	}
+	return ""
+}
+
+// Problem is an RFC 9457 problem details response body with the error code.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+// ProblemFor returns the problem details of an error. The detail is the user
+// friendly message of the error or its code, so internal error strings
+// aren't sent to clients.
+func ProblemFor(err error) Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Status: http.StatusInternalServerError,
+		Code:   ErrorCode(err),
+	}
+
+	var e Error
+	if errors.As(err, &e) {
+		p.Status = e.Status()
+		p.Detail = e.Message()
+	}
+	if p.Detail == "" && p.Code != "" {
+		p.Detail = lookupErrorCode(p.Code).message
+	}
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// ServeProblem is a ServeHTTP function for SetServeHTTP that writes errors
+// as application/problem+json responses with their error code. Responses for
+// an InsufficientScopeError list the missing scopes.
+func ServeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	p := ProblemFor(err)
+	var scope InsufficientScopeError
+	if errors.As(err, &scope) {
+		writeProblem(w, p.Status, scopeProblem{Problem: p, MissingScopes: scope.Missing})
+		return
+	}
+	writeProblem(w, p.Status, p)
+}
+
+// writeProblem writes a problem details response.
+func writeProblem(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewError(t *testing.T) {
+	RegisterErrorCode("user_not_found", http.StatusNotFound, "The user doesn't exist.")
+
+	err := NewError("user_not_found")
+	assert.Equal(t, http.StatusNotFound, err.Status())
+	assert.Equal(t, "The user doesn't exist.", err.Message())
+	assert.Equal(t, "router: user_not_found", err.Error())
+	assert.True(t, errors.Is(NewError("user_not_found").Err, err.Err))
+	assert.Equal(t, "user_not_found", ErrorCode(fmt.Errorf("load: %w", err)))
+	assert.Equal(t, "", ErrorCode(errors.New("plain")))
+
+	unknown := NewError("unregistered")
+	assert.Equal(t, http.StatusInternalServerError, unknown.Status())
+	assert.Equal(t, "unregistered", unknown.ErrorCode)
+}
+
+func TestServeProblem(t *testing.T) {
+	RegisterErrorCode("user_not_found", http.StatusNotFound, "The user doesn't exist.")
+
+	mux := New()
+	mux.SetServeHTTP(ServeProblem)
+	mux.Use(mux.Authorize(testRoleAuthorizer))
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		switch mux.Param(r, "id") {
+		case "1":
+			return nil
+		case "2":
+			return errors.New("database password is hunter2")
+		}
+		return NewError("user_not_found")
+	})
+	mux.Get("/admin", func(w http.ResponseWriter, r *http.Request) error { return nil }).Requires("admin")
+
+	for _, tc := range []struct {
+		path    string
+		status  int
+		problem Problem
+	}{
+		{"/users/1", http.StatusOK, Problem{}},
+		{"/users/3", http.StatusNotFound, Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "The user doesn't exist.", Code: "user_not_found"}},
+		{"/users/2", http.StatusInternalServerError, Problem{Type: "about:blank", Title: "Internal Server Error", Status: 500}},
+		{"/admin", http.StatusUnauthorized, Problem{Type: "about:blank", Title: "Unauthorized", Status: 401, Detail: "Authentication is required.", Code: "unauthenticated"}},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		if tc.status == http.StatusOK {
+			assert.Empty(t, w.Body.String())
+			continue
+		}
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		var p Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		assert.Equal(t, tc.problem, p, tc.path)
+	}
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrHandler is a handler that returns an error for the ServeHTTP function.
+type ErrHandler func(w http.ResponseWriter, r *http.Request) error
+
+// ErrMiddleware wraps an ErrHandler and may return its own error.
+type ErrMiddleware func(next ErrHandler) ErrHandler
+
+// innerErrKey is the context key for the error returned by the handler
+// inside an error middleware.
+type innerErrKey struct{}
+
+// UseErr adds error middleware that wraps the handlers of the routes
+// registered afterwards. An error returned by the middleware is joined with
+// the error of the handler it wraps using errors.Join so the ServeHTTP
+// function sees both. A middleware that returns nil handled the error.
+func (m *Mux) UseErr(mw ...ErrMiddleware) {
+	m.errMiddleware = append(m.errMiddleware, mw...)
+}
+
+// wrapErr returns fn wrapped in the error middleware.
+func (m *Mux) wrapErr(fn ErrHandler) ErrHandler {
+	for i := len(m.errMiddleware) - 1; i >= 0; i-- {
+		fn = joinErr(m.errMiddleware[i], fn)
+	}
+	return fn
+}
+
+// joinErr wraps next in the middleware and joins the errors they return.
+func joinErr(mw ErrMiddleware, next ErrHandler) ErrHandler {
+	wrapped := mw(func(w http.ResponseWriter, r *http.Request) error {
+		err := next(w, r)
+		if inner, ok := r.Context().Value(innerErrKey{}).(*error); ok {
+			*inner = err
+		}
+		return err
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var inner error
+		err := wrapped(w, r.WithContext(context.WithValue(r.Context(), innerErrKey{}, &inner)))
+		if err == nil || inner == nil || errors.Is(err, inner) {
+			return err
+		}
+		return errors.Join(inner, err)
+	}
+}
@@ -0,0 +1,60 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseErr(t *testing.T) {
+	mux := New()
+	var served error
+	mux.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		served = err
+	})
+
+	errCleanup := errors.New("cleanup failed")
+	errHandler := StatusError{Code: http.StatusConflict, Err: errors.New("conflict")}
+	mux.UseErr(func(next ErrHandler) ErrHandler {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			next(w, r)
+			if r.URL.Query().Get("cleanup") == "fail" {
+				return errCleanup
+			}
+			return nil
+		}
+	}, func(next ErrHandler) ErrHandler {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			return next(w, r)
+		}
+	})
+
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("handler") == "fail" {
+			return errHandler
+		}
+		return nil
+	})
+
+	for _, tc := range []struct {
+		query string
+		check func(err error)
+	}{
+		{"", func(err error) { assert.NoError(t, err) }},
+		{"handler=fail", func(err error) { assert.NoError(t, err) }},
+		{"cleanup=fail", func(err error) { assert.Equal(t, errCleanup, err) }},
+		{"handler=fail&cleanup=fail", func(err error) {
+			assert.True(t, errors.Is(err, errCleanup))
+			var se StatusError
+			assert.True(t, errors.As(err, &se))
+			assert.Equal(t, errHandler, se)
+		}},
+	} {
+		served = errors.New("not called")
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?"+tc.query, nil))
+		tc.check(served)
+	}
+}
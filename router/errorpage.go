@@ -0,0 +1,165 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+// ErrorPage is the data passed to an error page template.
+type ErrorPage struct {
+	Status    int
+	Title     string
+	Message   string
+	Code      string
+	RequestID string
+}
+
+// errorLayout is the layout of the default error pages. Each page defines
+// the message block.
+const errorLayout = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Status}} {{.Title}}</title>
+<style>
+body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;font-family:system-ui,-apple-system,"Segoe UI",sans-serif;background:#f6f7f9;color:#1f2328}
+main{max-width:32rem;padding:2rem;text-align:center}
+h1{margin:0;font-size:4rem;font-weight:600;color:#57606a}
+h2{margin:.5rem 0 1rem;font-size:1.25rem;font-weight:500}
+p{margin:0 0 1.5rem;line-height:1.5;color:#57606a}
+a{color:#0969da}
+small{display:block;margin-top:2rem;color:#8c959f;font-family:ui-monospace,monospace}
+@media (prefers-color-scheme:dark){body{background:#0d1117;color:#e6edf3}h1,p{color:#8d96a0}a{color:#4493f8}}
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Status}}</h1>
+<h2>{{.Title}}</h2>
+<p>{{if .Message}}{{.Message}}{{else}}{{template "message" .}}{{end}}</p>
+<a href="/">Go to the home page</a>
+{{if .RequestID}}<small>Request ID: {{.RequestID}}</small>{{end}}
+</main>
+</body>
+</html>
+{{define "message"}}An error occurred while handling your request.{{end}}`
+
+// errorPage returns a default error page with the message.
+func errorPage(message string) *template.Template {
+	tmpl := template.Must(template.New("error").Parse(errorLayout))
+	if message != "" {
+		template.Must(tmpl.New("message").Parse(message))
+	}
+	return tmpl
+}
+
+// DefaultErrorPage is the error page template of statuses without one in
+// DefaultErrorPages.
+var DefaultErrorPage = errorPage("")
+
+// DefaultErrorPages are the built-in error page templates by status code.
+// They are executed with an ErrorPage.
+var DefaultErrorPages = map[int]*template.Template{
+	http.StatusNotFound:            errorPage("The page you're looking for doesn't exist or has moved."),
+	http.StatusInternalServerError: errorPage("Something went wrong on our end. Please try again later."),
+	http.StatusServiceUnavailable:  errorPage("The service is temporarily unavailable. Please try again in a few minutes."),
+}
+
+// ErrorPages serves errors as HTML pages to browsers. Requests that don't
+// accept text/html, such as API clients, are passed to Fallback.
+type ErrorPages struct {
+	// Pages override the templates for status codes. They are executed with
+	// an ErrorPage.
+	Pages map[int]*template.Template
+	// Default overrides the built-in templates for statuses without one in
+	// Pages.
+	Default *template.Template
+	// Fallback serves errors to clients that don't accept HTML. It defaults
+	// to a plain text response with the status text.
+	Fallback func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// SetErrorPages serves handler errors and unmatched paths with the error
+// pages, so new apps get presentable error pages before they customize
+// anything. It replaces the ServeHTTP function and the NotFound handler;
+// call SetServeHTTP or SetNotFound afterwards to override either.
+func (m *Mux) SetErrorPages(pages ErrorPages) {
+	m.SetServeHTTP(pages.ServeError)
+	m.SetNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages.ServeError(w, r, StatusError{Code: http.StatusNotFound})
+	}))
+}
+
+// ServeError is a ServeHTTP function for SetServeHTTP that writes the error
+// page for the status of the error. The page shows the user friendly
+// message of the error or the message of its error code, and the
+// X-Request-ID header so users can quote it when reporting a problem.
+func (p ErrorPages) ServeError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+
+	if !acceptsHTML(r) {
+		if p.Fallback != nil {
+			p.Fallback(w, r, err)
+			return
+		}
+		status := http.StatusInternalServerError
+		var e Error
+		if errors.As(err, &e) {
+			status = e.Status()
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	problem := ProblemFor(err)
+	page := ErrorPage{
+		Status:    problem.Status,
+		Title:     problem.Title,
+		Message:   problem.Detail,
+		Code:      problem.Code,
+		RequestID: r.Header.Get("X-Request-ID"),
+	}
+
+	tmpl := p.template(page.Status)
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, page); err != nil {
+		http.Error(w, http.StatusText(page.Status), page.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(page.Status)
+	w.Write(buf.Bytes())
+}
+
+// template returns the error page template for a status.
+func (p ErrorPages) template(status int) *template.Template {
+	if tmpl, ok := p.Pages[status]; ok && tmpl != nil {
+		return tmpl
+	}
+	if p.Default != nil {
+		return p.Default
+	}
+	if tmpl, ok := DefaultErrorPages[status]; ok {
+		return tmpl
+	}
+	return DefaultErrorPage
+}
+
+// acceptsHTML returns true if the Accept header of the request lists
+// text/html, as browsers do for page loads.
+func acceptsHTML(r *http.Request) bool {
+	for _, ar := range parseAccept(r.Header.Get("Accept")) {
+		if ar.mediaType == "text/html" {
+			return ar.q > 0
+		}
+	}
+	return false
+}
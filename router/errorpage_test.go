@@ -0,0 +1,89 @@
+package router
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorPages(t *testing.T) {
+	mux := New()
+	mux.SetErrorPages(ErrorPages{
+		Pages: map[int]*template.Template{
+			http.StatusForbidden: template.Must(template.New("").Parse(`no access: {{.Message}}`)),
+		},
+	})
+	mux.Get("/boom", func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database password is hunter2")
+	})
+	mux.Get("/down", func(w http.ResponseWriter, r *http.Request) error {
+		return StatusError{Code: http.StatusServiceUnavailable}
+	})
+	mux.Get("/forbidden", func(w http.ResponseWriter, r *http.Request) error {
+		return StatusError{Code: http.StatusForbidden, Friendly: "ask an <admin>"}
+	})
+	mux.Get("/conflict", func(w http.ResponseWriter, r *http.Request) error {
+		return NewError("conflict")
+	})
+
+	serve := func(target string, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", target, nil)
+		r.Header.Set("Accept", accept)
+		r.Header.Set("X-Request-ID", "req-1")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	browser := "text/html,application/xhtml+xml,*/*;q=0.8"
+
+	w := serve("/missing", browser)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<title>404 Not Found</title>")
+	assert.Contains(t, w.Body.String(), "doesn't exist")
+	assert.Contains(t, w.Body.String(), "Request ID: req-1")
+
+	w = serve("/boom", browser)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "Something went wrong")
+	assert.NotContains(t, w.Body.String(), "hunter2")
+
+	w = serve("/down", browser)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "temporarily unavailable")
+
+	w = serve("/forbidden", browser)
+	assert.Equal(t, "no access: ask an &lt;admin&gt;", w.Body.String())
+
+	w = serve("/conflict", browser)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "<h2>Conflict</h2>")
+
+	w = serve("/missing", "application/json")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Not Found\n", w.Body.String())
+	w = serve("/missing", "text/html;q=0, */*")
+	assert.Equal(t, "Not Found\n", w.Body.String())
+}
+
+func TestErrorPagesOverrides(t *testing.T) {
+	page := ErrorPages{
+		Default:  template.Must(template.New("").Parse(`themed {{.Status}}`)),
+		Fallback: ServeProblem,
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	page.ServeError(w, r, StatusError{Code: http.StatusNotFound})
+	assert.Equal(t, "themed 404", w.Body.String())
+
+	r.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	page.ServeError(w, r, StatusError{Code: http.StatusNotFound})
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
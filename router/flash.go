@@ -0,0 +1,185 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrNoFlashStore is returned when flash messages are used without a store.
+var ErrNoFlashStore = errors.New("router: no flash store set")
+
+// flashesKey is the context key for the flash messages of a rendered page.
+type flashesKey struct{}
+
+// FlashMessage is a message shown to the user on the next page they view.
+type FlashMessage struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// FlashStore saves flash messages between requests.
+type FlashStore interface {
+	// Add saves a message for the client.
+	Add(w http.ResponseWriter, r *http.Request, message FlashMessage) error
+	// Pop returns and removes the messages for the client.
+	Pop(w http.ResponseWriter, r *http.Request) ([]FlashMessage, error)
+}
+
+// SetFlashStore sets the store used by Flash and Flashes.
+func (m *Mux) SetFlashStore(store FlashStore) {
+	m.flashes = store
+}
+
+// Flash saves a message to show on the next page the client views, usually
+// after a redirect.
+func (m *Mux) Flash(w http.ResponseWriter, r *http.Request, level string, message string) error {
+	if m.flashes == nil {
+		return ErrNoFlashStore
+	}
+	return m.flashes.Add(w, r, FlashMessage{Level: level, Message: message})
+}
+
+// Flashes returns and removes the flash messages for the client.
+func (m *Mux) Flashes(w http.ResponseWriter, r *http.Request) ([]FlashMessage, error) {
+	if m.flashes == nil {
+		return nil, ErrNoFlashStore
+	}
+	return m.flashes.Pop(w, r)
+}
+
+// CurrentFlashes returns and removes the flash messages for a request being
+// rendered with Mux.Render. It is intended to be called from a template
+// function so messages are only removed when a page shows them.
+func CurrentFlashes(r *http.Request) []FlashMessage {
+	fn, ok := r.Context().Value(flashesKey{}).(func() []FlashMessage)
+	if !ok {
+		return nil
+	}
+	return fn()
+}
+
+// withFlashes returns a request that can read flash messages with
+// CurrentFlashes.
+func (m *Mux) withFlashes(w http.ResponseWriter, r *http.Request) *http.Request {
+	if m.flashes == nil {
+		return r
+	}
+
+	var once sync.Once
+	var messages []FlashMessage
+	fn := func() []FlashMessage {
+		once.Do(func() {
+			messages, _ = m.Flashes(w, r)
+		})
+		return messages
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), flashesKey{}, fn))
+}
+
+// CookieFlashStore saves flash messages in an encrypted cookie.
+type CookieFlashStore struct {
+	cookies *Cookies
+	name    string
+}
+
+// NewCookieFlashStore returns a flash store that saves messages in an
+// encrypted cookie named flash.
+func NewCookieFlashStore(cookies *Cookies) *CookieFlashStore {
+	return &CookieFlashStore{
+		cookies: cookies,
+		name:    "flash",
+	}
+}
+
+// Add saves a message for the client.
+func (s *CookieFlashStore) Add(w http.ResponseWriter, r *http.Request, message FlashMessage) error {
+	messages := s.pending(w)
+	if messages == nil {
+		messages = s.read(r)
+	}
+	messages = append(messages, message)
+
+	b, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+
+	s.clear(w)
+	return s.cookies.SetEncrypted(w, &http.Cookie{
+		Name:     s.name,
+		Value:    string(b),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Pop returns and removes the messages for the client.
+func (s *CookieFlashStore) Pop(w http.ResponseWriter, r *http.Request) ([]FlashMessage, error) {
+	messages := s.pending(w)
+	if messages == nil {
+		messages = s.read(r)
+	}
+
+	s.clear(w)
+	if _, err := r.Cookie(s.name); err == nil {
+		s.cookies.Delete(w, s.name, "/")
+	}
+
+	return messages, nil
+}
+
+// read returns the messages in the request cookie.
+func (s *CookieFlashStore) read(r *http.Request) []FlashMessage {
+	value, err := s.cookies.GetEncrypted(r, s.name)
+	if err != nil {
+		return nil
+	}
+	return s.decode(value)
+}
+
+// pending returns the messages already added to the response or nil.
+func (s *CookieFlashStore) pending(w http.ResponseWriter) []FlashMessage {
+	resp := http.Response{Header: w.Header()}
+	for _, c := range resp.Cookies() {
+		if c.Name != s.name || c.MaxAge < 0 {
+			continue
+		}
+		value, err := s.cookies.decrypt(s.name, c.Value)
+		if err != nil {
+			return nil
+		}
+		return s.decode(value)
+	}
+	return nil
+}
+
+// clear removes the flash cookie from the response headers.
+func (s *CookieFlashStore) clear(w http.ResponseWriter) {
+	headers := w.Header()["Set-Cookie"]
+	kept := headers[:0]
+	for _, v := range headers {
+		if !strings.HasPrefix(v, s.name+"=") {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == 0 {
+		w.Header().Del("Set-Cookie")
+		return
+	}
+	w.Header()["Set-Cookie"] = kept
+}
+
+// decode returns the messages in a cookie value.
+func (s *CookieFlashStore) decode(value string) []FlashMessage {
+	messages := make([]FlashMessage, 0)
+	if err := json.Unmarshal([]byte(value), &messages); err != nil {
+		return nil
+	}
+	return messages
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlash(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetFlashStore(NewCookieFlashStore(NewCookies([]byte("secret"))))
+
+	mux.Post("/save", func(w http.ResponseWriter, r *http.Request) error {
+		if err := mux.Flash(w, r, "success", "Saved."); err != nil {
+			return err
+		}
+		if err := mux.Flash(w, r, "info", "Next step."); err != nil {
+			return err
+		}
+		http.Redirect(w, r, "/", http.StatusFound)
+		return nil
+	})
+
+	var flashes []FlashMessage
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) (err error) {
+		flashes, err = mux.Flashes(w, r)
+		return err
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/save", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Len(t, w.Result().Cookies(), 1)
+
+	r := cookieRequest(w)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, []FlashMessage{
+		{Level: "success", Message: "Saved."},
+		{Level: "info", Message: "Next step."},
+	}, flashes)
+	assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(t, flashes, 0)
+}
+
+type flashRenderer struct{}
+
+func (fr flashRenderer) Render(w io.Writer, r *http.Request, name string, data interface{}) error {
+	for _, f := range CurrentFlashes(r) {
+		fmt.Fprintf(w, "[%v] %v", f.Level, f.Message)
+	}
+	return nil
+}
+
+func TestRenderFlashes(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetRenderer(flashRenderer{})
+	mux.SetFlashStore(NewCookieFlashStore(NewCookies([]byte("secret"))))
+
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		if err := mux.Flash(w, r, "error", "Invalid."); err != nil {
+			return err
+		}
+		return mux.Render(w, r, http.StatusOK, "page.tmpl", nil)
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "[error] Invalid.", w.Body.String())
+	assert.Len(t, w.Result().Cookies(), 0)
+}
+
+func TestNoFlashStore(t *testing.T) {
+	mux := New()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, ErrNoFlashStore, mux.Flash(w, r, "info", "message"))
+	_, err := mux.Flashes(w, r)
+	assert.Equal(t, ErrNoFlashStore, err)
+	assert.Nil(t, CurrentFlashes(r))
+}
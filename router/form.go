@@ -0,0 +1,238 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFormMemory is the number of bytes of a multipart form kept in memory.
+const maxFormMemory = 32 << 20
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// FieldError describes a form field that couldn't be decoded.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors is a list of field errors.
+type FieldErrors []FieldError
+
+// Error returns the field errors as a single message.
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, 0, len(fe))
+	for _, e := range fe {
+		msgs = append(msgs, e.Field+": "+e.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// DecodeForm decodes a urlencoded or multipart form into the struct pointed
+// to by dst. Fields are matched by the form tag, or the field name if there
+// is no tag, and a tag of "-" skips the field. Nested structs use the tag as
+// a prefix, e.g. address.city. Slices are filled from repeated values.
+// time.Time fields use the layout tag, or RFC 3339 and 2006-01-02 if there
+// is no tag. Multipart files can be decoded into *multipart.FileHeader and
+// []*multipart.FileHeader fields.
+//
+// A malformed form returns a 400 StatusError and values that can't be
+// converted return a 422 StatusError that wraps FieldErrors.
+func (m *Mux) DecodeForm(r *http.Request, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("router: DecodeForm requires a pointer to a struct")
+	}
+
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		err = r.ParseMultipartForm(maxFormMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return readError(err)
+	}
+
+	var files map[string][]*multipart.FileHeader
+	if r.MultipartForm != nil {
+		files = r.MultipartForm.File
+	}
+
+	d := formDecoder{values: r.Form, files: files}
+	d.decodeStruct(v.Elem(), "")
+	if len(d.errs) > 0 {
+		return StatusError{Code: http.StatusUnprocessableEntity, Err: d.errs}
+	}
+
+	return nil
+}
+
+// formDecoder sets struct fields from form values.
+type formDecoder struct {
+	values map[string][]string
+	files  map[string][]*multipart.FileHeader
+	errs   FieldErrors
+}
+
+// decodeStruct sets the fields of a struct.
+func (d *formDecoder) decodeStruct(v reflect.Value, prefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name := sf.Tag.Get("form")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		name = prefix + name
+
+		fv := v.Field(i)
+		switch {
+		case sf.Type == fileHeaderType:
+			if files := d.files[name]; len(files) > 0 {
+				fv.Set(reflect.ValueOf(files[0]))
+			}
+		case sf.Type.Kind() == reflect.Slice && sf.Type.Elem() == fileHeaderType:
+			if files := d.files[name]; len(files) > 0 {
+				fv.Set(reflect.ValueOf(files))
+			}
+		case sf.Type.Kind() == reflect.Struct && sf.Type != timeType:
+			d.decodeStruct(fv, name+".")
+		case sf.Type.Kind() == reflect.Slice:
+			values, ok := d.values[name]
+			if !ok {
+				continue
+			}
+			slice := reflect.MakeSlice(sf.Type, 0, len(values))
+			for _, s := range values {
+				ev := reflect.New(sf.Type.Elem()).Elem()
+				if err := setFormValue(ev, s, sf.Tag.Get("layout")); err != nil {
+					d.errs = append(d.errs, FieldError{Field: name, Message: err.Error()})
+					break
+				}
+				slice = reflect.Append(slice, ev)
+			}
+			fv.Set(slice)
+		default:
+			values, ok := d.values[name]
+			if !ok || len(values) == 0 {
+				continue
+			}
+			if err := setFormValue(fv, values[0], sf.Tag.Get("layout")); err != nil {
+				d.errs = append(d.errs, FieldError{Field: name, Message: err.Error()})
+			}
+		}
+	}
+}
+
+// setFormValue converts a form value to the type of v.
+func setFormValue(v reflect.Value, s string, layout string) error {
+	if v.Kind() == reflect.Ptr {
+		if s == "" {
+			return nil
+		}
+		pv := reflect.New(v.Type().Elem())
+		if err := setFormValue(pv.Elem(), s, layout); err != nil {
+			return err
+		}
+		v.Set(pv)
+		return nil
+	}
+
+	if v.Type() == timeType {
+		if s == "" {
+			return nil
+		}
+		t, err := parseFormTime(s, layout)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			v.SetBool(false)
+			return nil
+		}
+		if s == "on" {
+			v.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive whole number")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+
+	return nil
+}
+
+// parseFormTime parses a time with the layout or the default layouts.
+func parseFormTime(s string, layout string) (time.Time, error) {
+	if layout != "" {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return t, fmt.Errorf("must be a time in the format %v", layout)
+		}
+		return t, nil
+	}
+
+	for _, l := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New("must be a date")
+}
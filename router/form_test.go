@@ -0,0 +1,124 @@
+package router
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAddress struct {
+	City string `form:"city"`
+	Zip  int    `form:"zip"`
+}
+
+type testForm struct {
+	Name     string      `form:"name"`
+	Age      int         `form:"age"`
+	Score    *float64    `form:"score"`
+	Admin    bool        `form:"admin"`
+	Tags     []string    `form:"tags"`
+	IDs      []uint      `form:"ids"`
+	Birthday time.Time   `form:"birthday" layout:"01/02/2006"`
+	Created  time.Time   `form:"created"`
+	Address  testAddress `form:"address"`
+	Ignored  string      `form:"-"`
+	Untagged string
+}
+
+func TestDecodeForm(t *testing.T) {
+	mux := New()
+
+	form := url.Values{}
+	form.Set("name", "John")
+	form.Set("age", "42")
+	form.Set("score", "9.5")
+	form.Set("admin", "on")
+	form.Add("tags", "a")
+	form.Add("tags", "b")
+	form.Add("ids", "1")
+	form.Add("ids", "2")
+	form.Set("birthday", "12/31/1990")
+	form.Set("created", "2021-03-04")
+	form.Set("address.city", "Boston")
+	form.Set("address.zip", "02101")
+	form.Set("Ignored", "x")
+	form.Set("Untagged", "y")
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	dst := testForm{}
+	assert.NoError(t, mux.DecodeForm(r, &dst))
+
+	score := 9.5
+	assert.Equal(t, testForm{
+		Name:     "John",
+		Age:      42,
+		Score:    &score,
+		Admin:    true,
+		Tags:     []string{"a", "b"},
+		IDs:      []uint{1, 2},
+		Birthday: time.Date(1990, 12, 31, 0, 0, 0, 0, time.UTC),
+		Created:  time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+		Address:  testAddress{City: "Boston", Zip: 2101},
+		Untagged: "y",
+	}, dst)
+}
+
+func TestDecodeFormErrors(t *testing.T) {
+	mux := New()
+
+	form := url.Values{}
+	form.Set("age", "old")
+	form.Add("ids", "1")
+	form.Add("ids", "-2")
+	form.Set("birthday", "1990-12-31")
+	form.Set("address.zip", "abc")
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	err := mux.DecodeForm(r, &testForm{})
+	assert.Equal(t, StatusError{Code: http.StatusUnprocessableEntity, Err: FieldErrors{
+		{Field: "age", Message: "must be a whole number"},
+		{Field: "ids", Message: "must be a positive whole number"},
+		{Field: "birthday", Message: "must be a time in the format 01/02/2006"},
+		{Field: "address.zip", Message: "must be a whole number"},
+	}}, err)
+
+	assert.Error(t, mux.DecodeForm(r, testForm{}))
+}
+
+func TestDecodeMultipartForm(t *testing.T) {
+	mux := New()
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("title", "Report")
+	fw, _ := mw.CreateFormFile("file", "report.txt")
+	fw.Write([]byte("contents"))
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	dst := struct {
+		Title string                `form:"title"`
+		File  *multipart.FileHeader `form:"file"`
+	}{}
+	assert.NoError(t, mux.DecodeForm(r, &dst))
+	assert.Equal(t, "Report", dst.Title)
+	assert.Equal(t, "report.txt", dst.File.Filename)
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader("broken"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	err := mux.DecodeForm(r, &dst)
+	assert.Equal(t, http.StatusBadRequest, err.(StatusError).Code)
+}
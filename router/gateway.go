@@ -0,0 +1,102 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrGateway is returned when a gateway error response doesn't have a
+// message.
+var ErrGateway = errors.New("router: gateway error")
+
+// gatewayStatus is the JSON body of a gRPC-gateway error response.
+type gatewayStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// MountGateway registers a gRPC-gateway mux, or any http.Handler, for all
+// methods under the path prefix. The prefix is stripped from the request
+// path so the gateway sees the paths of its service definitions. Gateway
+// error responses are passed to the ServeHTTP function as a StatusError with
+// the HTTP status and the gRPC message. The request headers listed in
+// metadata are also sent with the Grpc-Metadata- prefix so the gateway
+// forwards them to the service as incoming metadata.
+func (m *Mux) MountGateway(prefix string, gateway http.Handler, metadata ...string) *Route {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	strip := strings.TrimSuffix(prefix, "/")
+
+	return m.handle("*", prefix, func(w http.ResponseWriter, r *http.Request) error {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, strip), "/")
+		r2.URL.RawPath = ""
+		for _, name := range metadata {
+			if values := r.Header.Values(name); len(values) > 0 {
+				r2.Header["Grpc-Metadata-"+http.CanonicalHeaderKey(name)] = values
+			}
+		}
+
+		gw := &gatewayWriter{ResponseWriter: w}
+		gateway.ServeHTTP(gw, r2)
+		if gw.status == 0 {
+			return nil
+		}
+
+		var st gatewayStatus
+		err := ErrGateway
+		if json.Unmarshal(gw.body.Bytes(), &st) == nil && st.Message != "" {
+			err = errors.New(st.Message)
+		}
+		w.Header().Del("Content-Length")
+		return StatusError{Code: gw.status, Err: err}
+	})
+}
+
+// gatewayWriter holds back error responses so they can be translated and
+// writes all other responses through.
+type gatewayWriter struct {
+	http.ResponseWriter
+	// status is set if the response is an error.
+	status  int
+	started bool
+	body    bytes.Buffer
+}
+
+func (w *gatewayWriter) WriteHeader(status int) {
+	if w.started || w.status != 0 {
+		return
+	}
+	if status >= http.StatusBadRequest {
+		w.status = status
+		return
+	}
+	if !informational(status) {
+		w.started = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gatewayWriter) Write(b []byte) (int, error) {
+	if w.status != 0 {
+		return w.body.Write(b)
+	}
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client for streaming responses.
+func (w *gatewayWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && w.status == 0 {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying response writer.
+func (w *gatewayWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountGateway(t *testing.T) {
+	mux := New()
+	var served error
+	mux.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		served = err
+		if e, ok := err.(Error); ok {
+			http.Error(w, e.Error(), e.Status())
+		}
+	})
+
+	gw := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/users/1":
+			w.Header().Set("X-Tenant", r.Header.Get("Grpc-Metadata-X-Tenant"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"1"}`))
+		case "/v1/users/2":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"user not found","details":[]}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	mux.MountGateway("/api", gw, "X-Tenant")
+
+	r := httptest.NewRequest("GET", "/api/v1/users/1", nil)
+	r.Header.Set("X-Tenant", "acme")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":"1"}`, w.Body.String())
+	assert.Equal(t, "acme", w.Header().Get("X-Tenant"))
+	assert.Nil(t, served)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/users/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, StatusError{Code: http.StatusNotFound, Err: errors.New("user not found")}, served)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/other", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, StatusError{Code: http.StatusBadGateway, Err: ErrGateway}, served)
+}
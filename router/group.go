@@ -0,0 +1,244 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// stackKey is the route value key for the names of the group middleware.
+type stackKey struct{}
+
+// Group registers routes under a path prefix with middleware that only
+// wraps those routes.
+type Group struct {
+	mux        *Mux
+	prefix     string
+	middleware []func(http.Handler) http.Handler
+	when       []func(r *http.Request) bool
+	cache      *string
+	rates      map[string]Rate
+	scopes     []string
+	trail      []string
+	noIndex    bool
+}
+
+// Group returns a group for routes under the path prefix.
+func (m *Mux) Group(prefix string) *Group {
+	g := &Group{
+		mux:    m,
+		prefix: strings.TrimSuffix(prefix, "/"),
+	}
+	if g.prefix != "" {
+		g.trail = []string{g.prefix}
+	}
+	return g
+}
+
+// Group returns a child group for routes under the path prefix. The child
+// inherits the middleware and conditions added to g so far.
+func (g *Group) Group(prefix string) *Group {
+	child := &Group{
+		mux:        g.mux,
+		prefix:     g.prefix + strings.TrimSuffix(prefix, "/"),
+		middleware: append([]func(http.Handler) http.Handler(nil), g.middleware...),
+		when:       append([]func(r *http.Request) bool(nil), g.when...),
+		cache:      g.cache,
+		rates:      g.rates,
+		scopes:     g.scopes,
+		trail:      g.trail,
+		noIndex:    g.noIndex,
+	}
+	if child.prefix != g.prefix {
+		child.trail = append(append([]string(nil), g.trail...), child.prefix)
+	}
+	return child
+}
+
+// Use adds middleware that wraps the routes registered with the group
+// afterwards. Group middleware runs after the middleware added with
+// Mux.Use.
+func (g *Group) Use(mw ...func(http.Handler) http.Handler) *Group {
+	g.middleware = append(g.middleware, mw...)
+	return g
+}
+
+// When only matches the routes registered with the group afterwards if fn
+// returns true for the request.
+func (g *Group) When(fn func(r *http.Request) bool) *Group {
+	g.when = append(g.when, fn)
+	return g
+}
+
+// ForTenant only matches the routes registered with the group afterwards
+// for requests of the tenants.
+func (g *Group) ForTenant(tenants ...string) *Group {
+	g.when = append(g.when, func(r *http.Request) bool {
+		t := Tenant(r)
+		for _, v := range tenants {
+			if v == t {
+				return true
+			}
+		}
+		return false
+	})
+	return g
+}
+
+// CacheControl sets the Cache-Control header value for the routes
+// registered with the group afterwards. It is set by the CacheControl
+// middleware.
+func (g *Group) CacheControl(value string) *Group {
+	g.cache = &value
+	return g
+}
+
+// NoStore sets the Cache-Control header value for the routes registered with
+// the group afterwards to "no-store".
+func (g *Group) NoStore() *Group {
+	return g.CacheControl("no-store")
+}
+
+// RateLimit sets the rate for principals on the plan for the routes
+// registered with the group afterwards. It is enforced by the Throttle
+// middleware.
+func (g *Group) RateLimit(plan string, rate Rate) *Group {
+	rates := make(map[string]Rate, len(g.rates)+1)
+	for k, v := range g.rates {
+		rates[k] = v
+	}
+	rates[plan] = rate
+	g.rates = rates
+	return g
+}
+
+// Scopes sets the token scopes required by the routes registered with the
+// group afterwards. See Route.Scopes.
+func (g *Group) Scopes(scopes ...string) *Group {
+	g.scopes = append(append([]string(nil), g.scopes...), scopes...)
+	return g
+}
+
+// NoIndex excludes the routes registered with the group afterwards from
+// search engines. See Route.NoIndex.
+func (g *Group) NoIndex() *Group {
+	g.noIndex = true
+	return g
+}
+
+// Handle registers a method and pattern with the group. The pattern is
+// appended to the group prefix.
+func (g *Group) Handle(method string, path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.mux.handleWith(method, g.prefix+path, fn, g.configure, g.middleware...)
+}
+
+// configure applies the settings of the group to a route before it is
+// registered, so it is never served without the group conditions.
+func (g *Group) configure(rt *Route) {
+	names := make([]string, 0, len(g.middleware))
+	for _, mw := range g.middleware {
+		names = append(names, funcName(mw))
+	}
+	rt.route.WithValue(stackKey{}, names)
+	rt.route.WithValue(trailKey{}, g.trail)
+
+	for _, fn := range g.when {
+		rt.When(fn)
+	}
+
+	if g.cache != nil {
+		rt.CacheControl(*g.cache)
+	}
+
+	for plan, rate := range g.rates {
+		rt.RateLimit(plan, rate)
+	}
+
+	if len(g.scopes) > 0 {
+		rt.Scopes(g.scopes...)
+	}
+
+	if g.noIndex {
+		rt.NoIndex()
+	}
+}
+
+// Replace swaps the handler of a route registered with the group, keeping
+// its middleware, or registers the route with the group if it doesn't
+// exist.
+func (g *Group) Replace(method string, path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	if rt := g.mux.replace(method, g.prefix+path, fn); rt != nil {
+		return rt
+	}
+	return g.Handle(method, path, fn)
+}
+
+// Delete registers a pattern with the group.
+func (g *Group) Delete(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.Handle(http.MethodDelete, path, fn)
+}
+
+// Get registers a pattern with the group.
+func (g *Group) Get(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.Handle(http.MethodGet, path, fn)
+}
+
+// Head registers a pattern with the group.
+func (g *Group) Head(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.Handle(http.MethodHead, path, fn)
+}
+
+// Options registers a pattern with the group.
+func (g *Group) Options(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.Handle(http.MethodOptions, path, fn)
+}
+
+// Patch registers a pattern with the group.
+func (g *Group) Patch(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.Handle(http.MethodPatch, path, fn)
+}
+
+// Post registers a pattern with the group.
+func (g *Group) Post(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.Handle(http.MethodPost, path, fn)
+}
+
+// Put registers a pattern with the group.
+func (g *Group) Put(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return g.Handle(http.MethodPut, path, fn)
+}
+
+// Stack returns the names of the middleware that wrap the route in the
+// order they run: the middleware added with Mux.Use followed by the group
+// middleware.
+func (m *Mux) Stack(route *away.Route) []string {
+	names := append([]string(nil), m.middleware...)
+	if route != nil {
+		group, _ := route.Value(stackKey{}).([]string)
+		names = append(names, group...)
+	}
+	return names
+}
+
+// funcName returns the package qualified name of a function, such as
+// router.(*Mux).Audit.func1.
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func testHeader(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Stack", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	mux := New()
+	mux.Use(testHeader("global"))
+
+	api := mux.Group("/api/").Use(testHeader("api"))
+	v1 := api.Group("/v1").Use(testHeader("v1"))
+	api.Use(testHeader("late"))
+
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	v1.Get("/users/{id}", ok)
+	api.Get("/status", ok)
+	mux.Get("/", ok)
+
+	for _, tc := range []struct {
+		path  string
+		stack string
+	}{
+		{"/api/v1/users/1", "global,api,v1"},
+		{"/api/status", "global,api,late"},
+		{"/", "global"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, tc.path)
+		assert.Equal(t, tc.stack, strings.Join(w.Header()["X-Stack"], ","), tc.path)
+	}
+
+	var route *away.Route
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route = away.CurrentRoute(r.Context())
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/users/1", nil))
+	stack := mux.Stack(route)
+	assert.Len(t, stack, 4)
+	for _, name := range stack {
+		assert.True(t, strings.HasPrefix(name, "router."), name)
+	}
+}
+
+func TestGroupForTenant(t *testing.T) {
+	mux := New()
+	mux.SetTenantResolver(TenantFromHeader("X-Tenant"))
+	mux.Group("/admin").ForTenant("acme").Get("", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/admin", nil)
+	r.Header.Set("X-Tenant", "acme")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// A route added while serving is never matched without the group
+	// conditions.
+	var served int
+	mux.OnChange(func(event away.RouteEvent) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/admin/plugin", nil))
+		served = w.Code
+	})
+	mux.Group("/admin").ForTenant("acme").Get("/plugin", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	assert.Equal(t, http.StatusNotFound, served)
+}
+
+func TestReplace(t *testing.T) {
+	mux := New()
+	api := mux.Group("/api").Use(testHeader("api"))
+	api.Get("/status", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("v1"))
+		return nil
+	})
+	api.Replace("GET", "/status", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("v2"))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/status", nil))
+	assert.Equal(t, "v2", w.Body.String())
+	assert.Equal(t, "api", w.Header().Get("X-Stack"))
+	assert.Equal(t, 1, mux.Count())
+
+	// Replacing through the Mux keeps the group middleware.
+	api.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	mux.Replace("GET", "/api/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("user " + mux.Param(r, "id")))
+		return nil
+	})
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/1", nil))
+	assert.Equal(t, "user 1", w.Body.String())
+	assert.Equal(t, "api", w.Header().Get("X-Stack"))
+	assert.Equal(t, 2, mux.Count())
+
+	// A new route replaced with a group is registered with its middleware.
+	api.Replace("GET", "/new", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/new", nil))
+	assert.Equal(t, "api", w.Header().Get("X-Stack"))
+}
@@ -0,0 +1,241 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrIdempotencyInProgress is returned when a request with the same
+// idempotency key is still being served.
+var ErrIdempotencyInProgress = errors.New("router: request with the same idempotency key is in progress")
+
+// ErrIdempotencyMismatch is returned when an idempotency key is reused with a
+// different request body.
+var ErrIdempotencyMismatch = errors.New("router: idempotency key reused with a different request body")
+
+// IdempotencyHeader is the request header containing the idempotency key.
+const IdempotencyHeader = "Idempotency-Key"
+
+// StoredResponse is a response saved for an idempotency key.
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// BodyHash is the hex SHA-256 of the request body the response was
+	// saved for.
+	BodyHash string
+}
+
+// IdempotencyStore saves responses for idempotency keys.
+type IdempotencyStore interface {
+	// Get returns the response for a key if it has not expired.
+	Get(key string) (*StoredResponse, bool)
+	// Set saves the response for a key for the duration of ttl.
+	Set(key string, resp *StoredResponse, ttl time.Duration)
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryIdempotencyEntry
+	now     func() time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	resp    *StoredResponse
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore returns an in-memory IdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries: make(map[string]memoryIdempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the response for a key if it has not expired.
+func (s *MemoryIdempotencyStore) Get(key string) (*StoredResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !s.now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+
+	return e.resp, true
+}
+
+// Set saves the response for a key for the duration of ttl.
+func (s *MemoryIdempotencyStore) Set(key string, resp *StoredResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+
+	s.entries[key] = memoryIdempotencyEntry{resp: resp, expires: now.Add(ttl)}
+}
+
+// Idempotency returns middleware for Use that saves the first response to a
+// POST or PATCH request with an Idempotency-Key header and replays it for
+// retries with the same key. Server errors are not saved so they can be
+// retried. A retry that arrives while the first request is still being served
+// is passed to the ServeHTTP function as a 409 StatusError.
+//
+// Keys are scoped to the method, the path, and the caller, which is the
+// principal set with WithPrincipal, or else the Authorization header, or else
+// the client IP, so add it after the authentication middleware. A key reused with a different request
+// body is passed to the ServeHTTP function as a 422 StatusError.
+func (m *Mux) Idempotency(store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	inFlight := make(map[string]bool)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key = r.Method + " " + r.URL.Path + " " + idempotencyCaller(r) + " " + key
+
+			var body []byte
+			if r.Body != nil {
+				b, err := readBody(r.Body, m.jsonOptions.MaxBytes)
+				if err != nil {
+					m.serveError(w, r, err)
+					return
+				}
+				body = b
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			bodyHash := hashHex(body)
+
+			replay := func(resp *StoredResponse) {
+				if resp.BodyHash != bodyHash {
+					m.serveError(w, r, StatusError{Code: http.StatusUnprocessableEntity, Err: ErrIdempotencyMismatch})
+					return
+				}
+				replayResponse(w, resp)
+			}
+
+			if resp, ok := store.Get(key); ok {
+				replay(resp)
+				return
+			}
+
+			mu.Lock()
+			if inFlight[key] {
+				mu.Unlock()
+				m.serveError(w, r, StatusError{Code: http.StatusConflict, Err: ErrIdempotencyInProgress, ErrorCode: ErrorCodeConflict})
+				return
+			}
+			inFlight[key] = true
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				delete(inFlight, key)
+				mu.Unlock()
+			}()
+
+			// The first request may have finished between the lookup and
+			// the claim.
+			if resp, ok := store.Get(key); ok {
+				replay(resp)
+				return
+			}
+
+			cw := &captureWriter{ResponseWriter: w}
+			next.ServeHTTP(cw, r)
+
+			if cw.Status() < http.StatusInternalServerError {
+				store.Set(key, &StoredResponse{
+					Status:   cw.Status(),
+					Header:   w.Header().Clone(),
+					Body:     cw.body.Bytes(),
+					BodyHash: bodyHash,
+				}, ttl)
+			}
+		})
+	}
+}
+
+// idempotencyCaller returns the principal ID or a hash of the Authorization
+// header of the request, or the client IP for anonymous requests.
+func idempotencyCaller(r *http.Request) string {
+	if p := CurrentPrincipal(r); p != nil {
+		return "principal:" + p.ID()
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return "authorization:" + hashHex([]byte(auth))
+	}
+	return "address:" + clientIP(r)
+}
+
+// hashHex returns the hex SHA-256 of b.
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayResponse writes a stored response.
+func replayResponse(w http.ResponseWriter, resp *StoredResponse) {
+	for k, v := range resp.Header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// captureWriter copies the status and body of a response as it is written.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 && !informational(status) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status code of the response.
+func (w *captureWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,179 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	store := NewMemoryIdempotencyStore()
+	mux.Use(mux.Idempotency(store, time.Hour))
+
+	calls := 0
+	mux.Post("/payments", func(w http.ResponseWriter, r *http.Request) error {
+		calls++
+		w.Header().Set("X-Payment", fmt.Sprint(calls))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "payment %v", calls)
+		return nil
+	})
+
+	post := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/payments", nil)
+		if key != "" {
+			r.Header.Set(IdempotencyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("abc")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "payment 1", w.Body.String())
+	assert.Equal(t, "", w.Header().Get("Idempotent-Replayed"))
+
+	w = post("abc")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "payment 1", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Payment"))
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+
+	w = post("def")
+	assert.Equal(t, "payment 2", w.Body.String())
+	w = post("")
+	assert.Equal(t, "payment 3", w.Body.String())
+	w = post("")
+	assert.Equal(t, "payment 4", w.Body.String())
+}
+
+func TestIdempotencyScoped(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.Idempotency(NewMemoryIdempotencyStore(), time.Hour))
+
+	calls := 0
+	mux.Post("/payments", func(w http.ResponseWriter, r *http.Request) error {
+		calls++
+		fmt.Fprintf(w, "payment %v", calls)
+		return nil
+	})
+
+	post := func(auth, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+		r.Header.Set(IdempotencyHeader, "abc")
+		r.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, "payment 1", post("Bearer alice", `{"amount":1}`).Body.String())
+	assert.Equal(t, "payment 2", post("Bearer bob", `{"amount":1}`).Body.String())
+	assert.Equal(t, "payment 1", post("Bearer alice", `{"amount":1}`).Body.String())
+
+	w := post("Bearer alice", `{"amount":2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 2, calls)
+
+	// Anonymous callers are scoped by address.
+	anonymous := func(addr string) string {
+		r := httptest.NewRequest("POST", "/payments", strings.NewReader(`{"amount":1}`))
+		r.Header.Set(IdempotencyHeader, "abc")
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	assert.Equal(t, "payment 3", anonymous("192.0.2.1:1234"))
+	assert.Equal(t, "payment 4", anonymous("192.0.2.2:1234"))
+	assert.Equal(t, "payment 3", anonymous("192.0.2.1:5678"))
+}
+
+// finishingStore is a store where the first request finishes right after
+// the first lookup misses.
+type finishingStore struct {
+	*MemoryIdempotencyStore
+	finish func()
+}
+
+func (s finishingStore) Get(key string) (*StoredResponse, bool) {
+	resp, ok := s.MemoryIdempotencyStore.Get(key)
+	if !ok && s.finish != nil {
+		s.finish()
+	}
+	return resp, ok
+}
+
+func TestIdempotencyRecheck(t *testing.T) {
+	mem := NewMemoryIdempotencyStore()
+	store := finishingStore{MemoryIdempotencyStore: mem}
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.Idempotency(&store, time.Hour))
+
+	calls := 0
+	mux.Post("/payments", func(w http.ResponseWriter, r *http.Request) error {
+		calls++
+		return nil
+	})
+
+	r := httptest.NewRequest("POST", "/payments", nil)
+	r.Header.Set(IdempotencyHeader, "abc")
+	store.finish = func() {
+		store.finish = nil
+		key := "POST /payments " + idempotencyCaller(r) + " abc"
+		mem.Set(key, &StoredResponse{Status: http.StatusAccepted, BodyHash: hashHex(nil)}, time.Hour)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.Idempotency(NewMemoryIdempotencyStore(), time.Hour))
+
+	calls := 0
+	mux.Patch("/item", func(w http.ResponseWriter, r *http.Request) error {
+		calls++
+		if calls == 1 {
+			return StatusError{Code: http.StatusBadGateway}
+		}
+		return nil
+	})
+
+	for _, code := range []int{http.StatusBadGateway, http.StatusOK, http.StatusOK} {
+		r := httptest.NewRequest("PATCH", "/item", nil)
+		r.Header.Set(IdempotencyHeader, "key")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, code, w.Code)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestMemoryIdempotencyStoreExpires(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	now := time.Unix(1000, 0)
+	store.now = func() time.Time { return now }
+
+	store.Set("key", &StoredResponse{Status: http.StatusOK}, time.Minute)
+	_, ok := store.Get("key")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = store.Get("key")
+	assert.False(t, ok)
+}
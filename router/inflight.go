@@ -0,0 +1,107 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// InflightRequest is a request that is being served.
+type InflightRequest struct {
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+	Method     string        `json:"method"`
+	URL        string        `json:"url"`
+	Pattern    string        `json:"pattern"`
+	RemoteAddr string        `json:"remoteAddr"`
+	RequestID  string        `json:"requestId,omitempty"`
+}
+
+// inflightRegistry holds the requests being served.
+type inflightRegistry struct {
+	mu       sync.Mutex
+	next     uint64
+	requests map[uint64]InflightRequest
+}
+
+// add adds a request and returns its key.
+func (reg *inflightRegistry) add(req InflightRequest) uint64 {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.requests == nil {
+		reg.requests = make(map[uint64]InflightRequest)
+	}
+	reg.next++
+	reg.requests[reg.next] = req
+	return reg.next
+}
+
+// remove removes the request with the key.
+func (reg *inflightRegistry) remove(key uint64) {
+	reg.mu.Lock()
+	delete(reg.requests, key)
+	reg.mu.Unlock()
+}
+
+// TrackInflight returns middleware for Use that records each request in the
+// registry read by Inflight and InflightHandler while it is being served.
+// The URL is redacted with the params and query values marked with Redact.
+func (m *Mux) TrackInflight() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			req := InflightRequest{
+				Start:      time.Now(),
+				Method:     r.Method,
+				URL:        route.RedactURL(r.URL),
+				RemoteAddr: r.RemoteAddr,
+				RequestID:  r.Header.Get("X-Request-ID"),
+			}
+			if route != nil {
+				req.Pattern = route.Pattern()
+			}
+
+			key := m.inflight.add(req)
+			defer m.inflight.remove(key)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Inflight returns the requests being served, oldest first, with the time
+// they have been running. Only requests to routes wrapped in the
+// TrackInflight middleware are included.
+func (m *Mux) Inflight() []InflightRequest {
+	m.inflight.mu.Lock()
+	requests := make([]InflightRequest, 0, len(m.inflight.requests))
+	for _, req := range m.inflight.requests {
+		requests = append(requests, req)
+	}
+	m.inflight.mu.Unlock()
+
+	now := time.Now()
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Start.Before(requests[j].Start)
+	})
+	for i := range requests {
+		requests[i].Duration = now.Sub(requests[i].Start)
+	}
+	return requests
+}
+
+// InflightHandler returns a handler that responds with the requests being
+// served as JSON, so operators can see what a wedged server is doing before
+// restarting it. It isn't protected, so it should be served on an internal
+// port or behind authentication. AdminAPI also serves it.
+func (m *Mux) InflightHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(m.Inflight())
+	})
+}
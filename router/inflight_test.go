@@ -0,0 +1,61 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflight(t *testing.T) {
+	mux := New()
+	mux.Use(mux.TrackInflight())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux.Get("/exports/{token}", func(w http.ResponseWriter, r *http.Request) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}).Redact("token")
+
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			r := httptest.NewRequest("GET", "/exports/secret", nil)
+			r.RemoteAddr = "10.0.0.1:1234"
+			r.Header.Set("X-Request-ID", "abc")
+			mux.ServeHTTP(httptest.NewRecorder(), r)
+			done <- struct{}{}
+		}()
+		<-started
+		time.Sleep(time.Millisecond)
+	}
+
+	requests := mux.Inflight()
+	if assert.Len(t, requests, 2) {
+		assert.True(t, requests[0].Start.Before(requests[1].Start))
+		assert.True(t, requests[0].Duration > requests[1].Duration)
+		assert.Equal(t, "GET", requests[0].Method)
+		assert.Equal(t, "/exports/"+away.Redacted, requests[0].URL)
+		assert.Equal(t, "/exports/:token", requests[0].Pattern)
+		assert.Equal(t, "10.0.0.1:1234", requests[0].RemoteAddr)
+		assert.Equal(t, "abc", requests[0].RequestID)
+	}
+
+	w := httptest.NewRecorder()
+	mux.InflightHandler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var listed []InflightRequest
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Len(t, listed, 2)
+
+	close(release)
+	<-done
+	<-done
+	assert.Empty(t, mux.Inflight())
+}
@@ -0,0 +1,41 @@
+package router
+
+import "net/http"
+
+// informational returns true for 1xx status codes that are followed by a
+// final response. 101 Switching Protocols is final.
+func informational(status int) bool {
+	return status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+}
+
+// EarlyHints sends a 103 Early Hints response with Link headers so the
+// client can preload resources while the handler prepares the final
+// response. The Link headers are also sent with the final response. It
+// needs Go 1.19 or later.
+func EarlyHints(w http.ResponseWriter, links ...string) {
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
+
+// Continue sends a 100 Continue response before the request body is read.
+// The server sends it automatically when the body is first read, so it is
+// only needed to send it earlier. It needs Go 1.19 or later.
+func Continue(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusContinue)
+}
+
+// DeclareTrailers announces the trailers that are set with SetTrailer after
+// the body is written. It must be called before the response is written.
+func DeclareTrailers(w http.ResponseWriter, names ...string) {
+	for _, name := range names {
+		w.Header().Add("Trailer", name)
+	}
+}
+
+// SetTrailer sets a trailer value. It can be called after the body is
+// written.
+func SetTrailer(w http.ResponseWriter, name string, value string) {
+	w.Header().Set(http.TrailerPrefix+name, value)
+}
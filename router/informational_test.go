@@ -0,0 +1,53 @@
+package router
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarlyHintsAndTrailers(t *testing.T) {
+	mux := New()
+	records := make([]AuditRecord, 0)
+	mux.Use(mux.Audit(AuditSinkFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})))
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		EarlyHints(w, "</app.css>; rel=preload; as=style")
+		DeclareTrailers(w, "X-Checksum")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("body"))
+		SetTrailer(w, "X-Checksum", "abc")
+		return nil
+	}).Audited()
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	hints := make([]int, 0)
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			hints = append(hints, code)
+			assert.Equal(t, "</app.css>; rel=preload; as=style", header.Get("Link"))
+			return nil
+		},
+	}))
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, []int{http.StatusEarlyHints}, hints)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "body", string(b))
+	assert.Equal(t, "abc", resp.Trailer.Get("X-Checksum"))
+	assert.Len(t, records, 1)
+	assert.Equal(t, http.StatusCreated, records[0].Status)
+}
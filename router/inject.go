@@ -0,0 +1,150 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrNoProvider is returned when a constructor or handler factory needs
+	// a type that isn't provided.
+	ErrNoProvider = errors.New("router: no provider for type")
+	// ErrProviderCycle is returned when constructors depend on each other.
+	ErrProviderCycle = errors.New("router: provider cycle")
+)
+
+var (
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+	handlerFuncType = reflect.TypeOf((func(http.ResponseWriter, *http.Request) error)(nil))
+)
+
+// provider is a constructor and the value it returned.
+type provider struct {
+	fn        reflect.Value
+	value     reflect.Value
+	err       error
+	done      bool
+	resolving bool
+}
+
+// Provide registers constructors for the values used by Inject. A
+// constructor is a function that returns a value, optionally with an error,
+// and takes other provided values as parameters, such as
+// func NewUserService(db *sql.DB) *UserService. Each constructor is called
+// once, the first time its value is needed, and the value is shared. Provide
+// panics if a constructor doesn't have that form.
+func (m *Mux) Provide(constructors ...interface{}) {
+	m.providersMu.Lock()
+	defer m.providersMu.Unlock()
+
+	if m.providers == nil {
+		m.providers = make(map[reflect.Type]*provider)
+	}
+
+	for _, c := range constructors {
+		fn := reflect.ValueOf(c)
+		t := fn.Type()
+		if fn.Kind() != reflect.Func || t.NumOut() == 0 || t.NumOut() > 2 ||
+			(t.NumOut() == 2 && t.Out(1) != errorType) {
+			panic(fmt.Sprintf("router: Provide: %v is not a constructor", t))
+		}
+		m.providers[t.Out(0)] = &provider{fn: fn}
+	}
+}
+
+// Inject returns the handler made by factory, a function that takes values
+// registered with Provide and returns the handler, optionally with an error,
+// such as func(s *UserService) func(http.ResponseWriter, *http.Request) error.
+// The handler may also be an http.Handler or http.HandlerFunc. The values
+// are resolved when Inject is called, so route registration panics if a
+// value can't be provided instead of failing per request.
+func (m *Mux) Inject(factory interface{}) func(http.ResponseWriter, *http.Request) error {
+	fn, err := m.inject(factory)
+	if err != nil {
+		panic(err)
+	}
+	return fn
+}
+
+// inject calls the factory with the provided values.
+func (m *Mux) inject(factory interface{}) (func(http.ResponseWriter, *http.Request) error, error) {
+	fn := reflect.ValueOf(factory)
+	if fn.Kind() != reflect.Func {
+		return nil, fmt.Errorf("router: Inject: %T is not a function", factory)
+	}
+	t := fn.Type()
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, fmt.Errorf("router: Inject: %v is not a handler factory", t)
+	}
+
+	m.providersMu.Lock()
+	out, err := m.call(fn, nil)
+	m.providersMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	h := out.Interface()
+	switch v := h.(type) {
+	case func(http.ResponseWriter, *http.Request) error:
+		return v, nil
+	case func(http.ResponseWriter, *http.Request):
+		return m.Wrap(v), nil
+	case http.Handler:
+		return m.Wrap(v.ServeHTTP), nil
+	}
+	if out.Type().ConvertibleTo(handlerFuncType) {
+		return out.Convert(handlerFuncType).Interface().(func(http.ResponseWriter, *http.Request) error), nil
+	}
+	return nil, fmt.Errorf("router: Inject: %v doesn't return a handler", t)
+}
+
+// call calls fn with the provided values and returns its first result. The
+// path is the types being resolved, used to report cycles. The lock must be
+// held.
+func (m *Mux) call(fn reflect.Value, path []reflect.Type) (reflect.Value, error) {
+	t := fn.Type()
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		v, err := m.resolve(t.In(i), path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		args[i] = v
+	}
+
+	out := fn.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// resolve returns the provided value of the type. The lock must be held.
+func (m *Mux) resolve(t reflect.Type, path []reflect.Type) (reflect.Value, error) {
+	p, ok := m.providers[t]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%w %v", ErrNoProvider, t)
+	}
+	if p.done {
+		return p.value, p.err
+	}
+
+	path = append(path, t)
+	if p.resolving {
+		names := make([]string, len(path))
+		for i, pt := range path {
+			names[i] = pt.String()
+		}
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrProviderCycle, strings.Join(names, " -> "))
+	}
+
+	p.resolving = true
+	p.value, p.err = m.call(p.fn, path)
+	p.resolving = false
+	p.done = true
+	return p.value, p.err
+}
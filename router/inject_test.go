@@ -0,0 +1,92 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type injectDB struct {
+	name string
+}
+
+type injectUsers struct {
+	db *injectDB
+}
+
+type injectA struct{}
+
+type injectB struct{}
+
+func TestInject(t *testing.T) {
+	mux := New()
+	calls := 0
+	mux.Provide(
+		func(db *injectDB) *injectUsers {
+			calls++
+			return &injectUsers{db: db}
+		},
+		func() (*injectDB, error) {
+			return &injectDB{name: "main"}, nil
+		},
+	)
+
+	mux.Get("/users", mux.Inject(func(users *injectUsers) func(http.ResponseWriter, *http.Request) error {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			fmt.Fprint(w, users.db.name)
+			return nil
+		}
+	}))
+	mux.Get("/std", mux.Inject(func(users *injectUsers) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "std "+users.db.name)
+		}
+	}))
+	assert.Equal(t, 1, calls)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	assert.Equal(t, "main", w.Body.String())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/std", nil))
+	assert.Equal(t, "std main", w.Body.String())
+	assert.Equal(t, 1, calls)
+}
+
+func TestInjectErrors(t *testing.T) {
+	mux := New()
+	errDB := errors.New("db unavailable")
+	mux.Provide(
+		func() (*injectDB, error) { return nil, errDB },
+		func(b *injectB) *injectA { return &injectA{} },
+		func(a *injectA) *injectB { return &injectB{} },
+	)
+
+	handler := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	_, err := mux.inject(func(u *injectUsers) func(http.ResponseWriter, *http.Request) error { return handler })
+	assert.True(t, errors.Is(err, ErrNoProvider))
+
+	_, err = mux.inject(func(db *injectDB) func(http.ResponseWriter, *http.Request) error { return handler })
+	assert.Equal(t, errDB, err)
+
+	_, err = mux.inject(func(a *injectA) func(http.ResponseWriter, *http.Request) error { return handler })
+	assert.True(t, errors.Is(err, ErrProviderCycle))
+
+	_, err = mux.inject(func() string { return "" })
+	assert.Error(t, err)
+
+	assert.Panics(t, func() {
+		mux.Inject(func(u *injectUsers) func(http.ResponseWriter, *http.Request) error { return handler })
+	})
+	assert.Panics(t, func() {
+		mux.Provide(func() {})
+	})
+}
@@ -0,0 +1,316 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrJobQueueFull is returned when a job can't be queued.
+var ErrJobQueueFull = errors.New("router: job queue is full")
+
+// JobState is the state of a background job.
+type JobState string
+
+const (
+	// JobQueued is a job waiting for a worker.
+	JobQueued JobState = "queued"
+	// JobRunning is a job being run by a worker.
+	JobRunning JobState = "running"
+	// JobSucceeded is a job that returned without an error.
+	JobSucceeded JobState = "succeeded"
+	// JobFailed is a job that returned an error or panicked.
+	JobFailed JobState = "failed"
+)
+
+// JobConfig contains the settings for background jobs.
+type JobConfig struct {
+	// Workers is the number of jobs run at once. Defaults to 4.
+	Workers int
+	// QueueSize is the number of jobs that can wait for a worker. Requests
+	// beyond it are rejected with a 503 StatusError. Defaults to 100.
+	QueueSize int
+	// Retention is how long the status of a finished job is kept. Defaults
+	// to an hour.
+	Retention time.Duration
+	// StatusPath is the path prefix of the job status routes. Defaults to
+	// /jobs.
+	StatusPath string
+}
+
+// JobFunc runs a background job. The request has the path parameters and a
+// copy of the body but isn't canceled when the client goes away. The result
+// is encoded in the job status.
+type JobFunc func(ctx context.Context, r *http.Request) (result interface{}, err error)
+
+// Job is the status of a background job.
+type Job struct {
+	ID       string      `json:"id"`
+	State    JobState    `json:"state"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Created  time.Time   `json:"created"`
+	Started  *time.Time  `json:"started,omitempty"`
+	Finished *time.Time  `json:"finished,omitempty"`
+}
+
+// jobRunner runs the background jobs of a Mux on a pool of workers.
+type jobRunner struct {
+	config JobConfig
+	queue  chan *queuedJob
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// queuedJob is a job waiting for a worker.
+type queuedJob struct {
+	id string
+	fn JobFunc
+	r  *http.Request
+}
+
+// SetJobs sets the configuration of the background jobs. It must be called
+// before the first call to Async.
+func (m *Mux) SetJobs(config JobConfig) {
+	m.jobConfig = config
+}
+
+// Async registers a POST route that queues fn as a background job and
+// responds with 202 Accepted, the status URL in the Location header, and the
+// job status. The jobs run on a pool of workers so long exports don't tie
+// up request goroutines. The first call registers a GET route under the
+// status path, /jobs/{id} by default, that returns the job status.
+func (m *Mux) Async(path string, fn JobFunc) *Route {
+	runner := m.jobRunner()
+
+	return m.handle(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) error {
+		b, err := readBody(r.Body, m.jsonOptions.MaxBytes)
+		if err != nil && r.Body != nil {
+			return err
+		}
+
+		jr := r.Clone(jobContext{Context: r.Context(), base: runner.ctx})
+		jr.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+		job, err := runner.enqueue(fn, jr)
+		if err != nil {
+			return StatusError{Code: http.StatusServiceUnavailable, Err: err}
+		}
+
+		w.Header().Set("Location", runner.config.StatusPath+"/"+job.ID)
+		return m.Respond(w, r, http.StatusAccepted, job)
+	})
+}
+
+// Job returns the status of a background job.
+func (m *Mux) Job(id string) (Job, bool) {
+	m.jobsMu.Lock()
+	runner := m.jobs
+	m.jobsMu.Unlock()
+	if runner == nil {
+		return Job{}, false
+	}
+	return runner.get(id)
+}
+
+// StopJobs cancels the context of the running jobs and waits for the
+// workers to stop or the context to be done. Queued jobs are not run.
+func (m *Mux) StopJobs(ctx context.Context) error {
+	m.jobsMu.Lock()
+	runner := m.jobs
+	m.jobsMu.Unlock()
+	if runner == nil {
+		return nil
+	}
+
+	runner.cancel()
+	done := make(chan struct{})
+	go func() {
+		runner.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// jobRunner returns the job runner, starting the workers and registering
+// the status route the first time.
+func (m *Mux) jobRunner() *jobRunner {
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	if m.jobs != nil {
+		return m.jobs
+	}
+
+	config := m.jobConfig
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	if config.Retention <= 0 {
+		config.Retention = time.Hour
+	}
+	if config.StatusPath == "" {
+		config.StatusPath = "/jobs"
+	}
+	config.StatusPath = "/" + strings.Trim(config.StatusPath, "/")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := &jobRunner{
+		config: config,
+		queue:  make(chan *queuedJob, config.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[string]*Job),
+	}
+	for i := 0; i < config.Workers; i++ {
+		runner.wg.Add(1)
+		go runner.work()
+	}
+	m.jobs = runner
+
+	m.handle(http.MethodGet, config.StatusPath+"/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		job, ok := runner.get(m.Param(r, "id"))
+		if !ok {
+			return StatusError{Code: http.StatusNotFound}
+		}
+		return m.Respond(w, r, http.StatusOK, job)
+	})
+
+	return runner
+}
+
+// enqueue adds a job to the queue.
+func (jr *jobRunner) enqueue(fn JobFunc, r *http.Request) (Job, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Job{}, err
+	}
+	job := &Job{ID: hex.EncodeToString(b), State: JobQueued, Created: time.Now()}
+
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	jr.expire(job.Created)
+
+	select {
+	case jr.queue <- &queuedJob{id: job.ID, fn: fn, r: r}:
+	default:
+		return Job{}, ErrJobQueueFull
+	}
+	jr.jobs[job.ID] = job
+	return *job, nil
+}
+
+// get returns a copy of the job status.
+func (jr *jobRunner) get(id string) (Job, bool) {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	jr.expire(time.Now())
+
+	job, ok := jr.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// expire removes the jobs that finished before the retention. The lock must
+// be held.
+func (jr *jobRunner) expire(now time.Time) {
+	for id, job := range jr.jobs {
+		if job.Finished != nil && now.Sub(*job.Finished) > jr.config.Retention {
+			delete(jr.jobs, id)
+		}
+	}
+}
+
+// work runs queued jobs until the runner is stopped.
+func (jr *jobRunner) work() {
+	defer jr.wg.Done()
+	for {
+		select {
+		case <-jr.ctx.Done():
+			return
+		case q := <-jr.queue:
+			jr.run(q)
+		}
+	}
+}
+
+// run runs a job and records its result.
+func (jr *jobRunner) run(q *queuedJob) {
+	now := time.Now()
+	jr.mu.Lock()
+	job, ok := jr.jobs[q.id]
+	if ok {
+		job.State = JobRunning
+		job.Started = &now
+	}
+	jr.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	result, err := q.call()
+
+	finished := time.Now()
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	job.Finished = &finished
+	if err != nil {
+		job.State = JobFailed
+		job.Error = err.Error()
+		return
+	}
+	job.State = JobSucceeded
+	job.Result = result
+}
+
+// call runs the job function. A panic is returned as a PanicError so it
+// fails the job instead of crashing the process.
+func (q *queuedJob) call() (result interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			result, err = nil, PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return q.fn(q.r.Context(), q.r)
+}
+
+// jobContext has the values of the request context with the cancellation of
+// the job runner.
+type jobContext struct {
+	context.Context
+	base context.Context
+}
+
+func (c jobContext) Deadline() (time.Time, bool) {
+	return c.base.Deadline()
+}
+
+func (c jobContext) Done() <-chan struct{} {
+	return c.base.Done()
+}
+
+func (c jobContext) Err() error {
+	return c.base.Err()
+}
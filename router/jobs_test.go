@@ -0,0 +1,110 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsync(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetJobs(JobConfig{Workers: 1, QueueSize: 1})
+
+	release := make(chan struct{})
+	mux.Async("/exports/{name}", func(ctx context.Context, r *http.Request) (interface{}, error) {
+		<-release
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if string(b) == "fail" {
+			return nil, errors.New("export failed")
+		}
+		return mux.Param(r, "name") + ":" + string(b), nil
+	})
+
+	submit := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/exports/users", strings.NewReader(body)))
+		return w
+	}
+	status := func(location string) Job {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", location, nil))
+		var job Job
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+		return job
+	}
+	wait := func(location string) Job {
+		for i := 0; i < 100; i++ {
+			if job := status(location); job.Finished != nil {
+				return job
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("job didn't finish")
+		return Job{}
+	}
+
+	w1 := submit("csv")
+	assert.Equal(t, http.StatusAccepted, w1.Code)
+	loc1 := w1.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(loc1, "/jobs/"))
+
+	// Wait for the worker to take the first job so the second one queues.
+	for status(loc1).State != JobRunning {
+		time.Sleep(time.Millisecond)
+	}
+	w2 := submit("fail")
+	assert.Equal(t, http.StatusAccepted, w2.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, submit("full").Code)
+	close(release)
+
+	job := wait(loc1)
+	assert.Equal(t, JobSucceeded, job.State)
+	assert.Equal(t, "users:csv", job.Result)
+
+	job = wait(w2.Header().Get("Location"))
+	assert.Equal(t, JobFailed, job.State)
+	assert.Equal(t, "export failed", job.Error)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/jobs/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.NoError(t, mux.StopJobs(context.Background()))
+}
+
+func TestAsyncPanic(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Async("/explode", func(ctx context.Context, r *http.Request) (interface{}, error) {
+		panic("out of ink")
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/explode", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	id := strings.TrimPrefix(w.Header().Get("Location"), "/jobs/")
+
+	var job Job
+	for i := 0; i < 100; i++ {
+		if job, _ = mux.Job(id); job.Finished != nil {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, JobFailed, job.State)
+	assert.Equal(t, "router: panic: out of ink", job.Error)
+
+	assert.NoError(t, mux.StopJobs(context.Background()))
+}
@@ -0,0 +1,175 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// ErrOverloaded is returned when a limiter sheds a request.
+var ErrOverloaded = errors.New("router: too many requests in flight")
+
+// limiterKey is the route value key for the route limiter.
+type limiterKey struct{}
+
+// LimitConfig contains the settings for a concurrency limiter.
+type LimitConfig struct {
+	// MaxInFlight is the number of requests served at once.
+	MaxInFlight int
+	// MaxQueue is the number of requests that can wait for a free slot.
+	// Requests beyond it are rejected immediately.
+	MaxQueue int
+	// QueueTimeout is how long a request waits for a free slot. If zero, a
+	// request waits until it is canceled.
+	QueueTimeout time.Duration
+}
+
+// Limiter limits the number of requests served at once.
+type Limiter struct {
+	config LimitConfig
+	slots  chan struct{}
+	queue  chan struct{}
+	shed   uint64
+}
+
+// NewLimiter returns a concurrency limiter. A limiter can wrap a single
+// route with Wrap or every route with Mux.Limit.
+func NewLimiter(config LimitConfig) *Limiter {
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = 1
+	}
+	if config.MaxQueue < 0 {
+		config.MaxQueue = 0
+	}
+
+	return &Limiter{
+		config: config,
+		slots:  make(chan struct{}, config.MaxInFlight),
+		queue:  make(chan struct{}, config.MaxQueue),
+	}
+}
+
+// Wrap returns a handler that is rejected with a 503 StatusError when the
+// limiter and its queue are full.
+func (l *Limiter) Wrap(fn func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := l.acquire(r.Context()); err != nil {
+			return StatusError{Code: http.StatusServiceUnavailable, Err: err}
+		}
+		defer l.release()
+
+		return fn(w, r)
+	}
+}
+
+// Limit returns middleware for Use that applies the limiter to every request.
+// Rejected requests are passed to the ServeHTTP function as a 503
+// StatusError.
+func (m *Mux) Limit(l *Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serveLimited(l, next, w, r)
+		})
+	}
+}
+
+// Limit runs the route under its own limiter so a heavy route has a bounded
+// number of requests in flight and a queue, isolated from the other routes.
+// A limiter can be shared by several routes to give them one budget. It is
+// enforced by the RouteLimits middleware.
+func (rt *Route) Limit(l *Limiter) *Route {
+	rt.route.WithValue(limiterKey{}, l)
+	return rt
+}
+
+// RouteLimits returns middleware for Use that applies the limiter set with
+// Route.Limit. Requests rejected because the queue is full or the queue
+// timeout passed are passed to the ServeHTTP function as a 503 StatusError.
+func (m *Mux) RouteLimits() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			l, ok := route.Value(limiterKey{}).(*Limiter)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			m.serveLimited(l, next, w, r)
+		})
+	}
+}
+
+// serveLimited serves the request when the limiter has a free slot.
+func (m *Mux) serveLimited(l *Limiter, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	if err := l.acquire(r.Context()); err != nil {
+		m.serveError(w, r, StatusError{Code: http.StatusServiceUnavailable, Err: err})
+		return
+	}
+	defer l.release()
+
+	next.ServeHTTP(w, r)
+}
+
+// InFlight returns the number of requests being served.
+func (l *Limiter) InFlight() int {
+	return len(l.slots)
+}
+
+// Queued returns the number of requests waiting for a free slot.
+func (l *Limiter) Queued() int {
+	return len(l.queue)
+}
+
+// Shed returns the number of requests that were rejected.
+func (l *Limiter) Shed() uint64 {
+	return atomic.LoadUint64(&l.shed)
+}
+
+// acquire waits for a free slot.
+func (l *Limiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		atomic.AddUint64(&l.shed, 1)
+		return ErrOverloaded
+	}
+	defer func() { <-l.queue }()
+
+	var timeout <-chan time.Time
+	if l.config.QueueTimeout > 0 {
+		t := time.NewTimer(l.config.QueueTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		atomic.AddUint64(&l.shed, 1)
+		return ErrOverloaded
+	case <-ctx.Done():
+		atomic.AddUint64(&l.shed, 1)
+		return ctx.Err()
+	}
+}
+
+// release frees a slot.
+func (l *Limiter) release() {
+	<-l.slots
+}
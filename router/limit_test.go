@@ -0,0 +1,126 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterWrap(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	l := NewLimiter(LimitConfig{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: 50 * time.Millisecond})
+	started := make(chan bool)
+	unblock := make(chan bool)
+	mux.Get("/slow", l.Wrap(func(w http.ResponseWriter, r *http.Request) error {
+		started <- true
+		<-unblock
+		return nil
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}()
+	<-started
+	assert.Equal(t, 1, l.InFlight())
+
+	// Waits in the queue and times out.
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, ErrOverloaded.Error()+"\n", w.Body.String())
+	assert.Equal(t, uint64(1), l.Shed())
+
+	unblock <- true
+	wg.Wait()
+	assert.Equal(t, 0, l.InFlight())
+}
+
+func TestLimiterQueue(t *testing.T) {
+	l := NewLimiter(LimitConfig{MaxInFlight: 1, MaxQueue: 1})
+	mux := New()
+	mux.Use(mux.Limit(l))
+
+	started := make(chan bool)
+	unblock := make(chan bool)
+	mux.Get("/slow", func(w http.ResponseWriter, r *http.Request) error {
+		started <- true
+		<-unblock
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+		}()
+	}
+	<-started
+	for l.Queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Queue is full so the request is shed immediately.
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	unblock <- true
+	<-started
+	unblock <- true
+	wg.Wait()
+	assert.Equal(t, uint64(1), l.Shed())
+}
+
+func TestRouteLimits(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.RouteLimits())
+
+	l := NewLimiter(LimitConfig{MaxInFlight: 1, QueueTimeout: 10 * time.Millisecond})
+	started := make(chan bool)
+	unblock := make(chan bool)
+	mux.Get("/export", func(w http.ResponseWriter, r *http.Request) error {
+		started <- true
+		<-unblock
+		return nil
+	}).Limit(l)
+	mux.Get("/fast", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/export", nil))
+	}()
+	<-started
+
+	// The heavy route is full but the other routes aren't affected.
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	unblock <- true
+	wg.Wait()
+	assert.Equal(t, 0, l.InFlight())
+}
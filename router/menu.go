@@ -0,0 +1,164 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// menuKey is the route value key for the menu entry of a route.
+type menuKey struct{}
+
+// menuEntry is the place of a route in the menu.
+type menuEntry struct {
+	path   []string
+	weight int
+}
+
+// MenuItem is an item of the navigation menu returned by Menu.
+type MenuItem struct {
+	Title string
+	// URL is empty for items that only group other items.
+	URL    string
+	Weight int
+	// Active is true if the item or one of its children is the route of the
+	// request.
+	Active   bool
+	Children []MenuItem
+}
+
+// Menu adds the GET route to the navigation menu returned by Mux.Menu. The
+// path separates the titles of the parent items from the title of the route
+// with ">", such as "Admin > Users". Items are sorted by weight and then
+// title, so plugins can place their pages between the pages of the app.
+func (rt *Route) Menu(path string, weight int) *Route {
+	parts := strings.Split(path, ">")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	rt.route.WithValue(menuKey{}, menuEntry{path: parts, weight: weight})
+	return rt
+}
+
+// menuNode is an item of the menu being built.
+type menuNode struct {
+	item     MenuItem
+	hasRoute bool
+	children []*menuNode
+}
+
+// child returns the child with the title, adding it if needed.
+func (n *menuNode) child(title string) *menuNode {
+	for _, c := range n.children {
+		if c.item.Title == title {
+			return c
+		}
+	}
+	c := &menuNode{item: MenuItem{Title: title}}
+	n.children = append(n.children, c)
+	return c
+}
+
+// Menu returns the menu tree of the enabled GET routes added with
+// Route.Menu, so navigation stays in sync with the registered routes. URLs
+// are generated from the route patterns with the path parameters of the
+// request, so a route such as /orgs/{org}/settings links to the settings of
+// the current org; routes with parameters the request doesn't have are left
+// out. An item without a route of its own has no URL and the weight of its
+// lightest child. The request can be nil.
+func (m *Mux) Menu(r *http.Request) []MenuItem {
+	var params []away.PathParam
+	var current *away.Route
+	if r != nil {
+		params = away.ParamList(r.Context())
+		current = away.CurrentRoute(r.Context())
+	}
+
+	root := &menuNode{}
+	for _, route := range m.router.Routes() {
+		entry, ok := route.Value(menuKey{}).(menuEntry)
+		if !ok || route.Method() != http.MethodGet || route.Disabled() {
+			continue
+		}
+		link, ok := patternURL(route.Pattern(), params)
+		if !ok {
+			continue
+		}
+
+		n := root
+		for _, title := range entry.path {
+			n = n.child(title)
+		}
+		n.hasRoute = true
+		n.item.URL = link
+		n.item.Weight = entry.weight
+		n.item.Active = current != nil && current.Method() == route.Method() && current.Pattern() == route.Pattern()
+	}
+
+	return root.items()
+}
+
+// items returns the sorted menu items of the children of the node.
+func (n *menuNode) items() []MenuItem {
+	if len(n.children) == 0 {
+		return nil
+	}
+
+	items := make([]MenuItem, 0, len(n.children))
+	for _, c := range n.children {
+		item := c.item
+		item.Children = c.items()
+		for i, child := range item.Children {
+			item.Active = item.Active || child.Active
+			if !c.hasRoute && (i == 0 || child.Weight < item.Weight) {
+				item.Weight = child.Weight
+			}
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Weight != items[j].Weight {
+			return items[i].Weight < items[j].Weight
+		}
+		return items[i].Title < items[j].Title
+	})
+	return items
+}
+
+// patternURL returns the path of a route pattern with the parameters filled
+// in. It returns false if a parameter is missing.
+func patternURL(pattern string, params []away.PathParam) (string, bool) {
+	segs := strings.Split(pattern, "/")
+	for i, seg := range segs {
+		if !strings.HasPrefix(seg, ":") {
+			segs[i] = strings.TrimSuffix(seg, "...")
+			continue
+		}
+
+		name := strings.TrimSuffix(seg[1:], "...")
+		value, found := "", false
+		for _, p := range params {
+			if p.Name == name {
+				value, found = p.Value, true
+			}
+		}
+		if !found {
+			return "", false
+		}
+		if strings.HasSuffix(seg, "...") {
+			// A wildcard value spans segments, so its slashes are kept.
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segs[i] = strings.Join(parts, "/")
+			continue
+		}
+		segs[i] = url.PathEscape(value)
+	}
+	return strings.Join(segs, "/"), true
+}
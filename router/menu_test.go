@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMenu(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	var menu []MenuItem
+	capture := func(w http.ResponseWriter, r *http.Request) error {
+		menu = mux.Menu(r)
+		return nil
+	}
+
+	mux.Get("/", capture).Menu("Home", 0)
+	mux.Get("/admin/users", capture).Menu("Admin > Users", 20)
+	mux.Get("/admin/plugins", capture).Menu("Admin > Plugins", 10)
+	mux.Get("/orgs/{org}/settings", capture).Menu("Settings", 30)
+	mux.Get("/about", capture).Menu("About", 5)
+	mux.Post("/admin/users", capture).Menu("Admin > New", 0)
+	mux.Get("/orgs/{org}", capture)
+	mux.Get("/hidden", capture).Menu("Hidden", 1)
+	mux.SetEnabled("GET", "/hidden", false)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/admin/users", nil))
+	assert.Equal(t, []MenuItem{
+		{Title: "Home", URL: "/", Weight: 0},
+		{Title: "About", URL: "/about", Weight: 5},
+		{Title: "Admin", Weight: 10, Active: true, Children: []MenuItem{
+			{Title: "Plugins", URL: "/admin/plugins", Weight: 10},
+			{Title: "Users", URL: "/admin/users", Weight: 20, Active: true},
+		}},
+	}, menu)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orgs/acme%20co", nil))
+	if assert.Len(t, menu, 4) {
+		assert.Equal(t, MenuItem{Title: "Settings", URL: "/orgs/acme%20co/settings", Weight: 30}, menu[3])
+	}
+
+	assert.Len(t, mux.Menu(nil), 3)
+}
+
+func TestPatternURLWildcard(t *testing.T) {
+	link, ok := patternURL("/docs/:path...", []away.PathParam{{Name: "path", Value: "a b/c"}})
+	assert.True(t, ok)
+	assert.Equal(t, "/docs/a%20b/c", link)
+
+	link, ok = patternURL("/orgs/:org", []away.PathParam{{Name: "org", Value: "a/b"}})
+	assert.True(t, ok)
+	assert.Equal(t, "/orgs/a%2Fb", link)
+}
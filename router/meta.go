@@ -0,0 +1,119 @@
+package router
+
+import (
+	"context"
+	"html"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// metaKey is the route value key for the metadata function of a route and
+// the context key for the metadata of a page being rendered.
+type metaKey struct{}
+
+// Meta is the SEO metadata of a page.
+type Meta struct {
+	Title       string
+	Description string
+	// Canonical is the canonical URL of the page. Optional.
+	Canonical string
+	// OpenGraph are Open Graph properties without the og: prefix, such as
+	// type or image. og:title, og:description and og:url default to the
+	// fields above.
+	OpenGraph map[string]string
+}
+
+// MetaFunc returns the metadata of a page from the request and the data
+// passed to Render, such as the title of a blog post. An error with a
+// status, such as a 404 StatusError, is returned by Render as is.
+type MetaFunc func(r *http.Request, data interface{}) (Meta, error)
+
+// Meta sets the function that returns the SEO metadata of the pages of the
+// route. Render calls it and layouts read the result with CurrentMeta, so
+// titles and Open Graph tags are declared with the routes instead of in
+// each template.
+func (rt *Route) Meta(fn MetaFunc) *Route {
+	rt.route.WithValue(metaKey{}, fn)
+	return rt
+}
+
+// StaticMeta sets the SEO metadata of the pages of the route. See Meta.
+func (rt *Route) StaticMeta(meta Meta) *Route {
+	return rt.Meta(func(r *http.Request, data interface{}) (Meta, error) {
+		return meta, nil
+	})
+}
+
+// CurrentMeta returns the metadata of the page being rendered by Render,
+// for layouts to read with a function added by the renderer, for example
+// with render.Config.RequestFuncs:
+//
+//	"meta": func() router.Meta { return router.CurrentMeta(r) },
+//
+// It returns an empty Meta for routes without metadata.
+func CurrentMeta(r *http.Request) Meta {
+	if r == nil {
+		return Meta{}
+	}
+	meta, _ := r.Context().Value(metaKey{}).(Meta)
+	return meta
+}
+
+// withMeta returns a request with the metadata of the route for
+// CurrentMeta.
+func withMeta(r *http.Request, data interface{}) (*http.Request, error) {
+	route := away.CurrentRoute(r.Context())
+	if route == nil {
+		return r, nil
+	}
+	fn, ok := route.Value(metaKey{}).(MetaFunc)
+	if !ok {
+		return r, nil
+	}
+
+	meta, err := fn(r, data)
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), metaKey{}, meta)), nil
+}
+
+// HTML returns the title, description, canonical link and Open Graph tags
+// of the metadata for the head of a layout. Empty fields are left out.
+func (meta Meta) HTML() template.HTML {
+	var b strings.Builder
+	if meta.Title != "" {
+		b.WriteString("<title>" + html.EscapeString(meta.Title) + "</title>\n")
+	}
+	if meta.Description != "" {
+		b.WriteString(`<meta name="description" content="` + html.EscapeString(meta.Description) + "\">\n")
+	}
+	if meta.Canonical != "" {
+		b.WriteString(`<link rel="canonical" href="` + html.EscapeString(meta.Canonical) + "\">\n")
+	}
+
+	og := map[string]string{
+		"title":       meta.Title,
+		"description": meta.Description,
+		"url":         meta.Canonical,
+	}
+	for k, v := range meta.OpenGraph {
+		og[k] = v
+	}
+	keys := make([]string, 0, len(og))
+	for k, v := range og {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(`<meta property="og:` + html.EscapeString(k) + `" content="` + html.EscapeString(og[k]) + "\">\n")
+	}
+
+	return template.HTML(b.String())
+}
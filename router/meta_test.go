@@ -0,0 +1,75 @@
+package router
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ambientkit/away/router/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeta(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.tmpl": {Data: []byte(`<head>{{meta.HTML}}</head>{{template "content" .}}`)},
+		"post.tmpl":   {Data: []byte(`{{define "content"}}<p>{{.}}</p>{{end}}`)},
+	}
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetRenderer(render.New(fsys, render.Config{
+		Layout: "layout.tmpl",
+		RequestFuncs: func(r *http.Request) template.FuncMap {
+			return template.FuncMap{
+				"meta": func() Meta { return CurrentMeta(r) },
+			}
+		},
+	}))
+
+	mux.Get("/posts/{slug}", func(w http.ResponseWriter, r *http.Request) error {
+		return mux.Render(w, r, http.StatusOK, "post.tmpl", "Tips & tricks")
+	}).Meta(func(r *http.Request, data interface{}) (Meta, error) {
+		if mux.Param(r, "slug") == "missing" {
+			return Meta{}, StatusError{Code: http.StatusNotFound}
+		}
+		return Meta{
+			Title:       data.(string),
+			Description: `A "short" post`,
+			Canonical:   "https://example.com/posts/" + mux.Param(r, "slug"),
+			OpenGraph:   map[string]string{"type": "article"},
+		}, nil
+	})
+	mux.Get("/about", func(w http.ResponseWriter, r *http.Request) error {
+		return mux.Render(w, r, http.StatusOK, "post.tmpl", "About")
+	}).StaticMeta(Meta{Title: "About us"})
+	mux.Get("/plain", func(w http.ResponseWriter, r *http.Request) error {
+		return mux.Render(w, r, http.StatusOK, "post.tmpl", "Plain")
+	})
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	w := serve("/posts/tips")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<head><title>Tips &amp; tricks</title>\n"+
+		"<meta name=\"description\" content=\"A &#34;short&#34; post\">\n"+
+		"<link rel=\"canonical\" href=\"https://example.com/posts/tips\">\n"+
+		"<meta property=\"og:description\" content=\"A &#34;short&#34; post\">\n"+
+		"<meta property=\"og:title\" content=\"Tips &amp; tricks\">\n"+
+		"<meta property=\"og:type\" content=\"article\">\n"+
+		"<meta property=\"og:url\" content=\"https://example.com/posts/tips\">\n"+
+		"</head><p>Tips &amp; tricks</p>", w.Body.String())
+
+	w = serve("/about")
+	assert.Equal(t, "<head><title>About us</title>\n<meta property=\"og:title\" content=\"About us\">\n</head><p>About</p>", w.Body.String())
+
+	w = serve("/plain")
+	assert.Equal(t, "<head></head><p>Plain</p>", w.Body.String())
+
+	w = serve("/posts/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
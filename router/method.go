@@ -2,54 +2,126 @@ package router
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/ambientkit/away"
 	"github.com/ambientkit/away/router/ambhandler"
 	"github.com/ambientkit/away/router/paramconvert"
 )
 
-func (m *Mux) handle(method string, path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.router.Handle(method, paramconvert.BraceToColon(path), ambhandler.Handler{
-		HandlerFunc:     fn,
+// middlewareKey is the route value key for the middleware its handler is
+// wrapped in, so Replace can wrap a new handler the same way.
+type middlewareKey struct{}
+
+func (m *Mux) handle(method string, path string, fn func(http.ResponseWriter, *http.Request) error, mw ...func(http.Handler) http.Handler) *Route {
+	return m.handleWith(method, path, fn, nil, mw...)
+}
+
+// handleWith registers a route after calling configure with it, so the
+// route is never served without its settings.
+func (m *Mux) handleWith(method string, path string, fn func(http.ResponseWriter, *http.Request) error, configure func(rt *Route), mw ...func(http.Handler) http.Handler) *Route {
+	rt := &Route{mux: m}
+	m.router.HandleWith(method, paramconvert.BraceToColon(path), m.handler(fn, mw...), func(route *away.Route) {
+		route.WithValue(middlewareKey{}, mw)
+		rt.route = route
+		if configure != nil {
+			configure(rt)
+		}
+	})
+	return rt
+}
+
+// replace swaps the handler of an existing route, wrapping fn in the
+// middleware the route was registered with. It returns nil if there is no
+// route with the method and path.
+func (m *Mux) replace(method string, path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	pattern := paramconvert.BraceToColon(path)
+	for _, route := range m.router.Routes() {
+		if !strings.EqualFold(route.Method(), method) || route.Pattern() != pattern {
+			continue
+		}
+
+		mw, _ := route.Value(middlewareKey{}).([]func(http.Handler) http.Handler)
+		return &Route{
+			mux:   m,
+			route: m.router.Replace(method, pattern, m.handler(fn, mw...)),
+		}
+	}
+	return nil
+}
+
+// handler returns the http.Handler for a route handler function wrapped in
+// middleware.
+func (m *Mux) handler(fn func(http.ResponseWriter, *http.Request) error, mw ...func(http.Handler) http.Handler) http.Handler {
+	h := withTransforms(ambhandler.Handler{
+		HandlerFunc:     m.reportErr(m.wrapErr(m.withDeadline(fn))),
 		CustomServeHTTP: m.customServeHTTP,
 	})
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return namedHandler{Handler: h, name: funcName(fn)}
+}
+
+// Replace swaps the handler of a route in a single step, keeping its
+// position, settings, and middleware, including the middleware of the group
+// it was registered with, or registers the route if it doesn't exist. It is
+// used to reload a handler without a window where requests get a 404.
+func (m *Mux) Replace(method string, path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	if rt := m.replace(method, path, fn); rt != nil {
+		return rt
+	}
+	return m.handle(method, path, fn)
 }
 
 // Delete registers a pattern with the router.
-func (m *Mux) Delete(path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(http.MethodDelete, path, fn)
+func (m *Mux) Delete(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(http.MethodDelete, path, fn)
 }
 
 // Get registers a pattern with the router.
-func (m *Mux) Get(path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(http.MethodGet, path, fn)
+func (m *Mux) Get(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(http.MethodGet, path, fn)
 }
 
 // Handle registers a method and pattern with the router.
-func (m *Mux) Handle(method string, path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(method, path, fn)
+func (m *Mux) Handle(method string, path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(method, path, fn)
 }
 
 // Head registers a pattern with the router.
-func (m *Mux) Head(path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(http.MethodHead, path, fn)
+func (m *Mux) Head(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(http.MethodHead, path, fn)
 }
 
 // Options registers a pattern with the router.
-func (m *Mux) Options(path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(http.MethodOptions, path, fn)
+func (m *Mux) Options(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(http.MethodOptions, path, fn)
 }
 
 // Patch registers a pattern with the router.
-func (m *Mux) Patch(path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(http.MethodPatch, path, fn)
+func (m *Mux) Patch(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(http.MethodPatch, path, fn)
 }
 
 // Post registers a pattern with the router.
-func (m *Mux) Post(path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(http.MethodPost, path, fn)
+func (m *Mux) Post(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(http.MethodPost, path, fn)
 }
 
 // Put registers a pattern with the router.
-func (m *Mux) Put(path string, fn func(http.ResponseWriter, *http.Request) error) {
-	m.handle(http.MethodPut, path, fn)
+func (m *Mux) Put(path string, fn func(http.ResponseWriter, *http.Request) error) *Route {
+	return m.handle(http.MethodPut, path, fn)
+}
+
+// namedHandler reports the name of the handler function to route
+// introspection.
+type namedHandler struct {
+	http.Handler
+	name string
+}
+
+// HandlerName returns the name of the handler function.
+func (h namedHandler) HandlerName() string {
+	return h.name
 }
@@ -0,0 +1,750 @@
+// Package msgpack provides a MessagePack codec for router.Mux:
+//
+//	mux.RegisterCodec(msgpack.MediaType, msgpack.Codec{})
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MediaType is the media type of MessagePack.
+const MediaType = "application/x-msgpack"
+
+// errShort is returned for data that ends in the middle of a value.
+var errShort = errors.New("msgpack: unexpected end of data")
+
+// maxDepth is the maximum nesting of arrays and maps Codec decodes.
+const maxDepth = 100
+
+// timestampExt is the extension type of timestamps.
+const timestampExt = -1
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Codec encodes and decodes MessagePack. Struct fields are named by their
+// msgpack tag, or json tag if there is none, and support omitempty like
+// encoding/json. Byte slices are encoded as binary and time.Time as the
+// timestamp extension. Decoding into an interface{} produces nil, bool,
+// int64, uint64 for integers larger than an int64, float64, string, []byte,
+// time.Time, []interface{}, and map[string]interface{} or
+// map[interface{}]interface{} if a key isn't a string.
+type Codec struct{}
+
+// Marshal returns the MessagePack encoding of v.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	var e encoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// Unmarshal decodes MessagePack into v, which must be a non-nil pointer.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("msgpack: Unmarshal requires a non-nil pointer, not %T", v)
+	}
+
+	d := decoder{data: data}
+	src, err := d.decode(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: data after the top-level value")
+	}
+	return assign(rv.Elem(), src)
+}
+
+// encoder appends the encoding of values to a buffer.
+type encoder struct {
+	buf []byte
+}
+
+// encode appends the encoding of a value.
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, 0xca)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBinary(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// encodeInt appends an integer in the smallest format.
+func (e *encoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.encodeUint(uint64(i))
+	case i >= -32:
+		e.buf = append(e.buf, byte(i))
+	case i >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(i))
+	case i >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(i))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(i))
+	}
+}
+
+// encodeUint appends an unsigned integer in the smallest format.
+func (e *encoder) encodeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		e.buf = append(e.buf, byte(u))
+	case u <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(u))
+	case u <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(u))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, u)
+	}
+}
+
+// encodeLength appends the header of a string, binary, array, or map of
+// length n. The fixed format, if not 0, is used up to fixMax and the 8 bit
+// format, if not 0, up to 255.
+func (e *encoder) encodeLength(n int, fix byte, fixMax int, f8, f16, f32 byte) {
+	switch {
+	case fix != 0 && n <= fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case f8 != 0 && n <= math.MaxUint8:
+		e.buf = append(e.buf, f8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, f16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, f32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+// encodeString appends a string.
+func (e *encoder) encodeString(s string) {
+	e.encodeLength(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	e.buf = append(e.buf, s...)
+}
+
+// encodeBinary appends a byte slice.
+func (e *encoder) encodeBinary(b []byte) {
+	e.encodeLength(len(b), 0, 0, 0xc4, 0xc5, 0xc6)
+	e.buf = append(e.buf, b...)
+}
+
+// encodeArray appends a slice or array.
+func (e *encoder) encodeArray(v reflect.Value) error {
+	e.encodeLength(v.Len(), 0x90, 15, 0, 0xdc, 0xdd)
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap appends a map. String keys are sorted so the encoding is
+// deterministic.
+func (e *encoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	if v.Type().Key().Kind() == reflect.String {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+	}
+
+	e.encodeLength(len(keys), 0x80, 15, 0, 0xde, 0xdf)
+	for _, k := range keys {
+		if err := e.encode(k); err != nil {
+			return err
+		}
+		if err := e.encode(v.MapIndex(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeStruct appends a struct as a map of its fields.
+func (e *encoder) encodeStruct(v reflect.Value) error {
+	var fields []structField
+	for _, f := range structFields(v.Type()) {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		fields = append(fields, f)
+	}
+
+	e.encodeLength(len(fields), 0x80, 15, 0, 0xde, 0xdf)
+	for _, f := range fields {
+		e.encodeString(f.name)
+		if err := e.encode(v.FieldByIndex(f.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeTime appends a timestamp in the smallest format.
+func (e *encoder) encodeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec>>32 == 0 && nsec == 0:
+		e.buf = append(e.buf, 0xd6, 0xff)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(sec))
+	case sec >= 0 && sec>>34 == 0:
+		e.buf = append(e.buf, 0xd7, 0xff)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, nsec<<34|uint64(sec))
+	default:
+		e.buf = append(e.buf, 0xc7, 12, 0xff)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(nsec))
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(sec))
+	}
+}
+
+// structField is an encoded struct field.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns the exported fields of a struct. Embedded structs
+// without a name are flattened like encoding/json.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		embedded := f.Anonymous && f.Type.Kind() == reflect.Struct
+		if f.PkgPath != "" && !embedded {
+			continue
+		}
+
+		tag, ok := f.Tag.Lookup("msgpack")
+		if !ok {
+			tag = f.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		if embedded && name == "" {
+			for _, field := range structFields(f.Type) {
+				field.index = append([]int{i}, field.index...)
+				fields = append(fields, field)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		field := structField{name: name, index: []int{i}}
+		for _, p := range parts[1:] {
+			if p == "omitempty" {
+				field.omitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// decoder decodes values from msgpack data.
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next n bytes.
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes.
+func (d *decoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// length reads a length of n bytes.
+func (d *decoder) length(n int) (int, error) {
+	u, err := d.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(d.data)-d.pos) {
+		return 0, errShort
+	}
+	return int(u), nil
+}
+
+// decode returns the next value.
+func (d *decoder) decode(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("msgpack: exceeded max depth")
+	}
+
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(n)
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, nil
+		}
+		return int64(u), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		n := 1 << (c - 0xd0)
+		u, err := d.uint(n)
+		if err != nil {
+			return nil, err
+		}
+		// Sign extend from n bytes.
+		shift := 64 - 8*uint(n)
+		return int64(u<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n, depth)
+	}
+
+	return nil, fmt.Errorf("msgpack: invalid format 0x%02x", c)
+}
+
+// decodeString returns a string of n bytes.
+func (d *decoder) decodeString(n int) (interface{}, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// decodeArray returns an array of n values.
+func (d *decoder) decodeArray(n int, depth int) (interface{}, error) {
+	// Each value is at least a byte, so a length larger than the data left
+	// is invalid and isn't allocated.
+	if n > len(d.data)-d.pos {
+		return nil, errShort
+	}
+
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+// decodeMap returns a map of n pairs. Maps with only string keys are
+// returned as map[string]interface{}.
+func (d *decoder) decodeMap(n int, depth int) (interface{}, error) {
+	if n > (len(d.data)-d.pos)/2 {
+		return nil, errShort
+	}
+
+	keys := make([]interface{}, n)
+	values := make([]interface{}, n)
+	strs := true
+	for i := 0; i < n; i++ {
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := k.(string); !ok {
+			strs = false
+		}
+		keys[i], values[i] = k, v
+	}
+
+	if strs {
+		m := make(map[string]interface{}, n)
+		for i, k := range keys {
+			m[k.(string)] = values[i]
+		}
+		return m, nil
+	}
+
+	m := make(map[interface{}]interface{}, n)
+	for i, k := range keys {
+		if k != nil && !reflect.TypeOf(k).Comparable() {
+			return nil, fmt.Errorf("msgpack: unsupported map key of type %T", k)
+		}
+		m[k] = values[i]
+	}
+	return m, nil
+}
+
+// decodeExt returns the value of an extension with n bytes of data. Only
+// timestamps are supported.
+func (d *decoder) decodeExt(n int) (interface{}, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	if int8(b[0]) != timestampExt {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %v", int8(b[0]))
+	}
+
+	data, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		sec := binary.BigEndian.Uint64(data[4:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	}
+	return nil, fmt.Errorf("msgpack: invalid timestamp length %v", n)
+}
+
+// assign sets dst to a decoded value. Like encoding/json, nil leaves
+// values other than pointers, interfaces, maps, and slices unchanged.
+func assign(dst reflect.Value, src interface{}) error {
+	if src == nil {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		return nil
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("msgpack: can't decode %T into %v", src, dst.Type())
+	}
+
+	if dst.Type() == timeType {
+		t, ok := src.(time.Time)
+		if !ok {
+			return mismatch()
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src)
+	case reflect.Interface:
+		sv := reflect.ValueOf(src)
+		if !sv.Type().AssignableTo(dst.Type()) {
+			return mismatch()
+		}
+		dst.Set(sv)
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			return mismatch()
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := src.(int64)
+		if !ok || dst.OverflowInt(i) {
+			return mismatch()
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		switch n := src.(type) {
+		case int64:
+			if n < 0 {
+				return mismatch()
+			}
+			u = uint64(n)
+		case uint64:
+			u = n
+		default:
+			return mismatch()
+		}
+		if dst.OverflowUint(u) {
+			return mismatch()
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		switch n := src.(type) {
+		case float64:
+			dst.SetFloat(n)
+		case int64:
+			dst.SetFloat(float64(n))
+		case uint64:
+			dst.SetFloat(float64(n))
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		switch s := src.(type) {
+		case string:
+			dst.SetString(s)
+		case []byte:
+			dst.SetString(string(s))
+		default:
+			return mismatch()
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			switch b := src.(type) {
+			case []byte:
+				dst.SetBytes(b)
+				return nil
+			case string:
+				dst.SetBytes([]byte(b))
+				return nil
+			}
+		}
+		a, ok := src.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		s := reflect.MakeSlice(dst.Type(), len(a), len(a))
+		for i, v := range a {
+			if err := assign(s.Index(i), v); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Array:
+		a, ok := src.([]interface{})
+		if !ok || len(a) > dst.Len() {
+			return mismatch()
+		}
+		for i, v := range a {
+			if err := assign(dst.Index(i), v); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		set := func(k interface{}, v interface{}) error {
+			kv := reflect.New(dst.Type().Key()).Elem()
+			if err := assign(kv, k); err != nil {
+				return err
+			}
+			vv := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(vv, v); err != nil {
+				return err
+			}
+			dst.SetMapIndex(kv, vv)
+			return nil
+		}
+		switch m := src.(type) {
+		case map[string]interface{}:
+			for k, v := range m {
+				if err := set(k, v); err != nil {
+					return err
+				}
+			}
+		case map[interface{}]interface{}:
+			for k, v := range m {
+				if err := set(k, v); err != nil {
+					return err
+				}
+			}
+		default:
+			return mismatch()
+		}
+	case reflect.Struct:
+		m, ok := src.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		fields := structFields(dst.Type())
+		for k, v := range m {
+			f, ok := fieldByName(fields, k)
+			if !ok {
+				continue
+			}
+			if err := assign(dst.FieldByIndex(f.index), v); err != nil {
+				return err
+			}
+		}
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// fieldByName returns the field with the name, or else the first
+// whose name matches without case like encoding/json.
+func fieldByName(fields []structField, name string) (structField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
@@ -0,0 +1,150 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testUser struct {
+	testAddress
+	Name    string            `msgpack:"name"`
+	Age     int               `json:"age,omitempty"`
+	Score   float64           `json:"score"`
+	Nick    *string           `json:"nick"`
+	Tags    []string          `json:"tags"`
+	Avatar  []byte            `json:"avatar"`
+	Created time.Time         `json:"created"`
+	Meta    map[string]uint16 `json:"meta"`
+	Ignored string            `json:"-"`
+}
+
+func TestMsgpackEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		v   interface{}
+		hex string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{5, "05"},
+		{-1, "ff"},
+		{-33, "d0df"},
+		{200, "ccc8"},
+		{-200, "d1ff38"},
+		{70000, "ce00011170"},
+		{1.5, "cb3ff8000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{"abc", "a3616263"},
+		{strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{[]byte{1, 2}, "c4020102"},
+		{[]int{1, 2}, "920102"},
+		{map[string]int{"b": 2, "a": 1}, "82a16101a16202"},
+		{time.Unix(1, 0), "d6ff00000001"},
+		{time.Unix(1, 1), "d7ff0000000400000001"},
+		{time.Unix(-1, 0), "c70cff00000000ffffffffffffffff"},
+	} {
+		b, err := Codec{}.Marshal(tc.v)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.hex, hex.EncodeToString(b), "%v", tc.v)
+	}
+
+	_, err := Codec{}.Marshal(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	nick := "j"
+	in := testUser{
+		testAddress: testAddress{City: "Paris"},
+		Name:        "john",
+		Score:       -2.5,
+		Nick:        &nick,
+		Tags:        []string{"a", "b"},
+		Avatar:      []byte{0, 1, 2},
+		Created:     time.Unix(1700000000, 5).UTC(),
+		Meta:        map[string]uint16{"x": 300},
+		Ignored:     "secret",
+	}
+
+	c := Codec{}
+	b, err := c.Marshal(in)
+	assert.NoError(t, err)
+
+	var out testUser
+	assert.NoError(t, c.Unmarshal(b, &out))
+	in.Ignored = ""
+	out.Created = out.Created.UTC()
+	assert.Equal(t, in, out)
+
+	var generic map[string]interface{}
+	assert.NoError(t, c.Unmarshal(b, &generic))
+	assert.Equal(t, "Paris", generic["city"])
+	assert.Equal(t, -2.5, generic["score"])
+	assert.Equal(t, []interface{}{"a", "b"}, generic["tags"])
+	assert.Equal(t, []byte{0, 1, 2}, generic["avatar"])
+	assert.NotContains(t, generic, "age")
+	assert.NotContains(t, generic, "Ignored")
+}
+
+func TestMsgpackDecodeErrors(t *testing.T) {
+	c := Codec{}
+	var v interface{}
+	for _, data := range []string{
+		"",           // Empty.
+		"a36162",     // Truncated string.
+		"dd7fffffff", // Array longer than the data.
+		"c1",         // Never used format.
+		"0102",       // Data after the value.
+		"d401ff",     // Unsupported extension.
+	} {
+		b, _ := hex.DecodeString(data)
+		assert.Error(t, c.Unmarshal(b, &v), data)
+	}
+
+	deep := strings.Repeat("\x91", maxDepth+2) + "\xc0"
+	assert.Error(t, c.Unmarshal([]byte(deep), &v))
+
+	var n int8
+	assert.Error(t, c.Unmarshal([]byte{0xcc, 0xc8}, &n))
+	var u uint
+	assert.Error(t, c.Unmarshal([]byte{0xff}, &u))
+	assert.Error(t, c.Unmarshal([]byte{0xc0}, v))
+}
+
+func FuzzMsgpackDecode(f *testing.F) {
+	for _, data := range []string{
+		"c0",
+		"81a46e616d65a46a616e65",
+		"92cd0100a3616263",
+		"d6ffffffffff",
+		"dd7fffffff",
+		strings.Repeat("91", maxDepth+2) + "c0",
+	} {
+		b, _ := hex.DecodeString(data)
+		f.Add(b)
+	}
+
+	c := Codec{}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v interface{}
+		if err := c.Unmarshal(data, &v); err != nil {
+			return
+		}
+		b, err := c.Marshal(v)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var again interface{}
+		assert.NoError(t, c.Unmarshal(b, &again))
+
+		var u testUser
+		c.Unmarshal(data, &u)
+	})
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+var (
+	// ErrPartTooLarge is returned when a part of a multipart body is larger
+	// than MultipartOptions.MaxPartBytes.
+	ErrPartTooLarge = errors.New("router: multipart part too large")
+	// ErrTooManyParts is returned when a multipart body has more parts than
+	// MultipartOptions.MaxParts.
+	ErrTooManyParts = errors.New("router: too many multipart parts")
+)
+
+// MultipartOptions are the limits of EachPart.
+type MultipartOptions struct {
+	// MaxPartBytes is the maximum size of each part. Zero means no limit.
+	MaxPartBytes int64
+	// MaxParts is the maximum number of parts. Zero means no limit.
+	MaxParts int
+}
+
+// DefaultMultipartOptions are the multipart options of a new Mux.
+var DefaultMultipartOptions = MultipartOptions{
+	MaxParts: 1000,
+}
+
+// SetMultipartOptions sets the limits used by EachPart.
+func (m *Mux) SetMultipartOptions(options MultipartOptions) {
+	m.multipartOptions = options
+}
+
+// Part is a part of a multipart body. Reads past the size limit return a
+// 413 StatusError with ErrPartTooLarge.
+type Part struct {
+	*multipart.Part
+	remaining int64
+	limited   bool
+}
+
+// Read reads the body of the part.
+func (p *Part) Read(b []byte) (int, error) {
+	if !p.limited {
+		return p.Part.Read(b)
+	}
+	if p.remaining <= 0 {
+		// Check for more data so a part of exactly the limit succeeds.
+		var one [1]byte
+		if n, err := p.Part.Read(one[:]); n == 0 {
+			return 0, err
+		}
+		return 0, StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrPartTooLarge, ErrorCode: ErrorCodeBodyTooLarge}
+	}
+	if int64(len(b)) > p.remaining {
+		b = b[:p.remaining]
+	}
+	n, err := p.Part.Read(b)
+	p.remaining -= int64(n)
+	return n, err
+}
+
+// EachPart streams a multipart/form-data body, calling fn with each part in
+// order, so large uploads can be written to storage without buffering the
+// whole form in memory or on disk like ParseMultipartForm. Parts fn doesn't
+// read are skipped. An error returned by fn stops reading and is returned.
+// A body that isn't multipart returns a 415 StatusError, a malformed body a
+// 400 StatusError, and bodies over the MultipartOptions limits a 413
+// StatusError.
+func (m *Mux) EachPart(r *http.Request, fn func(part *Part) error) error {
+	mr, err := r.MultipartReader()
+	if err == http.ErrNotMultipart {
+		return StatusError{Code: http.StatusUnsupportedMediaType, Err: ErrUnsupportedMediaType}
+	} else if err != nil {
+		return StatusError{Code: http.StatusBadRequest, Err: err}
+	}
+
+	options := m.multipartOptions
+	for count := 1; ; count++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return readError(err)
+		}
+
+		if options.MaxParts > 0 && count > options.MaxParts {
+			part.Close()
+			return StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrTooManyParts, ErrorCode: ErrorCodeBodyTooLarge}
+		}
+
+		err = fn(&Part{
+			Part:      part,
+			remaining: options.MaxPartBytes,
+			limited:   options.MaxPartBytes > 0,
+		})
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testMultipart returns a multipart request with the fields in order.
+func testMultipart(fields ...string) *http.Request {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for i := 0; i < len(fields); i += 2 {
+		w, _ := mw.CreateFormFile(fields[i], fields[i]+".txt")
+		w.Write([]byte(fields[i+1]))
+	}
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/upload", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestEachPart(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetMultipartOptions(MultipartOptions{MaxPartBytes: 8, MaxParts: 3})
+
+	var got []string
+	mux.Post("/upload", func(w http.ResponseWriter, r *http.Request) error {
+		got = nil
+		return mux.EachPart(r, func(part *Part) error {
+			if part.FormName() == "skip" {
+				return nil
+			}
+			if part.FormName() == "stop" {
+				return StatusError{Code: http.StatusUnprocessableEntity}
+			}
+			b, err := ioutil.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			got = append(got, part.FileName()+"="+string(b))
+			return nil
+		})
+	})
+
+	for _, tc := range []struct {
+		fields []string
+		status int
+		got    []string
+	}{
+		{[]string{"a", "12345678", "skip", strings.Repeat("x", 100), "b", "two"}, http.StatusOK, []string{"a.txt=12345678", "b.txt=two"}},
+		{[]string{"a", "123456789"}, http.StatusRequestEntityTooLarge, nil},
+		{[]string{"a", "1", "b", "2", "c", "3", "d", "4"}, http.StatusRequestEntityTooLarge, []string{"a.txt=1", "b.txt=2", "c.txt=3"}},
+		{[]string{"a", "1", "stop", "", "b", "2"}, http.StatusUnprocessableEntity, []string{"a.txt=1"}},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, testMultipart(tc.fields...))
+		assert.Equal(t, tc.status, w.Code, tc.fields)
+		assert.Equal(t, tc.got, got, tc.fields)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/upload", strings.NewReader("{}"))
+	r.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestEachPartStreams(t *testing.T) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	r := httptest.NewRequest("POST", "/upload", pr)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	// The writer blocks until the part is read, so the part is handed to
+	// fn before the rest of the body is sent.
+	go func() {
+		w, _ := mw.CreateFormFile("file", "big.bin")
+		w.Write(bytes.Repeat([]byte("x"), 1<<20))
+		mw.Close()
+		pw.Close()
+	}()
+
+	var n int64
+	err := New().EachPart(r, func(part *Part) error {
+		var err error
+		n, err = io.Copy(ioutil.Discard, part)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1<<20), n)
+
+	r = httptest.NewRequest("POST", "/upload", strings.NewReader("bad"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	err = New().EachPart(r, func(part *Part) error { return nil })
+	var se StatusError
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, http.StatusBadRequest, se.Status())
+}
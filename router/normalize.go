@@ -0,0 +1,111 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// ErrMixedScripts is returned when a path segment mixes scripts in a way
+// that is used to spoof other paths, such as Latin and Cyrillic letters.
+var ErrMixedScripts = errors.New("router: path segment mixes scripts")
+
+// scripts are the scripts checked by RejectMixedScripts.
+var scripts = map[string]*unicode.RangeTable{
+	"Latin":    unicode.Latin,
+	"Cyrillic": unicode.Cyrillic,
+	"Greek":    unicode.Greek,
+	"Armenian": unicode.Armenian,
+	"Hebrew":   unicode.Hebrew,
+	"Arabic":   unicode.Arabic,
+	"Han":      unicode.Han,
+	"Hiragana": unicode.Hiragana,
+	"Katakana": unicode.Katakana,
+	"Bopomofo": unicode.Bopomofo,
+	"Hangul":   unicode.Hangul,
+	"Thai":     unicode.Thai,
+}
+
+// scriptCombinations are the sets of scripts that can be mixed in a segment
+// because they are written together.
+var scriptCombinations = []map[string]bool{
+	{"Latin": true, "Han": true, "Hiragana": true, "Katakana": true},
+	{"Latin": true, "Han": true, "Bopomofo": true},
+	{"Latin": true, "Han": true, "Hangul": true},
+}
+
+// SetPathNormalizer sets a function that normalizes the request path before
+// it is routed, such as norm.NFC.String from golang.org/x/text/unicode/norm,
+// so paths sent in NFD match routes registered in NFC.
+func (m *Mux) SetPathNormalizer(fn func(path string) string) {
+	m.normalizer = fn
+}
+
+// RejectMixedScripts rejects requests with a path segment that mixes
+// scripts, such as a Cyrillic а in an otherwise Latin segment, with a 400
+// StatusError. Scripts that are written together, such as Han and
+// Hiragana, are allowed.
+func (m *Mux) RejectMixedScripts(enabled bool) {
+	m.rejectMixedScripts = enabled
+}
+
+// normalize returns the request with a normalized path or an error if the
+// path is rejected.
+func (m *Mux) normalize(r *http.Request) (*http.Request, error) {
+	if m.normalizer != nil {
+		if p := m.normalizer(r.URL.Path); p != r.URL.Path {
+			u := *r.URL
+			u.Path = p
+			u.RawPath = ""
+			r = r.Clone(r.Context())
+			r.URL = &u
+		}
+	}
+
+	if m.rejectMixedScripts {
+		for _, seg := range strings.Split(r.URL.Path, "/") {
+			if mixedScripts(seg) {
+				return r, StatusError{Code: http.StatusBadRequest, Err: ErrMixedScripts}
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// mixedScripts returns true if the letters of s are from scripts that aren't
+// written together.
+func mixedScripts(s string) bool {
+	found := make(map[string]bool)
+	for _, c := range s {
+		if !unicode.IsLetter(c) {
+			continue
+		}
+		for name, table := range scripts {
+			if unicode.Is(table, c) {
+				found[name] = true
+				break
+			}
+		}
+	}
+
+	if len(found) <= 1 {
+		return false
+	}
+
+	for _, combination := range scriptCombinations {
+		allowed := true
+		for name := range found {
+			if !combination[name] {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			return false
+		}
+	}
+
+	return true
+}
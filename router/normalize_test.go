@@ -0,0 +1,54 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathNormalizer(t *testing.T) {
+	mux := New()
+	mux.SetPathNormalizer(func(p string) string {
+		return strings.Replace(p, "e\u0301", "\u00e9", -1)
+	})
+	mux.Get("/caf\u00e9", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/cafe%CC%81", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMixedScripts(t *testing.T) {
+	for _, tc := range []struct {
+		seg   string
+		mixed bool
+	}{
+		{"paypal", false},
+		{"p\u0430ypal", true},
+		{"привет-2024", false},
+		{"東京タワー", false},
+		{"tokyo-東京", false},
+		{"αβc", true},
+	} {
+		assert.Equal(t, tc.mixed, mixedScripts(tc.seg), tc.seg)
+	}
+
+	mux := New()
+	mux.RejectMixedScripts(true)
+	mux.Get("/{name}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/p%D0%B0ypal", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/paypal", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,148 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnimplementedOperation is matched by an OperationError with
+	// operations that don't have a handler.
+	ErrUnimplementedOperation = errors.New("router: unimplemented operation")
+	// ErrOrphanedHandler is matched by an OperationError with handlers that
+	// don't have an operation.
+	ErrOrphanedHandler = errors.New("router: handler without operation")
+)
+
+// openAPIMethods are the operation keys of an OpenAPI path item in the order
+// they are registered.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// OperationError is returned by ImportOpenAPI when the operations in the
+// document and the handlers don't match. No routes are registered.
+type OperationError struct {
+	// Unimplemented are the operation IDs without a handler.
+	Unimplemented []string
+	// Orphaned are the handler names without an operation.
+	Orphaned []string
+	// Unnamed are the operations without an operation ID, such as GET /users.
+	Unnamed []string
+}
+
+func (e *OperationError) Error() string {
+	parts := make([]string, 0, 3)
+	if len(e.Unimplemented) > 0 {
+		parts = append(parts, "unimplemented operations: "+strings.Join(e.Unimplemented, ", "))
+	}
+	if len(e.Orphaned) > 0 {
+		parts = append(parts, "handlers without operations: "+strings.Join(e.Orphaned, ", "))
+	}
+	if len(e.Unnamed) > 0 {
+		parts = append(parts, "operations without operationId: "+strings.Join(e.Unnamed, ", "))
+	}
+	return "router: openapi: " + strings.Join(parts, "; ")
+}
+
+// Is returns true for ErrUnimplementedOperation and ErrOrphanedHandler if the
+// error has operations or handlers of that kind.
+func (e *OperationError) Is(target error) bool {
+	switch target {
+	case ErrUnimplementedOperation:
+		return len(e.Unimplemented) > 0
+	case ErrOrphanedHandler:
+		return len(e.Orphaned) > 0
+	}
+	return false
+}
+
+// openAPIDocument is the part of an OpenAPI or Swagger document used to
+// register routes.
+type openAPIDocument struct {
+	BasePath string                                `json:"basePath"`
+	Paths    map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// openAPIOperation is the part of an operation used to register routes.
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+}
+
+// ImportOpenAPI registers a route for each operation in an OpenAPI 3 or
+// Swagger 2 JSON document with the handler named by its operationId. The
+// routes are named after the operation. If an operation doesn't have an
+// operationId or a handler, or a handler doesn't have an operation, an
+// OperationError is returned and no routes are registered so the problem is
+// found at startup. Convert YAML documents to JSON first.
+func (m *Mux) ImportOpenAPI(doc []byte, handlers map[string]func(http.ResponseWriter, *http.Request) error) ([]*Route, error) {
+	var d openAPIDocument
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, fmt.Errorf("router: openapi: %w", err)
+	}
+
+	type operation struct {
+		method string
+		path   string
+		id     string
+	}
+
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	operations := make([]operation, 0)
+	opErr := &OperationError{}
+	used := make(map[string]bool)
+	for _, p := range paths {
+		for _, method := range openAPIMethods {
+			raw, ok := d.Paths[p][method]
+			if !ok {
+				continue
+			}
+
+			var op openAPIOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("router: openapi: %s %s: %w", strings.ToUpper(method), p, err)
+			}
+
+			name := strings.ToUpper(method) + " " + p
+			switch {
+			case op.OperationID == "":
+				opErr.Unnamed = append(opErr.Unnamed, name)
+				continue
+			case handlers[op.OperationID] == nil:
+				opErr.Unimplemented = append(opErr.Unimplemented, op.OperationID)
+				continue
+			}
+
+			used[op.OperationID] = true
+			operations = append(operations, operation{
+				method: strings.ToUpper(method),
+				path:   strings.TrimSuffix(d.BasePath, "/") + p,
+				id:     op.OperationID,
+			})
+		}
+	}
+
+	for id := range handlers {
+		if !used[id] {
+			opErr.Orphaned = append(opErr.Orphaned, id)
+		}
+	}
+	sort.Strings(opErr.Orphaned)
+
+	if len(opErr.Unimplemented) > 0 || len(opErr.Orphaned) > 0 || len(opErr.Unnamed) > 0 {
+		return nil, opErr
+	}
+
+	routes := make([]*Route, 0, len(operations))
+	for _, op := range operations {
+		routes = append(routes, m.Handle(op.method, op.path, handlers[op.id]).Name(op.id))
+	}
+	return routes, nil
+}
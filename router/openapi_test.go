@@ -0,0 +1,84 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOpenAPI = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Users", "version": "1.0.0"},
+	"paths": {
+		"/users": {
+			"get": {"operationId": "listUsers"},
+			"post": {"operationId": "createUser"}
+		},
+		"/users/{id}": {
+			"parameters": [{"name": "id", "in": "path", "required": true}],
+			"get": {"operationId": "getUser"}
+		}
+	}
+}`
+
+func TestImportOpenAPI(t *testing.T) {
+	mux := New()
+	matched := ""
+	handler := func(name string) func(http.ResponseWriter, *http.Request) error {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			matched = name + " " + mux.Param(r, "id")
+			return nil
+		}
+	}
+
+	routes, err := mux.ImportOpenAPI([]byte(testOpenAPI), map[string]func(http.ResponseWriter, *http.Request) error{
+		"listUsers":  handler("listUsers"),
+		"createUser": handler("createUser"),
+		"getUser":    handler("getUser"),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, routes, 3)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/7", nil))
+	assert.Equal(t, "getUser 7", matched)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+	assert.Equal(t, "createUser ", matched)
+
+	names := make([]string, 0)
+	mux.Walk(func(route away.RouteInfo) error {
+		names = append(names, route.Method+" "+route.Handler)
+		return nil
+	})
+	assert.ElementsMatch(t, []string{"GET listUsers", "POST createUser", "GET getUser"}, names)
+}
+
+func TestImportOpenAPIMismatch(t *testing.T) {
+	mux := New()
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	_, err := mux.ImportOpenAPI([]byte(testOpenAPI), map[string]func(http.ResponseWriter, *http.Request) error{
+		"listUsers":  ok,
+		"deleteUser": ok,
+	})
+	assert.True(t, errors.Is(err, ErrUnimplementedOperation))
+	assert.True(t, errors.Is(err, ErrOrphanedHandler))
+
+	var opErr *OperationError
+	if assert.True(t, errors.As(err, &opErr)) {
+		assert.Equal(t, []string{"createUser", "getUser"}, opErr.Unimplemented)
+		assert.Equal(t, []string{"deleteUser"}, opErr.Orphaned)
+	}
+	assert.Equal(t, 0, mux.Count())
+
+	_, err = mux.ImportOpenAPI([]byte(`{"paths": {"/a": {"get": {}}}}`), nil)
+	assert.EqualError(t, err, "router: openapi: operations without operationId: GET /a")
+
+	_, err = mux.ImportOpenAPI([]byte(`{`), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+// Decision is the result of evaluating an access policy.
+type Decision struct {
+	// Allowed is true if the request may be served.
+	Allowed bool
+	// Reason explains a denial. It is added to the ErrForbidden error.
+	Reason string
+	// Err is set if the policy couldn't be evaluated, such as when an
+	// external evaluator is unavailable. The request is denied with a 500
+	// StatusError.
+	Err error
+}
+
+// AccessPolicy decides if a principal may access a route, so access control
+// lives at the router instead of in each handler. The principal is nil for
+// anonymous requests.
+//
+// External evaluators such as Open Policy Agent fit behind the interface by
+// sending the principal, route, and request attributes as the input
+// document and mapping the result to a Decision:
+//
+//	type opaPolicy struct{ client *http.Client; url string }
+//
+//	func (o opaPolicy) Allow(p router.Principal, route away.RouteInfo, r *http.Request) router.Decision {
+//		input := map[string]interface{}{
+//			"method":  route.Method,
+//			"pattern": route.Pattern,
+//			"path":    r.URL.Path,
+//		}
+//		if p != nil {
+//			input["principal"] = p.ID()
+//		}
+//		allowed, err := o.query(r.Context(), input) // POST {"input": ...} to the decision URL
+//		return router.Decision{Allowed: allowed, Err: err}
+//	}
+//
+// Policies are called for every request, so remote evaluators should cache
+// decisions or use a sidecar.
+type AccessPolicy interface {
+	Allow(p Principal, route away.RouteInfo, r *http.Request) Decision
+}
+
+// AccessPolicyFunc is a function that implements AccessPolicy.
+type AccessPolicyFunc func(p Principal, route away.RouteInfo, r *http.Request) Decision
+
+// Allow calls fn.
+func (fn AccessPolicyFunc) Allow(p Principal, route away.RouteInfo, r *http.Request) Decision {
+	return fn(p, route, r)
+}
+
+// Enforce returns middleware for Use that evaluates the policy for each
+// matched route. It must run after the authentication middleware. Denied
+// requests are passed to the ServeHTTP function as a 401 StatusError without
+// a principal or a 403 StatusError with one, and policy errors as a 500
+// StatusError.
+func (m *Mux) Enforce(policy AccessPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p := CurrentPrincipal(r)
+			d := policy.Allow(p, route.Info(), r)
+			switch {
+			case d.Err != nil:
+				m.serveError(w, r, StatusError{Code: http.StatusInternalServerError, Err: d.Err})
+			case d.Allowed:
+				next.ServeHTTP(w, r)
+			case p == nil:
+				m.serveError(w, r, StatusError{Code: http.StatusUnauthorized, Err: ErrUnauthenticated, ErrorCode: ErrorCodeUnauthenticated})
+			case d.Reason != "":
+				m.serveError(w, r, StatusError{Code: http.StatusForbidden, Err: fmt.Errorf("%w: %v", ErrForbidden, d.Reason), ErrorCode: ErrorCodeForbidden})
+			default:
+				m.serveError(w, r, StatusError{Code: http.StatusForbidden, Err: ErrForbidden, ErrorCode: ErrorCodeForbidden})
+			}
+		})
+	}
+}
+
+// Anyone is the role in a RoleMatrix that allows every request, including
+// anonymous ones.
+const Anyone = "*"
+
+// RoleMatrix is an AccessPolicy that allows the roles listed for each route.
+type RoleMatrix struct {
+	// Roles returns the roles of a principal. It isn't called for anonymous
+	// requests.
+	Roles func(p Principal) []string
+	// Routes are the roles allowed by route, keyed by the method and
+	// pattern, such as "GET /posts/:id", or "* /posts/:id" for every
+	// method. The role Anyone allows every request.
+	Routes map[string][]string
+	// AllowUnlisted allows requests to routes not in Routes. Otherwise they
+	// are denied.
+	AllowUnlisted bool
+}
+
+// Allow returns whether one of the roles of the principal is listed for the
+// route.
+func (rm RoleMatrix) Allow(p Principal, route away.RouteInfo, r *http.Request) Decision {
+	allowed, ok := rm.Routes[route.Method+" "+route.Pattern]
+	if !ok {
+		allowed, ok = rm.Routes["* "+route.Pattern]
+	}
+	if !ok {
+		if rm.AllowUnlisted {
+			return Decision{Allowed: true}
+		}
+		return Decision{Reason: "route not in role matrix"}
+	}
+
+	for _, role := range allowed {
+		if role == Anyone {
+			return Decision{Allowed: true}
+		}
+	}
+	if p == nil || rm.Roles == nil {
+		return Decision{}
+	}
+
+	for _, have := range rm.Roles(p) {
+		for _, role := range allowed {
+			if have == role {
+				return Decision{Allowed: true}
+			}
+		}
+	}
+	return Decision{Reason: "role not allowed"}
+}
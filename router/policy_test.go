@@ -0,0 +1,82 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnforceRoleMatrix(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(testAuthenticate, mux.Enforce(RoleMatrix{
+		Roles: func(p Principal) []string { return p.(testPrincipal).roles },
+		Routes: map[string][]string{
+			"GET /posts":        {Anyone},
+			"* /posts/:id":      {"editor"},
+			"DELETE /posts/:id": {"admin"},
+		},
+	}))
+
+	h := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux.Get("/posts", h)
+	mux.Put("/posts/{id}", h)
+	mux.Delete("/posts/{id}", h)
+	mux.Get("/settings", h)
+
+	for _, tc := range []struct {
+		method, path, user string
+		status             int
+		body               string
+	}{
+		{"GET", "/posts", "", http.StatusOK, ""},
+		{"PUT", "/posts/1", "", http.StatusUnauthorized, ""},
+		{"PUT", "/posts/1", "editor", http.StatusOK, ""},
+		{"DELETE", "/posts/1", "editor", http.StatusForbidden, "router: permission denied: role not allowed"},
+		{"DELETE", "/posts/1", "admin", http.StatusOK, ""},
+		{"GET", "/settings", "admin", http.StatusForbidden, "router: permission denied: route not in role matrix"},
+		{"GET", "/missing", "", http.StatusNotFound, ""},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		r.Header.Set("X-User", tc.user)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.method+" "+tc.path+" "+tc.user)
+		if tc.body != "" {
+			assert.Equal(t, tc.body, strings.TrimSpace(w.Body.String()))
+		}
+	}
+}
+
+func TestEnforcePolicyFunc(t *testing.T) {
+	var seen away.RouteInfo
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.Enforce(AccessPolicyFunc(func(p Principal, route away.RouteInfo, r *http.Request) Decision {
+		seen = route
+		if r.Header.Get("X-Fail") != "" {
+			return Decision{Err: errors.New("evaluator unavailable")}
+		}
+		return Decision{Allowed: true}
+	})))
+	mux.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) error { return nil })
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/posts/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "GET", seen.Method)
+	assert.Equal(t, "/posts/:id", seen.Pattern)
+
+	r := httptest.NewRequest("GET", "/posts/1", nil)
+	r.Header.Set("X-Fail", "1")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	assert.True(t, RoleMatrix{AllowUnlisted: true}.Allow(nil, seen, r).Allowed)
+}
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrTooManyRequests is returned by TooManyRequests.
+var ErrTooManyRequests = errors.New("router: too many requests")
+
+// SetRetryAfter sets the Retry-After header to the duration rounded up to
+// whole seconds.
+func SetRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(seconds(retryAfter)))
+}
+
+// TooManyRequests sets the Retry-After header and returns a 429 StatusError
+// for the handler to return, so rate limited responses are consistent:
+//
+//	return router.TooManyRequests(w, time.Minute)
+func TooManyRequests(w http.ResponseWriter, retryAfter time.Duration) error {
+	SetRetryAfter(w, retryAfter)
+	return StatusError{Code: http.StatusTooManyRequests, Err: ErrTooManyRequests, ErrorCode: ErrorCodeTooManyRequests}
+}
+
+// SetRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining, and
+// RateLimit-Reset headers with the number of requests allowed in the window,
+// the number left, and the time until the window resets in seconds.
+func SetRateLimitHeaders(w http.ResponseWriter, limit, remaining int, reset time.Duration) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+}
+
+// seconds returns the duration rounded up to whole seconds.
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
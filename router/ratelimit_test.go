@@ -0,0 +1,31 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTooManyRequests(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := TooManyRequests(w, 1500*time.Millisecond)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	var se StatusError
+	if assert.True(t, errors.As(err, &se)) {
+		assert.Equal(t, http.StatusTooManyRequests, se.Status())
+		assert.Equal(t, ErrTooManyRequests, se.Err)
+	}
+}
+
+func TestSetRateLimitHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetRateLimitHeaders(w, 100, -1, 30*time.Second)
+	assert.Equal(t, "100", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+}
@@ -0,0 +1,177 @@
+package recorder
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// HAR is a HTTP Archive 1.2 document.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR document.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that created the HAR document.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request and response.
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest is a request in a HAR entry.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse is a response in a HAR entry.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie, or query string parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the body of a request.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the body of a response.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARTimings contains the timing of a request in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HAR returns the recorded requests as a HAR document.
+func (rec *Recorder) HAR() HAR {
+	entries := rec.Entries()
+	har := HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "away", Version: "1"},
+			Entries: make([]HAREntry, 0, len(entries)),
+		},
+	}
+
+	for _, e := range entries {
+		ms := float64(e.Latency) / float64(time.Millisecond)
+		he := HAREntry{
+			StartedDateTime: e.Started.Format(time.RFC3339Nano),
+			Time:            ms,
+			Request: HARRequest{
+				Method:      e.Method,
+				URL:         e.URL,
+				HTTPVersion: e.Proto,
+				Cookies:     []HARNameValue{},
+				Headers:     harHeaders(e.RequestHeader),
+				QueryString: harQuery(e.URL),
+				HeadersSize: -1,
+				BodySize:    e.RequestBodySize,
+			},
+			Response: HARResponse{
+				Status:      e.Status,
+				StatusText:  http.StatusText(e.Status),
+				HTTPVersion: e.Proto,
+				Cookies:     []HARNameValue{},
+				Headers:     harHeaders(e.ResponseHeader),
+				Content: HARContent{
+					Size:     e.ResponseSize,
+					MimeType: e.ResponseHeader.Get("Content-Type"),
+					Text:     e.ResponseBody,
+				},
+				RedirectURL: e.ResponseHeader.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    e.ResponseSize,
+			},
+			Timings: HARTimings{Wait: ms},
+			Comment: e.Pattern,
+		}
+		if e.RequestBodySize > 0 {
+			he.Request.PostData = &HARPostData{
+				MimeType: e.RequestHeader.Get("Content-Type"),
+				Text:     e.RequestBody,
+			}
+		}
+		har.Log.Entries = append(har.Log.Entries, he)
+	}
+
+	return har
+}
+
+// harHeaders converts headers to HAR name value pairs.
+func harHeaders(h http.Header) []HARNameValue {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]HARNameValue, 0, len(h))
+	for _, name := range names {
+		for _, v := range h[name] {
+			out = append(out, HARNameValue{Name: name, Value: v})
+		}
+	}
+	return out
+}
+
+// harQuery converts the query string of a URL to HAR name value pairs.
+func harQuery(rawURL string) []HARNameValue {
+	out := make([]HARNameValue, 0)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return out
+	}
+	for name, values := range u.Query() {
+		for _, v := range values {
+			out = append(out, HARNameValue{Name: name, Value: v})
+		}
+	}
+	return out
+}
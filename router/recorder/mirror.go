@@ -0,0 +1,152 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ambientkit/away"
+	"github.com/ambientkit/away/router/paramconvert"
+)
+
+// MirrorConfig contains the settings for mirroring requests to files.
+type MirrorConfig struct {
+	// Dir is the directory the files are written to. It is created if it
+	// doesn't exist.
+	Dir string
+	// Routes are the patterns of the routes to mirror, such as /users/{id}.
+	// All routes are mirrored if empty.
+	Routes []string
+	// Sample is the fraction of requests mirrored, from 0 to 1. Defaults
+	// to 1.
+	Sample float64
+	// MaxBody is the maximum size of the request and response bodies.
+	// Requests with larger bodies aren't mirrored because they can't be
+	// replayed. Defaults to 1 MiB.
+	MaxBody int
+	// OnError is called when a file can't be written. Optional.
+	OnError func(err error)
+}
+
+// Mirror writes sampled requests and their responses to files as JSON
+// entries that routertest.Replay can send again, for catching regressions
+// when refactoring handlers. URLs, headers, and the fields of JSON and form
+// bodies are redacted with the route settings, so redacted values are
+// replayed as REDACTED. Bodies of other content types are stored raw.
+type Mirror struct {
+	config MirrorConfig
+	routes map[string]bool
+	seq    uint64
+}
+
+// NewMirror returns a mirror.
+func NewMirror(config MirrorConfig) *Mirror {
+	if config.Sample <= 0 {
+		config.Sample = 1
+	}
+	if config.MaxBody <= 0 {
+		config.MaxBody = 1 << 20
+	}
+
+	m := &Mirror{config: config}
+	if len(config.Routes) > 0 {
+		m.routes = make(map[string]bool, len(config.Routes))
+		for _, p := range config.Routes {
+			m.routes[paramconvert.BraceToColon(p)] = true
+		}
+	}
+	return m
+}
+
+// Middleware mirrors the sampled requests of the selected routes. The file
+// is written after the handler returns.
+func (m *Mirror) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := away.CurrentRoute(r.Context())
+		if route == nil || (m.routes != nil && !m.routes[route.Pattern()]) || rand.Float64() >= m.config.Sample {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Buffer the body so it is mirrored even if the handler doesn't
+		// read it.
+		var body []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			b, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(m.config.MaxBody)+1))
+			if err != nil || len(b) > m.config.MaxBody {
+				r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(b), r.Body), Closer: r.Body}
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body = readCloser{Reader: bytes.NewReader(b), Closer: r.Body}
+			body = b
+		}
+
+		e := capture(next, w, r, m.config.MaxBody)
+		e.RequestBody = route.RedactBody(r.Header.Get("Content-Type"), string(body))
+		e.ResponseBody = route.RedactBody(e.ResponseHeader.Get("Content-Type"), e.ResponseBody)
+		e.RequestBodySize = int64(len(body))
+		if e.ResponseSize > int64(m.config.MaxBody) {
+			return
+		}
+		if err := m.write(e); err != nil && m.config.OnError != nil {
+			m.config.OnError(err)
+		}
+	})
+}
+
+// write stores the entry in a new file.
+func (m *Mirror) write(e Entry) error {
+	if err := os.MkdirAll(m.config.Dir, 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%06d-%s.json", e.Started.UnixNano(), atomic.AddUint64(&m.seq, 1), strings.ToLower(e.Method))
+	tmp := filepath.Join(m.config.Dir, "."+name)
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.config.Dir, name))
+}
+
+// ReadMirror returns the entries written to a directory by a Mirror in the
+// order they were recorded.
+func ReadMirror(dir string) ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(files))
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, fmt.Errorf("recorder: %s: %w", filepath.Base(f), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readCloser reads from a buffered body and closes the original.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,70 @@
+package recorder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirror(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMirror(MirrorConfig{Dir: dir, Routes: []string{"/user/{id}"}, MaxBody: 8})
+	r := away.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("POST", "/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		// The body isn't read but is still mirrored.
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+	r.HandleFunc("GET", "/other", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/user/1", strings.NewReader("body")))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/user/2", strings.NewReader("too large body")))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/other", nil))
+
+	entries, err := ReadMirror(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "/user/1", entries[0].URL)
+		assert.Equal(t, "body", entries[0].RequestBody)
+		assert.Equal(t, http.StatusCreated, entries[0].Status)
+		assert.Equal(t, "created", entries[0].ResponseBody)
+	}
+}
+
+func TestMirrorRedactBody(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMirror(MirrorConfig{Dir: dir})
+	r := away.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("POST", "/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user":{"name":"a","token":"secret"}}`))
+	}).Redact("password", "token")
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"name":"a","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("POST", "/login", strings.NewReader("name=a&password=secret"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("POST", "/login", strings.NewReader("password=secret"))
+	req.Header.Set("Content-Type", "text/plain")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, err := ReadMirror(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, `{"name":"a","password":"REDACTED"}`, entries[0].RequestBody)
+		assert.Equal(t, `{"user":{"name":"a","token":"REDACTED"}}`, entries[0].ResponseBody)
+		assert.Equal(t, "name=a&password=REDACTED", entries[1].RequestBody)
+		// Other content types are stored raw.
+		assert.Equal(t, "password=secret", entries[2].RequestBody)
+	}
+}
@@ -0,0 +1,246 @@
+// Package recorder captures recent requests and responses for debugging.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// Config contains the settings for the recorder.
+type Config struct {
+	// Enabled turns on recording. It can be changed later with SetEnabled.
+	Enabled bool
+	// Size is the number of requests to keep. Defaults to 50.
+	Size int
+	// MaxBody is the maximum number of body bytes captured for each request
+	// and response. Defaults to 4096.
+	MaxBody int
+}
+
+// Entry is a recorded request and response.
+type Entry struct {
+	Started         time.Time     `json:"started"`
+	Method          string        `json:"method"`
+	URL             string        `json:"url"`
+	Proto           string        `json:"proto"`
+	Pattern         string        `json:"pattern"`
+	RequestHeader   http.Header   `json:"requestHeader"`
+	RequestBody     string        `json:"requestBody"`
+	RequestBodySize int64         `json:"requestBodySize"`
+	Status          int           `json:"status"`
+	ResponseHeader  http.Header   `json:"responseHeader"`
+	ResponseBody    string        `json:"responseBody"`
+	ResponseSize    int64         `json:"responseSize"`
+	Latency         time.Duration `json:"latency"`
+}
+
+// Recorder keeps the most recent requests in a ring buffer.
+type Recorder struct {
+	enabled int32
+	size    int
+	maxBody int
+
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+}
+
+// New returns a recorder.
+func New(config Config) *Recorder {
+	if config.Size <= 0 {
+		config.Size = 50
+	}
+	if config.MaxBody <= 0 {
+		config.MaxBody = 4096
+	}
+
+	rec := &Recorder{
+		size:    config.Size,
+		maxBody: config.MaxBody,
+		entries: make([]Entry, 0, config.Size),
+	}
+	rec.SetEnabled(config.Enabled)
+
+	return rec
+}
+
+// SetEnabled turns recording on or off.
+func (rec *Recorder) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&rec.enabled, v)
+}
+
+// Enabled returns true if recording is turned on.
+func (rec *Recorder) Enabled() bool {
+	return atomic.LoadInt32(&rec.enabled) == 1
+}
+
+// Middleware records each request when the recorder is enabled.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rec.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		e := capture(next, w, r, rec.maxBody)
+		rec.add(e)
+	})
+}
+
+// capture serves the request with next and returns the request and response
+// with up to maxBody bytes of each body.
+func capture(next http.Handler, w http.ResponseWriter, r *http.Request, maxBody int) Entry {
+	route := away.CurrentRoute(r.Context())
+	e := Entry{
+		Started:       time.Now(),
+		Method:        r.Method,
+		URL:           route.RedactURL(r.URL),
+		Proto:         r.Proto,
+		RequestHeader: route.RedactHeader(r.Header),
+	}
+	if route != nil {
+		e.Pattern = route.Pattern()
+	}
+
+	reqBody := &limitedBuffer{max: maxBody}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &teeBody{ReadCloser: r.Body, buf: reqBody}
+	}
+
+	rw := &responseWriter{ResponseWriter: w, body: &limitedBuffer{max: maxBody}}
+	next.ServeHTTP(rw, r)
+
+	e.Latency = time.Since(e.Started)
+	e.RequestBody = reqBody.String()
+	e.RequestBodySize = reqBody.total
+	e.Status = rw.status
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	e.ResponseHeader = route.RedactHeader(w.Header())
+	e.ResponseBody = rw.body.String()
+	e.ResponseSize = rw.body.total
+
+	return e
+}
+
+// Entries returns the recorded requests from oldest to newest.
+func (rec *Recorder) Entries() []Entry {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	out := make([]Entry, 0, len(rec.entries))
+	out = append(out, rec.entries[rec.next:]...)
+	out = append(out, rec.entries[:rec.next]...)
+	return out
+}
+
+// Reset removes all recorded requests.
+func (rec *Recorder) Reset() {
+	rec.mu.Lock()
+	rec.entries = rec.entries[:0]
+	rec.next = 0
+	rec.mu.Unlock()
+}
+
+// Handler serves the recorded requests as JSON or as a HAR file when the
+// format query parameter is set to har.
+func (rec *Recorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v interface{} = rec.Entries()
+		if r.URL.Query().Get("format") == "har" {
+			v = rec.HAR()
+			w.Header().Set("Content-Disposition", `attachment; filename="away.har"`)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+	})
+}
+
+// add stores an entry in the ring buffer.
+func (rec *Recorder) add(e Entry) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if len(rec.entries) < rec.size {
+		rec.entries = append(rec.entries, e)
+		return
+	}
+
+	rec.entries[rec.next] = e
+	rec.next = (rec.next + 1) % rec.size
+}
+
+// limitedBuffer stores up to max bytes and counts the total bytes written.
+type limitedBuffer struct {
+	bytes.Buffer
+	max   int
+	total int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if remaining := b.max - b.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.Buffer.Write(p[:remaining])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// teeBody copies the request body to a buffer as it is read.
+type teeBody struct {
+	io.ReadCloser
+	buf *limitedBuffer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		t.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// responseWriter captures the status code and body of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	body   *limitedBuffer
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 && (status >= 200 || status == http.StatusSwitchingProtocols) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
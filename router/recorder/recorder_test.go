@@ -0,0 +1,115 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouter(rec *Recorder) *away.Router {
+	r := away.NewRouter()
+	r.Use(rec.Middleware)
+	r.HandleFunc("POST", "/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created " + string(b)))
+	})
+	return r
+}
+
+func TestRecorder(t *testing.T) {
+	rec := New(Config{Enabled: true, Size: 2, MaxBody: 4})
+	r := newRouter(rec)
+
+	for _, id := range []string{"1", "2", "3"} {
+		req := httptest.NewRequest("POST", "/user/"+id+"?q=1", strings.NewReader("body"+id))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, "created body"+id, w.Body.String())
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "/user/3?q=1", entries[0].URL)
+	assert.Equal(t, "/user/:id", entries[0].Pattern)
+	assert.Equal(t, "body", entries[0].RequestBody)
+	assert.Equal(t, int64(5), entries[0].RequestBodySize)
+	assert.Equal(t, http.StatusCreated, entries[0].Status)
+	assert.Equal(t, "crea", entries[0].ResponseBody)
+	assert.Equal(t, int64(13), entries[0].ResponseSize)
+	assert.Equal(t, "/missing", entries[1].URL)
+	assert.Equal(t, "", entries[1].Pattern)
+	assert.Equal(t, http.StatusNotFound, entries[1].Status)
+
+	rec.Reset()
+	assert.Len(t, rec.Entries(), 0)
+}
+
+func TestDisabled(t *testing.T) {
+	rec := New(Config{})
+	r := newRouter(rec)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/user/1", strings.NewReader("body")))
+	assert.Len(t, rec.Entries(), 0)
+
+	rec.SetEnabled(true)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/user/1", strings.NewReader("body")))
+	assert.Len(t, rec.Entries(), 1)
+}
+
+func TestHandlerHAR(t *testing.T) {
+	rec := New(Config{Enabled: true})
+	r := newRouter(rec)
+	req := httptest.NewRequest("POST", "/user/1?q=1", strings.NewReader("body"))
+	req.Header.Set("Content-Type", "text/plain")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	rec.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/requests?format=har", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	har := HAR{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Len(t, har.Log.Entries, 1)
+	e := har.Log.Entries[0]
+	assert.Equal(t, "POST", e.Request.Method)
+	assert.Equal(t, []HARNameValue{{Name: "q", Value: "1"}}, e.Request.QueryString)
+	assert.Equal(t, "body", e.Request.PostData.Text)
+	assert.Equal(t, http.StatusCreated, e.Response.Status)
+	assert.Equal(t, "created body", e.Response.Content.Text)
+	assert.Equal(t, "/user/:id", e.Comment)
+
+	w = httptest.NewRecorder()
+	rec.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/requests", nil))
+	entries := make([]Entry, 0)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Len(t, entries, 1)
+}
+
+func TestRecorderRedact(t *testing.T) {
+	rec := New(Config{Enabled: true})
+	r := away.NewRouter()
+	r.Use(rec.Middleware)
+	r.HandleFunc("GET", "/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+	}).Redact("id", "key")
+
+	req := httptest.NewRequest("GET", "/user/1?key=secret", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "/user/REDACTED?key=REDACTED", entries[0].URL)
+	assert.Equal(t, away.Redacted, entries[0].RequestHeader.Get("Authorization"))
+	assert.Equal(t, away.Redacted, entries[0].ResponseHeader.Get("Set-Cookie"))
+}
@@ -0,0 +1,107 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// panicKey is the route value key for the panic handler of a route.
+type panicKey struct{}
+
+// PanicError is a recovered panic.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the panic value.
+func (e PanicError) Error() string {
+	return fmt.Sprintf("router: panic: %v", e.Value)
+}
+
+// PanicHandler writes the response for a recovered panic.
+type PanicHandler func(w http.ResponseWriter, r *http.Request, err PanicError)
+
+// panicPrefix is a panic handler for a group of routes.
+type panicPrefix struct {
+	prefix  string
+	handler PanicHandler
+}
+
+// OnPanic sets the panic handler of the route. It is used by the Recover
+// middleware instead of the group or default handler.
+func (rt *Route) OnPanic(h PanicHandler) *Route {
+	rt.route.WithValue(panicKey{}, h)
+	return rt
+}
+
+// OnPanic sets the panic handler for requests with paths at or below
+// prefix, such as "/api", which matches /api/users but not /apiary. The
+// longest matching prefix is used.
+func (m *Mux) OnPanic(prefix string, h PanicHandler) {
+	m.panics = append(m.panics, panicPrefix{prefix: prefix, handler: h})
+}
+
+// Recover returns middleware for Use that recovers panics in handlers. The
+// panic is passed to the handler set on the route with Route.OnPanic, then
+// the handler of the longest prefix set with Mux.OnPanic, then fallback. If
+// fallback is nil, the panic is passed to the ServeHTTP function as a 500
+// StatusError. Panics are sent to the error reporter either way.
+func (m *Mux) Recover(fallback PanicHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				} else if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				err := PanicError{Value: v, Stack: debug.Stack()}
+				if h := m.panicHandler(r, fallback); h != nil {
+					m.report(r, err)
+					h(w, r, err)
+					return
+				}
+				m.serveError(w, r, StatusError{Code: http.StatusInternalServerError, Err: err})
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// panicHandler returns the panic handler for the request.
+func (m *Mux) panicHandler(r *http.Request, fallback PanicHandler) PanicHandler {
+	if route := away.CurrentRoute(r.Context()); route != nil {
+		if h, ok := route.Value(panicKey{}).(PanicHandler); ok {
+			return h
+		}
+	}
+
+	var h PanicHandler
+	longest := -1
+	for _, p := range m.panics {
+		if hasPathPrefix(r.URL.Path, p.prefix) && len(p.prefix) > longest {
+			h = p.handler
+			longest = len(p.prefix)
+		}
+	}
+	if h != nil {
+		return h
+	}
+
+	return fallback
+}
+
+// hasPathPrefix returns true if the path is the prefix or is below it, so
+// the prefix /api matches /api and /api/users but not /apiary.
+func hasPathPrefix(path string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
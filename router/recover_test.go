@@ -0,0 +1,91 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	mux := New()
+	mux.Use(mux.Recover(func(w http.ResponseWriter, r *http.Request, err PanicError) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "<h1>Something went wrong</h1>")
+	}))
+	mux.OnPanic("/api/", func(w http.ResponseWriter, r *http.Request, err PanicError) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprint(err.Value)})
+	})
+
+	boom := func(w http.ResponseWriter, r *http.Request) (err error) {
+		panic("boom")
+	}
+	mux.Get("/page", boom)
+	mux.Get("/api/users", boom)
+	mux.Get("/api/plain", boom).OnPanic(func(w http.ResponseWriter, r *http.Request, err PanicError) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/page", 500, "<h1>Something went wrong</h1>"},
+		{"/api/users", 500, `{"error":"boom"}` + "\n"},
+		{"/api/plain", 503, ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		assert.Equal(t, tc.body, w.Body.String(), tc.path)
+	}
+}
+
+func TestRecoverPrefixSegments(t *testing.T) {
+	mux := New()
+	mux.Use(mux.Recover(func(w http.ResponseWriter, r *http.Request, err PanicError) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	mux.OnPanic("/api", func(w http.ResponseWriter, r *http.Request, err PanicError) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	boom := func(w http.ResponseWriter, r *http.Request) (err error) {
+		panic("boom")
+	}
+	mux.Get("/api", boom)
+	mux.Get("/api/users", boom)
+	mux.Get("/apiary", boom)
+
+	for path, status := range map[string]int{"/api": 503, "/api/users": 503, "/apiary": 500} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, status, w.Code, path)
+	}
+}
+
+func TestRecoverDefault(t *testing.T) {
+	mux := New()
+	var got error
+	mux.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		if err != nil {
+			got = err
+			w.WriteHeader(statusCode(err))
+		}
+	})
+	mux.Use(mux.Recover(nil))
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) (err error) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, "router: panic: boom", got.Error())
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrNoRenderer is returned by Render when no renderer has been set.
+var ErrNoRenderer = errors.New("router: no renderer set")
+
+// Renderer writes a named template with data.
+type Renderer interface {
+	Render(w io.Writer, r *http.Request, name string, data interface{}) error
+}
+
+// SetRenderer sets the renderer used by Render.
+func (m *Mux) SetRenderer(renderer Renderer) {
+	m.renderer = renderer
+}
+
+// Render executes the named template and writes it to the response with the
+// status code. The template is rendered to a buffer first so a template error
+// is returned as a StatusError instead of writing a partial page. The
+// renderer can read flash messages with CurrentFlashes and the metadata of
+// the route with CurrentMeta.
+func (m *Mux) Render(w http.ResponseWriter, r *http.Request, status int, name string, data interface{}) error {
+	if m.renderer == nil {
+		return StatusError{Code: http.StatusInternalServerError, Err: ErrNoRenderer}
+	}
+
+	r, err := withMeta(r, data)
+	if err != nil {
+		var e Error
+		if errors.As(err, &e) {
+			return err
+		}
+		return StatusError{Code: http.StatusInternalServerError, Err: err}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := m.renderer.Render(buf, m.withFlashes(w, r), name, data); err != nil {
+		return StatusError{Code: http.StatusInternalServerError, Err: err}
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(status)
+	_, err = buf.WriteTo(w)
+	return err
+}
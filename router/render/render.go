@@ -0,0 +1,273 @@
+// Package render provides a html/template renderer with layout and partial
+// support.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// maxFragments is the number of cached fragments after which expired
+// fragments are removed and new ones aren't cached until there is room.
+const maxFragments = 10000
+
+// Config contains the settings for the template renderer.
+type Config struct {
+	// Layout is the template each page is rendered within. The layout should
+	// call {{template "content" .}} to include the page. Optional.
+	Layout string
+	// Partials are glob patterns of templates parsed with every page.
+	Partials []string
+	// Funcs are made available to every template.
+	Funcs template.FuncMap
+	// RequestFuncs returns functions bound to the request being rendered,
+	// e.g. to read flash messages. It is also called with a nil request when
+	// parsing so the function names are known. Optional.
+	RequestFuncs func(r *http.Request) template.FuncMap
+	// Reload re-parses a page when the layout, a partial or the page changed,
+	// checked with a stat of each file on every render, so edits show up
+	// without a restart. It is meant for development; otherwise parsed
+	// templates are cached until the process exits. Fragments aren't cached
+	// in this mode.
+	Reload bool
+	// FragmentTTL is how long the output of RenderFragment is cached. Zero
+	// disables fragment caching.
+	FragmentTTL time.Duration
+}
+
+// Template renders pages from a filesystem using html/template.
+type Template struct {
+	fsys   fs.FS
+	config Config
+
+	mu    sync.RWMutex
+	cache map[string]*page
+
+	fragmentMu sync.Mutex
+	fragments  map[string]fragment
+}
+
+// page is a parsed page and the signature of the files it was parsed from.
+type page struct {
+	tmpl      *template.Template
+	signature string
+}
+
+// fragment is the cached output of a fragment.
+type fragment struct {
+	body    []byte
+	expires time.Time
+}
+
+// New returns a template renderer that reads templates from fsys.
+func New(fsys fs.FS, config Config) *Template {
+	return &Template{
+		fsys:      fsys,
+		config:    config,
+		cache:     make(map[string]*page),
+		fragments: make(map[string]fragment),
+	}
+}
+
+// Render executes the named page and writes the output to w.
+func (t *Template) Render(w io.Writer, r *http.Request, name string, data interface{}) error {
+	entry := path.Base(name)
+	if t.config.Layout != "" {
+		entry = path.Base(t.config.Layout)
+	}
+
+	return t.execute(w, r, name, entry, data)
+}
+
+// RenderFragment executes the template named fragment, such as a block
+// defined in the page or a partial, from the templates of the page and
+// writes the output to w. With FragmentTTL set the output is cached by page,
+// fragment, route pattern and path parameters, so the fragment must not
+// depend on anything else in data or the request, such as the user.
+func (t *Template) RenderFragment(w io.Writer, r *http.Request, name string, fragment string, data interface{}) error {
+	if t.config.FragmentTTL <= 0 || t.config.Reload || r == nil {
+		return t.execute(w, r, name, fragment, data)
+	}
+
+	key := fragmentKey(r, name, fragment)
+	now := time.Now()
+	t.fragmentMu.Lock()
+	cached, ok := t.fragments[key]
+	t.fragmentMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		_, err := w.Write(cached.body)
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.execute(buf, r, name, fragment, data); err != nil {
+		return err
+	}
+	t.storeFragment(key, buf.Bytes(), now.Add(t.config.FragmentTTL))
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ClearFragments removes the cached fragments, such as after the data they
+// show changed.
+func (t *Template) ClearFragments() {
+	t.fragmentMu.Lock()
+	t.fragments = make(map[string]fragment)
+	t.fragmentMu.Unlock()
+}
+
+// storeFragment caches the output of a fragment.
+func (t *Template) storeFragment(key string, body []byte, expires time.Time) {
+	t.fragmentMu.Lock()
+	defer t.fragmentMu.Unlock()
+
+	if len(t.fragments) >= maxFragments {
+		now := time.Now()
+		for k, f := range t.fragments {
+			if !now.Before(f.expires) {
+				delete(t.fragments, k)
+			}
+		}
+		if len(t.fragments) >= maxFragments {
+			return
+		}
+	}
+	t.fragments[key] = fragment{body: body, expires: expires}
+}
+
+// fragmentKey returns the cache key of a fragment for the route of the
+// request.
+func fragmentKey(r *http.Request, name string, fragment string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte(0)
+	b.WriteString(fragment)
+	b.WriteByte(0)
+	if route := away.CurrentRoute(r.Context()); route != nil {
+		b.WriteString(route.Pattern())
+	} else {
+		b.WriteString(r.URL.Path)
+	}
+	for _, p := range away.ParamList(r.Context()) {
+		b.WriteByte(0)
+		b.WriteString(p.Name)
+		b.WriteByte('=')
+		b.WriteString(p.Value)
+	}
+	return b.String()
+}
+
+// execute executes the entry template from the templates of the page.
+func (t *Template) execute(w io.Writer, r *http.Request, name string, entry string, data interface{}) error {
+	tmpl, err := t.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	if t.config.RequestFuncs != nil {
+		tmpl, err = tmpl.Clone()
+		if err != nil {
+			return err
+		}
+		tmpl.Funcs(t.config.RequestFuncs(r))
+	}
+
+	return tmpl.ExecuteTemplate(w, entry, data)
+}
+
+// lookup returns the parsed template for a page, parsing it on first use
+// and, with Reload, again after its files changed.
+func (t *Template) lookup(name string) (*template.Template, error) {
+	t.mu.RLock()
+	p, ok := t.cache[name]
+	t.mu.RUnlock()
+	if ok && !t.config.Reload {
+		return p.tmpl, nil
+	}
+
+	files, err := t.files(name)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := ""
+	if t.config.Reload {
+		signature, err = t.signature(files)
+		if err != nil {
+			return nil, err
+		}
+		if ok && p.signature == signature {
+			return p.tmpl, nil
+		}
+	}
+
+	tmpl, err := t.parse(files)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.cache[name] = &page{tmpl: tmpl, signature: signature}
+	t.mu.Unlock()
+
+	return tmpl, nil
+}
+
+// signature returns a string that changes when one of the files is
+// modified.
+func (t *Template) signature(files []string) (string, error) {
+	var b strings.Builder
+	for _, file := range files {
+		fi, err := fs.Stat(t.fsys, file)
+		if err != nil {
+			return "", fmt.Errorf("render: %w", err)
+		}
+		fmt.Fprintf(&b, "%v:%v:%v;", file, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// files returns the layout, partials, and page files of a page.
+func (t *Template) files(name string) ([]string, error) {
+	files := make([]string, 0)
+	if t.config.Layout != "" {
+		files = append(files, t.config.Layout)
+	}
+
+	for _, pattern := range t.config.Partials {
+		matches, err := fs.Glob(t.fsys, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("render: partials %v: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+
+	files = append(files, name)
+	return files, nil
+}
+
+// parse parses the layout, partials, and page into a single template.
+func (t *Template) parse(files []string) (*template.Template, error) {
+	tmpl := template.New(path.Base(files[0])).Funcs(t.config.Funcs)
+	if t.config.RequestFuncs != nil {
+		tmpl.Funcs(t.config.RequestFuncs(nil))
+	}
+
+	tmpl, err := tmpl.ParseFS(t.fsys, files...)
+	if err != nil {
+		return nil, fmt.Errorf("render: %w", err)
+	}
+
+	return tmpl, nil
+}
@@ -0,0 +1,136 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFS = fstest.MapFS{
+	"layout.tmpl":        {Data: []byte(`<html>{{template "nav" .}}{{template "content" .}}</html>`)},
+	"partials/nav.tmpl":  {Data: []byte(`{{define "nav"}}<nav></nav>{{end}}`)},
+	"page.tmpl":          {Data: []byte(`{{define "content"}}<p>{{.}}</p>{{end}}`)},
+	"standalone.tmpl":    {Data: []byte(`<b>{{.}}</b>`)},
+	"broken.tmpl":        {Data: []byte(`{{define "content"}}{{.Missing.Field}}{{end}}`)},
+	"partials/more.tmpl": {Data: []byte(`{{define "more"}}more{{end}}`)},
+}
+
+func TestLayout(t *testing.T) {
+	tr := New(testFS, Config{
+		Layout:   "layout.tmpl",
+		Partials: []string{"partials/*.tmpl"},
+	})
+
+	buf := new(bytes.Buffer)
+	err := tr.Render(buf, nil, "page.tmpl", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, `<html><nav></nav><p>hello</p></html>`, buf.String())
+
+	// Cached template.
+	buf.Reset()
+	err = tr.Render(buf, nil, "page.tmpl", "again")
+	assert.NoError(t, err)
+	assert.Equal(t, `<html><nav></nav><p>again</p></html>`, buf.String())
+}
+
+func TestNoLayout(t *testing.T) {
+	tr := New(testFS, Config{})
+
+	buf := new(bytes.Buffer)
+	err := tr.Render(buf, nil, "standalone.tmpl", "<hi>")
+	assert.NoError(t, err)
+	assert.Equal(t, `<b>&lt;hi&gt;</b>`, buf.String())
+}
+
+func TestErrors(t *testing.T) {
+	tr := New(testFS, Config{Layout: "layout.tmpl", Partials: []string{"partials/*.tmpl"}})
+
+	buf := new(bytes.Buffer)
+	assert.Error(t, tr.Render(buf, nil, "missing.tmpl", nil))
+	assert.Error(t, tr.Render(buf, nil, "broken.tmpl", "string"))
+}
+
+func TestRequestFuncs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.tmpl": {Data: []byte(`{{path}}`)},
+	}
+	tr := New(fsys, Config{
+		RequestFuncs: func(r *http.Request) template.FuncMap {
+			return template.FuncMap{
+				"path": func() string { return r.URL.Path },
+			}
+		},
+	})
+
+	for _, p := range []string{"/one", "/two"} {
+		buf := new(bytes.Buffer)
+		err := tr.Render(buf, httptest.NewRequest("GET", p, nil), "page.tmpl", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, p, buf.String())
+	}
+}
+
+func TestReload(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.tmpl": {Data: []byte(`v1 {{.}}`), ModTime: time.Unix(100, 0)},
+	}
+	cached := New(fsys, Config{})
+	reload := New(fsys, Config{Reload: true})
+
+	render := func(tr *Template) string {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, tr.Render(buf, nil, "page.tmpl", "x"))
+		return buf.String()
+	}
+	assert.Equal(t, "v1 x", render(cached))
+	assert.Equal(t, "v1 x", render(reload))
+
+	fsys["page.tmpl"] = &fstest.MapFile{Data: []byte(`v2 {{.}}`), ModTime: time.Unix(200, 0)}
+	assert.Equal(t, "v1 x", render(cached))
+	assert.Equal(t, "v2 x", render(reload))
+
+	// A partial added after the first render is picked up too.
+	reload = New(fsys, Config{Reload: true, Layout: "page.tmpl", Partials: []string{"partials/*.tmpl"}})
+	assert.Equal(t, "v2 x", render(reload))
+	fsys["partials/nav.tmpl"] = &fstest.MapFile{Data: []byte(`{{define "nav"}}{{end}}`)}
+	fsys["page.tmpl"] = &fstest.MapFile{Data: []byte(`v3 {{template "nav"}}{{.}}`), ModTime: time.Unix(300, 0)}
+	assert.Equal(t, "v3 x", render(reload))
+}
+
+func TestRenderFragment(t *testing.T) {
+	fsys := fstest.MapFS{
+		"post.tmpl": {Data: []byte(`{{define "comments"}}{{.}} comments{{end}}<p>{{template "comments" .}}</p>`)},
+	}
+	tr := New(fsys, Config{FragmentTTL: time.Minute})
+
+	count := 0
+	router := away.NewRouter()
+	router.HandleFunc("GET", "/posts/:id", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		assert.NoError(t, tr.RenderFragment(w, r, "post.tmpl", "comments", count))
+	})
+	get := func(target string) string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "1 comments", get("/posts/1"))
+	assert.Equal(t, "1 comments", get("/posts/1"))
+	assert.Equal(t, "3 comments", get("/posts/2"))
+
+	tr.ClearFragments()
+	assert.Equal(t, "4 comments", get("/posts/1"))
+
+	// Without a TTL every render executes the fragment.
+	buf := new(bytes.Buffer)
+	assert.NoError(t, New(fsys, Config{}).RenderFragment(buf, nil, "post.tmpl", "comments", 5))
+	assert.Equal(t, "5 comments", buf.String())
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+// ErrorReporter receives server errors and recovered panics, so they can be
+// sent to an error tracking service such as Sentry or Rollbar. The route is
+// empty for requests that didn't match one. Panics are reported as a
+// PanicError with the stack trace of the panic.
+type ErrorReporter interface {
+	Report(ctx context.Context, err error, route away.RouteInfo, r *http.Request)
+}
+
+// ErrorReporterFunc is a function that implements ErrorReporter.
+type ErrorReporterFunc func(ctx context.Context, err error, route away.RouteInfo, r *http.Request)
+
+// Report calls fn.
+func (fn ErrorReporterFunc) Report(ctx context.Context, err error, route away.RouteInfo, r *http.Request) {
+	fn(ctx, err, route, r)
+}
+
+// SetErrorReporter sets the reporter of errors with a 5xx status returned by
+// handlers or middleware and of panics recovered by the Recover middleware.
+// It should be set before serving requests.
+func (m *Mux) SetErrorReporter(reporter ErrorReporter) {
+	m.reporter = reporter
+}
+
+// report sends the error to the error reporter if it is a server error.
+func (m *Mux) report(r *http.Request, err error) {
+	if m.reporter == nil || err == nil {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var e Error
+	if errors.As(err, &e) {
+		status = e.Status()
+	}
+	var pe PanicError
+	if status < 500 && !errors.As(err, &pe) {
+		return
+	}
+
+	var info away.RouteInfo
+	if route := away.CurrentRoute(r.Context()); route != nil {
+		info = route.Info()
+	}
+	m.reporter.Report(r.Context(), err, info, r)
+}
+
+// reportErr returns fn with its server errors sent to the error reporter.
+func (m *Mux) reportErr(fn ErrHandler) ErrHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := fn(w, r)
+		m.report(r, err)
+		return err
+	}
+}
+
+// SlogReporter returns an ErrorReporter that logs errors at error level with
+// the request method, redacted URL, route, and request ID, and the stack
+// trace of panics. A nil logger uses slog.Default, which writes to stderr.
+func SlogReporter(logger *slog.Logger) ErrorReporter {
+	return ErrorReporterFunc(func(ctx context.Context, err error, route away.RouteInfo, r *http.Request) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+
+		attrs := []slog.Attr{
+			slog.String("error", err.Error()),
+			slog.String("method", r.Method),
+			slog.String("url", away.CurrentRoute(ctx).RedactURL(r.URL)),
+		}
+		if route.Pattern != "" {
+			attrs = append(attrs, slog.String("pattern", route.Pattern), slog.String("handler", route.Handler))
+		}
+		if id := r.Header.Get("X-Request-ID"); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		var pe PanicError
+		if errors.As(err, &pe) {
+			attrs = append(attrs, slog.String("stack", string(pe.Stack)))
+		}
+
+		l.LogAttrs(ctx, slog.LevelError, "request error", attrs...)
+	})
+}
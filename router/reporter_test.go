@@ -0,0 +1,84 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorReporter(t *testing.T) {
+	type report struct {
+		err   error
+		route away.RouteInfo
+	}
+	var reports []report
+
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetErrorReporter(ErrorReporterFunc(func(ctx context.Context, err error, route away.RouteInfo, r *http.Request) {
+		reports = append(reports, report{err, route})
+	}))
+	mux.Use(mux.Recover(nil))
+	mux.Get("/fail", func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database down")
+	})
+	mux.Get("/missing", func(w http.ResponseWriter, r *http.Request) error {
+		return StatusError{Code: http.StatusNotFound}
+	})
+	mux.Get("/panic", func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+	mux.Get("/handled", func(w http.ResponseWriter, r *http.Request) error {
+		panic("handled")
+	}).OnPanic(func(w http.ResponseWriter, r *http.Request, err PanicError) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	for _, path := range []string{"/fail", "/missing", "/panic", "/handled"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if assert.Len(t, reports, 3) {
+		assert.EqualError(t, reports[0].err, "database down")
+		assert.Equal(t, "/fail", reports[0].route.Pattern)
+
+		var pe PanicError
+		assert.True(t, errors.As(reports[1].err, &pe))
+		assert.Equal(t, "boom", pe.Value)
+		assert.Contains(t, string(pe.Stack), "reporter_test.go")
+		assert.Equal(t, "/panic", reports[1].route.Pattern)
+
+		assert.True(t, errors.As(reports[2].err, &pe))
+		assert.Equal(t, "handled", pe.Value)
+	}
+}
+
+func TestSlogReporter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetErrorReporter(SlogReporter(slog.New(slog.NewTextHandler(buf, nil))))
+	mux.Use(mux.Recover(nil))
+	mux.Get("/users/{token}", func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	}).Redact("token")
+
+	r := httptest.NewRequest("GET", "/users/secret", nil)
+	r.Header.Set("X-Request-ID", "abc")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "time="))
+	assert.Contains(t, out, `level=ERROR msg="request error" error="router: panic: boom" method=GET url=/users/`+away.Redacted+` pattern=/users/:token`)
+	assert.Contains(t, out, "request_id=abc")
+	assert.Contains(t, out, "stack=")
+	assert.NotContains(t, out, "secret")
+}
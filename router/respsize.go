@@ -0,0 +1,113 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+// ErrResponseTooLarge is returned when a response is larger than the maximum
+// size of its route.
+var ErrResponseTooLarge = errors.New("router: response too large")
+
+// maxResponseKey is the route value key for the maximum response size.
+type maxResponseKey struct{}
+
+// MaxResponseSize sets the maximum number of response body bytes for the
+// route. It is enforced by the LimitResponseSize middleware.
+func (rt *Route) MaxResponseSize(n int64) *Route {
+	rt.route.WithValue(maxResponseKey{}, n)
+	return rt
+}
+
+// LimitResponseSize returns middleware for Use that stops responses larger
+// than the maximum size set on the route with MaxResponseSize, or max for
+// other routes. Zero means no limit. Writes past the limit return
+// ErrResponseTooLarge and the error is passed to the ServeHTTP function as
+// a 500 StatusError. If the response has already started, the error is
+// still passed on for logging but its output is discarded and the
+// connection is aborted.
+func (m *Mux) LimitResponseSize(max int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := max
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				if n, ok := route.Value(maxResponseKey{}).(int64); ok {
+					limit = n
+				}
+			}
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			lw := &limitWriter{ResponseWriter: w, max: limit}
+			next.ServeHTTP(lw, r)
+			if !lw.exceeded {
+				return
+			}
+
+			err := StatusError{Code: http.StatusInternalServerError, Err: ErrResponseTooLarge}
+			if !lw.started {
+				m.serveError(w, r, err)
+				return
+			}
+
+			m.serveError(discardWriter{header: make(http.Header)}, r, err)
+			panic(http.ErrAbortHandler)
+		})
+	}
+}
+
+// limitWriter rejects writes past the maximum size. Once the size is
+// exceeded, all output is discarded so only the middleware reports the error.
+type limitWriter struct {
+	http.ResponseWriter
+	max      int64
+	written  int64
+	started  bool
+	exceeded bool
+}
+
+func (w *limitWriter) WriteHeader(status int) {
+	if w.exceeded {
+		return
+	}
+	if !informational(status) {
+		w.started = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if w.exceeded || w.written+int64(len(b)) > w.max {
+		w.exceeded = true
+		return 0, ErrResponseTooLarge
+	}
+
+	w.started = true
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying response writer.
+func (w *limitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// discardWriter is a response writer that discards the response.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w discardWriter) WriteHeader(status int) {}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitResponseSize(t *testing.T) {
+	mux := New()
+	errs := make([]error, 0)
+	mux.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		if err != nil {
+			errs = append(errs, err)
+			w.WriteHeader(statusCode(err))
+		}
+	})
+	mux.Use(mux.LimitResponseSize(10))
+
+	write := func(body string) func(http.ResponseWriter, *http.Request) error {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			w.Write([]byte(body))
+			return nil
+		}
+	}
+	mux.Get("/small", write("0123456789"))
+	mux.Get("/large", write("0123456789a"))
+	mux.Get("/export", write(strings.Repeat("a", 100))).MaxResponseSize(0)
+	mux.Get("/stream", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("0123456789"))
+		w.Write([]byte("a"))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/small", nil))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+	assert.Equal(t, 100, w.Body.Len())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/large", nil))
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, []error{StatusError{Code: 500, Err: ErrResponseTooLarge}}, errs)
+
+	w = httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+	})
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Len(t, errs, 2)
+}
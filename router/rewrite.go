@@ -0,0 +1,151 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// originalPathKey is the context key for the path before it was rewritten.
+type originalPathKey struct{}
+
+// rewriteRule rewrites paths that match from to the to pattern.
+type rewriteRule struct {
+	from   []string
+	to     []rewriteToken
+	prefix bool
+}
+
+// rewriteToken is a literal part of a to pattern or, if param is set, the
+// index of the from segment whose value replaces it.
+type rewriteToken struct {
+	literal string
+	param   bool
+	index   int
+}
+
+// Rewrite rewrites request paths that match from to the to pattern before
+// the request is routed. Parameters in braces are copied from the matched
+// path, for example "/blog/{slug}" to "/posts/{slug}". If from ends with /,
+// it matches any path with the prefix and the rest of the path is appended
+// to the to pattern. Rules are tried in the order they are added and only
+// the first match is applied. The original path is available via
+// OriginalPath.
+func (m *Mux) Rewrite(from string, to string) {
+	rule := rewriteRule{
+		prefix: strings.HasSuffix(from, "/"),
+	}
+	if trimmed := strings.Trim(from, "/"); trimmed != "" {
+		rule.from = strings.Split(trimmed, "/")
+	}
+	rule.to = parseRewriteTarget(to, rule.from)
+	m.rewrites = append(m.rewrites, rule)
+}
+
+// parseRewriteTarget splits a to pattern into literals and the parameters
+// of the from segments. Braces that don't name a parameter of from are kept
+// as literals.
+func parseRewriteTarget(to string, from []string) []rewriteToken {
+	params := make(map[string]int)
+	for i, seg := range from {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params[seg] = i
+		}
+	}
+
+	var tokens []rewriteToken
+	literal := func(s string) {
+		if n := len(tokens); n > 0 && !tokens[n-1].param {
+			tokens[n-1].literal += s
+		} else if s != "" {
+			tokens = append(tokens, rewriteToken{literal: s})
+		}
+	}
+	for to != "" {
+		start := strings.Index(to, "{")
+		if start < 0 {
+			literal(to)
+			break
+		}
+		end := strings.Index(to[start:], "}")
+		if end < 0 {
+			literal(to)
+			break
+		}
+		end += start + 1
+
+		literal(to[:start])
+		if i, ok := params[to[start:end]]; ok {
+			tokens = append(tokens, rewriteToken{param: true, index: i})
+		} else {
+			literal(to[start:end])
+		}
+		to = to[end:]
+	}
+	return tokens
+}
+
+// OriginalPath returns the request path before it was rewritten.
+func OriginalPath(r *http.Request) string {
+	if p, ok := r.Context().Value(originalPathKey{}).(string); ok {
+		return p
+	}
+	return r.URL.Path
+}
+
+// rewrite returns the request with the path rewritten by the first matching
+// rule.
+func (m *Mux) rewrite(r *http.Request) *http.Request {
+	if len(m.rewrites) == 0 {
+		return r
+	}
+
+	segs := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, rule := range m.rewrites {
+		p, ok := rule.apply(segs)
+		if !ok {
+			continue
+		}
+
+		ctx := context.WithValue(r.Context(), originalPathKey{}, r.URL.Path)
+		r = r.WithContext(ctx)
+		u := *r.URL
+		u.Path = p
+		u.RawPath = ""
+		r.URL = &u
+		return r
+	}
+
+	return r
+}
+
+// apply returns the rewritten path if the path segments match the rule.
+func (rule rewriteRule) apply(segs []string) (string, bool) {
+	if len(segs) < len(rule.from) || (len(segs) > len(rule.from) && !rule.prefix) {
+		return "", false
+	}
+
+	for i, seg := range rule.from {
+		if !(strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")) && seg != segs[i] {
+			return "", false
+		}
+	}
+
+	// Build the path in one pass so parameter values are never substituted
+	// again.
+	var b strings.Builder
+	for _, t := range rule.to {
+		if t.param {
+			b.WriteString(segs[t.index])
+		} else {
+			b.WriteString(t.literal)
+		}
+	}
+	p := b.String()
+
+	if rule.prefix {
+		p = strings.TrimSuffix(p, "/") + "/" + strings.Join(segs[len(rule.from):], "/")
+	}
+
+	return p, true
+}
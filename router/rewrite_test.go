@@ -0,0 +1,55 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewrite(t *testing.T) {
+	mux := New()
+	mux.Rewrite("/blog/{slug}", "/posts/{slug}")
+	mux.Rewrite("/legacy/", "/")
+	mux.Get("/posts/{slug}", func(w http.ResponseWriter, r *http.Request) (err error) {
+		w.Write([]byte(mux.Param(r, "slug") + " " + OriginalPath(r)))
+		return nil
+	})
+	mux.Get("/admin/users", func(w http.ResponseWriter, r *http.Request) (err error) {
+		w.Write([]byte(r.URL.Path + " " + OriginalPath(r)))
+		return nil
+	})
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/blog/hello", 200, "hello /blog/hello"},
+		{"/posts/hello", 200, "hello /posts/hello"},
+		{"/blog/hello/extra", 404, ""},
+		{"/legacy/admin/users", 200, "/admin/users /legacy/admin/users"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		if tc.status == 200 {
+			assert.Equal(t, tc.body, w.Body.String(), tc.path)
+		}
+	}
+}
+
+func TestRewriteRuleApply(t *testing.T) {
+	mux := New()
+	mux.Rewrite("/r/{a}/{b}", "/x/{b}/{a}/{c}")
+	rule := mux.rewrites[0]
+
+	p, ok := rule.apply([]string{"r", "{b}", "2"})
+	assert.True(t, ok)
+	assert.Equal(t, "/x/2/{b}/{c}", p)
+
+	p, ok = rule.apply([]string{"r", "1", "{a}"})
+	assert.True(t, ok)
+	assert.Equal(t, "/x/{a}/1/{c}", p)
+}
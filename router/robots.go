@@ -0,0 +1,113 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// noIndexKey is the route value key for routes excluded from crawlers.
+type noIndexKey struct{}
+
+// NoIndex excludes the route from search engines. The Robots middleware
+// sets an X-Robots-Tag header on its responses and RobotsHandler disallows
+// its path.
+func (rt *Route) NoIndex() *Route {
+	rt.route.WithValue(noIndexKey{}, true)
+	return rt
+}
+
+// RobotsOptions are the settings of RobotsHandler.
+type RobotsOptions struct {
+	// Disallow are paths disallowed in addition to the NoIndex routes.
+	Disallow []string
+	// Sitemap is the URL of the sitemap, such as one served by
+	// SitemapHandler. Optional.
+	Sitemap string
+}
+
+// Robots returns middleware for Use that sets the X-Robots-Tag header to
+// "noindex" on responses of routes marked with Route.NoIndex or
+// Group.NoIndex, which also covers files and API responses that a
+// robots.txt disallow doesn't keep out of search results.
+func (m *Mux) Robots() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				if noIndex, _ := route.Value(noIndexKey{}).(bool); noIndex {
+					w.Header().Set("X-Robots-Tag", "noindex")
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RobotsHandler returns a handler that serves a robots.txt for all user
+// agents that disallows the routes marked with NoIndex, so private sections
+// are excluded from crawlers as routes are added. A route with parameters
+// disallows the path up to its first parameter.
+func (m *Mux) RobotsHandler(options RobotsOptions) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var b strings.Builder
+		b.WriteString("User-agent: *\n")
+		disallow := m.disallowed(options.Disallow)
+		if len(disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+		for _, p := range disallow {
+			b.WriteString("Disallow: " + p + "\n")
+		}
+		if options.Sitemap != "" {
+			b.WriteString("\nSitemap: " + options.Sitemap + "\n")
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err := w.Write([]byte(b.String()))
+		return err
+	}
+}
+
+// disallowed returns the sorted robots.txt paths of the NoIndex routes and
+// the extra paths, without paths covered by a shorter one since crawlers
+// match them as prefixes.
+func (m *Mux) disallowed(extra []string) []string {
+	seen := make(map[string]bool)
+	paths := make([]string, 0, len(extra))
+	add := func(p string) {
+		if p != "" && !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	for _, p := range extra {
+		add(p)
+	}
+	for _, route := range m.router.Routes() {
+		if noIndex, _ := route.Value(noIndexKey{}).(bool); noIndex {
+			add(robotsPath(route.Pattern()))
+		}
+	}
+
+	sort.Strings(paths)
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if len(out) > 0 && strings.HasPrefix(p, out[len(out)-1]) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// robotsPath returns the robots.txt path of a route pattern: the pattern up
+// to its first parameter or wildcard.
+func robotsPath(pattern string) string {
+	if i := strings.Index(pattern, "/:"); i >= 0 {
+		return pattern[:i+1]
+	}
+	return strings.TrimSuffix(pattern, "...")
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobots(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.Robots())
+
+	ok := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux.Get("/", ok)
+	mux.Get("/drafts/{id}", ok).NoIndex()
+	mux.Get("/tmp/export.csv", ok).NoIndex()
+	admin := mux.Group("/admin").NoIndex()
+	admin.Get("", ok)
+	admin.Get("/users/{id}", ok)
+	mux.Get("/robots.txt", mux.RobotsHandler(RobotsOptions{
+		Disallow: []string{"/search"},
+		Sitemap:  "https://example.com/sitemap.xml",
+	}))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/admin/users/1", nil))
+	assert.Equal(t, "noindex", w.Header().Get("X-Robots-Tag"))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Empty(t, w.Header().Get("X-Robots-Tag"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/robots.txt", nil))
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "User-agent: *\n"+
+		"Disallow: /admin\n"+
+		"Disallow: /drafts/\n"+
+		"Disallow: /search\n"+
+		"Disallow: /tmp/export.csv\n"+
+		"\nSitemap: https://example.com/sitemap.xml\n", w.Body.String())
+
+	empty := New()
+	w = httptest.NewRecorder()
+	assert.NoError(t, empty.RobotsHandler(RobotsOptions{})(w, httptest.NewRequest("GET", "/robots.txt", nil)))
+	assert.Equal(t, "User-agent: *\nDisallow:\n", w.Body.String())
+}
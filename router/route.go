@@ -0,0 +1,81 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ambientkit/away"
+	"github.com/ambientkit/away/router/ambhandler"
+)
+
+// Route is a registered route that can be configured further.
+type Route struct {
+	mux   *Mux
+	route *away.Route
+}
+
+// When only matches the route if fn returns true for the request. Otherwise,
+// the next candidate route is tried.
+func (rt *Route) When(fn func(r *http.Request) bool) *Route {
+	rt.route.When(fn)
+	return rt
+}
+
+// WithFlag only matches the route when the feature flag is enabled for the
+// request. Flags are evaluated by the function passed to Mux.SetFlags.
+func (rt *Route) WithFlag(name string) *Route {
+	m := rt.mux
+	return rt.When(func(r *http.Request) bool {
+		if m.flags == nil {
+			return false
+		}
+		return m.flags(r, name)
+	})
+}
+
+// Shadow sets a handler that receives a copy of every request served by the
+// route, for comparing a new implementation against production traffic. The
+// shadow handler runs asynchronously and its response and error are
+// discarded.
+func (rt *Route) Shadow(fn func(w http.ResponseWriter, r *http.Request) error) *Route {
+	rt.route.Shadow(ambhandler.Handler{HandlerFunc: fn})
+	return rt
+}
+
+// Index sets a handler for requests for the path of a prefix route itself,
+// such as /docs or /docs/ for the pattern /docs/.
+func (rt *Route) Index(fn func(w http.ResponseWriter, r *http.Request) error) *Route {
+	rt.route.Index(rt.mux.handler(fn))
+	return rt
+}
+
+// Redact marks path params, headers, and query values with the names as
+// sensitive so the audit and recorder output masks them.
+func (rt *Route) Redact(names ...string) *Route {
+	rt.route.Redact(names...)
+	return rt
+}
+
+// Name sets the handler name shown by Walk and OnChange instead of the name
+// of the handler function.
+func (rt *Route) Name(name string) *Route {
+	rt.route.Name(name)
+	return rt
+}
+
+// WithValue stores static configuration on the route, such as a template
+// name, that handlers and middleware read with RouteValue. Use a key type
+// defined in your package to avoid collisions.
+func (rt *Route) WithValue(key, value interface{}) *Route {
+	rt.route.WithValue(key, value)
+	return rt
+}
+
+// RouteValue returns the value stored with WithValue on the route that
+// matched the request or nil.
+func RouteValue(r *http.Request, key interface{}) interface{} {
+	route := away.CurrentRoute(r.Context())
+	if route == nil {
+		return nil
+	}
+	return route.Value(key)
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFlag(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	match := ""
+	mux.Get("/checkout", func(w http.ResponseWriter, r *http.Request) (err error) {
+		match = "new"
+		return nil
+	}).WithFlag("new-checkout")
+	mux.Get("/checkout", func(w http.ResponseWriter, r *http.Request) (err error) {
+		match = "old"
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/checkout", nil)
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "old", match)
+
+	mux.SetFlags(func(r *http.Request, name string) bool {
+		return name == "new-checkout" && r.Header.Get("X-Beta") == "1"
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "old", match)
+
+	r.Header.Set("X-Beta", "1")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "new", match)
+}
+
+func TestShadow(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	shadowed := make(chan bool, 1)
+	mux.Get("/user", func(w http.ResponseWriter, r *http.Request) (err error) {
+		return nil
+	}).Shadow(func(w http.ResponseWriter, r *http.Request) (err error) {
+		shadowed <- true
+		return StatusError{Code: http.StatusInternalServerError}
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/user", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, <-shadowed)
+}
+
+func testNamedHandler(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func TestRouteName(t *testing.T) {
+	mux := New()
+	mux.Get("/", testNamedHandler)
+	mux.Group("/api").Use(testHeader("api")).Get("/users", testNamedHandler).Name("listUsers")
+
+	names := make([]string, 0)
+	mux.Walk(func(route away.RouteInfo) error {
+		names = append(names, route.Handler)
+		return nil
+	})
+	assert.Equal(t, []string{"router.testNamedHandler", "listUsers"}, names)
+}
+
+type templateKey struct{}
+
+func TestRouteValue(t *testing.T) {
+	mux := New()
+	render := func(w http.ResponseWriter, r *http.Request) error {
+		name, _ := RouteValue(r, templateKey{}).(string)
+		w.Write([]byte(name))
+		return nil
+	}
+	mux.Get("/", render).WithValue(templateKey{}, "home.tmpl")
+	mux.Get("/about", render).WithValue(templateKey{}, "about.tmpl")
+	mux.Get("/blank", render)
+
+	for path, want := range map[string]string{
+		"/":      "home.tmpl",
+		"/about": "about.tmpl",
+		"/blank": "",
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, want, w.Body.String(), path)
+	}
+
+	assert.Nil(t, RouteValue(httptest.NewRequest("GET", "/", nil), templateKey{}))
+}
+
+func TestRouteIndex(t *testing.T) {
+	mux := New()
+	mux.Get("/docs/", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("page"))
+		return nil
+	}).Index(func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("index"))
+		return nil
+	})
+
+	for path, want := range map[string]string{
+		"/docs/":      "index",
+		"/docs/intro": "page",
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, want, w.Body.String(), path)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	mux := New()
+	mux.Get("/users/{id}", testNamedHandler)
+	mux.Get("/users/{name}", testNamedHandler)
+
+	kinds := make([]away.IssueKind, 0)
+	for _, issue := range mux.Validate() {
+		kinds = append(kinds, issue.Kind)
+	}
+	assert.Equal(t, []away.IssueKind{away.IssueConflict, away.IssueConfig, away.IssueConfig}, kinds)
+
+	mux.Clear("GET", "/users/{name}")
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetNotFound(http.NotFoundHandler())
+	assert.Empty(t, mux.Validate())
+}
@@ -2,7 +2,12 @@
 package router
 
 import (
+	"errors"
 	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ambientkit/away"
 	"github.com/ambientkit/away/router/paramconvert"
@@ -14,6 +19,74 @@ type Mux struct {
 
 	// customServeHTTP is the serve function.
 	customServeHTTP func(w http.ResponseWriter, r *http.Request, err error)
+
+	// notFoundSet is true if SetNotFound was called.
+	notFoundSet bool
+
+	// renderer is used by Render.
+	renderer Renderer
+
+	// flashes stores flash messages.
+	flashes FlashStore
+
+	// jsonOptions are used by Bind.
+	jsonOptions JSONOptions
+
+	// multipartOptions are used by EachPart.
+	multipartOptions MultipartOptions
+
+	// codecs are used by Respond and Bind.
+	codecs []registeredCodec
+
+	// panics are the panic handlers for path prefixes.
+	panics []panicPrefix
+
+	// reporter receives server errors and panics.
+	reporter ErrorReporter
+
+	// inflight holds the requests tracked by TrackInflight.
+	inflight inflightRegistry
+
+	// requestDeadline is the timeout set on each request context.
+	requestDeadline time.Duration
+
+	// tenants resolves the tenant of each request.
+	tenants TenantResolver
+
+	// middleware are the names of the middleware added with Use.
+	middleware []string
+
+	// errMiddleware wraps the handlers of routes registered afterwards.
+	errMiddleware []ErrMiddleware
+
+	// normalizer normalizes the request path before routing.
+	normalizer func(path string) string
+	// rejectMixedScripts rejects paths that mix scripts.
+	rejectMixedScripts bool
+
+	// rewrites are applied to the request path before routing.
+	rewrites []rewriteRule
+
+	// flags determines if a feature flag is enabled for a request.
+	flags func(r *http.Request, name string) bool
+
+	// breakers are the circuit breakers created by NewBreaker.
+	breakersMu sync.Mutex
+	breakers   map[string]*Breaker
+
+	// jobs runs the background jobs registered with Async.
+	jobConfig JobConfig
+	jobsMu    sync.Mutex
+	jobs      *jobRunner
+
+	// providers are the constructors registered with Provide by the type
+	// they return.
+	providersMu sync.Mutex
+	providers   map[reflect.Type]*provider
+
+	// config is the *runtimeConfig set with ApplyConfig.
+	configMu sync.Mutex
+	config   atomic.Value
 }
 
 // New returns an instance of the router.
@@ -21,7 +94,12 @@ func New() *Mux {
 	r := away.NewRouter()
 
 	return &Mux{
-		router: r,
+		router:           r,
+		jsonOptions:      DefaultJSONOptions,
+		multipartOptions: DefaultMultipartOptions,
+		codecs: []registeredCodec{
+			{mediaType: "application/json", codec: JSONCodec{}},
+		},
 	}
 }
 
@@ -30,16 +108,150 @@ func (m *Mux) SetServeHTTP(csh func(w http.ResponseWriter, r *http.Request, err
 	m.customServeHTTP = csh
 }
 
+// SetFlags sets the function that determines if a feature flag is enabled
+// for a request. Routes registered with WithFlag only match when it returns
+// true.
+func (m *Mux) SetFlags(fn func(r *http.Request, name string) bool) {
+	m.flags = fn
+}
+
 // SetNotFound sets the NotFound function.
 func (m *Mux) SetNotFound(notFound http.Handler) {
+	m.notFoundSet = true
+	if d, ok := m.router.NotFound.(*tenantNotFound); ok {
+		d.fallback = notFound
+		return
+	}
 	m.router.NotFound = notFound
 }
 
+// SetMethodNotAllowed sets the handler for requests with a path that only
+// matches routes of other methods. The Allow header is set before it is
+// called. If not set, the NotFound handler is used.
+func (m *Mux) SetMethodNotAllowed(h http.Handler) {
+	m.router.MethodNotAllowed = h
+}
+
+// SetNotImplemented sets the handler for requests with a method that no
+// route is registered for. If not set, the MethodNotAllowed or NotFound
+// handler is used.
+func (m *Mux) SetNotImplemented(h http.Handler) {
+	m.router.NotImplemented = h
+}
+
+// Use adds middleware that wraps every request after it is routed. The
+// matched route is available via away.CurrentRoute.
+func (m *Mux) Use(mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		m.middleware = append(m.middleware, funcName(fn))
+	}
+	m.router.Use(mw...)
+}
+
+// Walk calls fn for each route in the order routes are matched. If fn
+// returns an error, Walk stops and returns it.
+func (m *Mux) Walk(fn func(route away.RouteInfo) error) error {
+	return m.router.Walk(fn)
+}
+
+// OnChange adds a listener that is called after a route is registered or
+// removed.
+func (m *Mux) OnChange(fn func(event away.RouteEvent)) {
+	m.router.OnChange(fn)
+}
+
+// OnUnmatched adds a listener that is called for each request that no route
+// matches with the method, path, and whether it was not found, not allowed,
+// or not implemented.
+func (m *Mux) OnUnmatched(fn func(event away.UnmatchedEvent)) {
+	m.router.OnUnmatched(fn)
+}
+
+// UnmatchedStats returns the number of requests that no route matched.
+func (m *Mux) UnmatchedStats() away.UnmatchedStats {
+	return m.router.UnmatchedStats()
+}
+
+// SetSuggestions enables up to max suggestions of similar routes to be
+// passed to the NotFound handler, available via away.Suggestions.
+func (m *Mux) SetSuggestions(max int) {
+	m.router.SetSuggestions(max)
+}
+
+// SetTrace sets a function that is called for every route considered for a
+// request and why it was rejected. Use nil to disable tracing.
+func (m *Mux) SetTrace(fn func(event away.TraceEvent)) {
+	m.router.SetTrace(fn)
+}
+
+// SetEscapedPath enables matching against the escaped path so parameters
+// can contain encoded slashes.
+func (m *Mux) SetEscapedPath(enabled bool) {
+	m.router.SetEscapedPath(enabled)
+}
+
+// Redact marks headers and query values with the names as sensitive for
+// every route so logging, audit, and recording mask them.
+func (m *Mux) Redact(names ...string) {
+	m.router.Redact(names...)
+}
+
+// SetStrict enables strict mode where registering an invalid pattern panics.
+func (m *Mux) SetStrict(strict bool) {
+	m.router.SetStrict(strict)
+}
+
+// ValidatePattern returns an error if the path pattern is invalid.
+func (m *Mux) ValidatePattern(path string) error {
+	return m.router.ValidatePattern(paramconvert.BraceToColon(path))
+}
+
 // Clear will remove a method and path from the router.
 func (m *Mux) Clear(method string, path string) {
 	m.router.Remove(method, paramconvert.BraceToColon(path))
 }
 
+// ClearAll will remove a path from the router for all methods and return the
+// number of routes removed.
+func (m *Mux) ClearAll(path string) int {
+	return m.router.RemoveAll(paramconvert.BraceToColon(path))
+}
+
+// SetEnabled enables or disables the route with the method and pattern and
+// returns false if there isn't one. Requests for a disabled route fall
+// through to the next candidate or the NotFound handler.
+func (m *Mux) SetEnabled(method string, path string, enabled bool) bool {
+	return m.router.SetEnabled(method, paramconvert.BraceToColon(path), enabled)
+}
+
+// AllowedMethods returns the sorted methods of the routes that match the
+// path, such as for the Allow header of an OPTIONS response. Routes
+// registered for away.MethodAny are reported by AnyMethod instead.
+func (m *Mux) AllowedMethods(path string) []string {
+	return m.router.AllowedMethods(path)
+}
+
+// AnyMethod returns true if a route registered for away.MethodAny matches
+// the path, so every method is allowed.
+func (m *Mux) AnyMethod(path string) bool {
+	return m.router.AnyMethod(path)
+}
+
+// Validate returns the problems with the routing table found by
+// away.Router.Validate and the missing NotFound handler or ServeHTTP
+// function. Without a ServeHTTP function, errors returned by handlers are
+// discarded.
+func (m *Mux) Validate() []away.Issue {
+	issues := m.router.Validate()
+	if m.customServeHTTP == nil {
+		issues = append(issues, away.Issue{Kind: away.IssueConfig, Message: "ServeHTTP function not set, handler errors are discarded"})
+	}
+	if !m.notFoundSet {
+		issues = append(issues, away.Issue{Kind: away.IssueConfig, Message: "NotFound handler not set"})
+	}
+	return issues
+}
+
 // Count will return the number of routes from the router.
 func (m *Mux) Count() int {
 	return m.router.Count()
@@ -48,7 +260,13 @@ func (m *Mux) Count() int {
 // ServeHTTP routes the incoming http.Request based on method and path
 // extracting path parameters as it goes.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	m.router.ServeHTTP(w, r)
+	r, err := m.normalize(withStore(r))
+	if err != nil {
+		m.serveError(w, r, err)
+		return
+	}
+
+	m.router.ServeHTTP(w, m.rewrite(m.resolveTenant(r)))
 }
 
 // StatusError returns error with a status code.
@@ -58,11 +276,29 @@ func (m *Mux) StatusError(status int, err error) error {
 
 // Error shows error page based on the status code.
 func (m *Mux) Error(status int, w http.ResponseWriter, r *http.Request) {
+	m.serveError(w, r, StatusError{Code: status, Err: nil})
+}
+
+// ServeError passes err to the ServeHTTP function like an error returned by a
+// handler, for middleware outside the package.
+func (m *Mux) ServeError(w http.ResponseWriter, r *http.Request, err error) {
+	m.serveError(w, r, err)
+}
+
+// serveError reports server errors and passes the error to the ServeHTTP
+// function or writes the status text if one is not set.
+func (m *Mux) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	m.report(r, err)
 	if m.customServeHTTP != nil {
-		m.customServeHTTP(w, r, StatusError{Code: status, Err: nil})
+		m.customServeHTTP(w, r, err)
 		return
 	}
 
+	status := http.StatusInternalServerError
+	var e Error
+	if errors.As(err, &e) {
+		status = e.Status()
+	}
 	http.Error(w, http.StatusText(status), status)
 }
 
@@ -71,6 +307,18 @@ func (m *Mux) Param(r *http.Request, param string) string {
 	return away.Param(r.Context(), param)
 }
 
+// Params returns the URL parameters by name with a value for each occurrence
+// of the name in the pattern.
+func (m *Mux) Params(r *http.Request) map[string][]string {
+	return away.Params(r.Context())
+}
+
+// ParamList returns the URL parameters in the order they appear in the
+// pattern.
+func (m *Mux) ParamList(r *http.Request) []away.PathParam {
+	return away.ParamList(r.Context())
+}
+
 // Wrap a standard http handler so it can be used easily.
 func (m *Mux) Wrap(handler http.HandlerFunc) func(w http.ResponseWriter, r *http.Request) (err error) {
 	return func(w http.ResponseWriter, r *http.Request) (err error) {
@@ -92,6 +340,9 @@ type StatusError struct {
 	Code     int
 	Err      error
 	Friendly string
+	// ErrorCode is a machine-readable code, such as user_not_found, that
+	// clients can branch on. See NewError.
+	ErrorCode string
 }
 
 // Error returns the error.
@@ -103,6 +354,11 @@ func (se StatusError) Error() string {
 	return ""
 }
 
+// Unwrap returns the wrapped error.
+func (se StatusError) Unwrap() error {
+	return se.Err
+}
+
 // Status returns a HTTP status code.
 func (se StatusError) Status() int {
 	return se.Code
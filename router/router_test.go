@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -353,3 +355,48 @@ func TestClear(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 	assert.False(t, called)
 }
+
+type testRenderer struct{}
+
+func (tr testRenderer) Render(w io.Writer, r *http.Request, name string, data interface{}) error {
+	if name != "page.tmpl" {
+		return errors.New("template not found")
+	}
+	_, err := fmt.Fprintf(w, "<p>%v</p>", data)
+	return err
+}
+
+func TestRender(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.SetRenderer(testRenderer{})
+
+	mux.Get("/page", func(w http.ResponseWriter, r *http.Request) (err error) {
+		return mux.Render(w, r, http.StatusCreated, "page.tmpl", "hello")
+	})
+	mux.Get("/missing", func(w http.ResponseWriter, r *http.Request) (err error) {
+		return mux.Render(w, r, http.StatusOK, "missing.tmpl", nil)
+	})
+
+	r := httptest.NewRequest("GET", "/page", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "<p>hello</p>", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	r = httptest.NewRequest("GET", "/missing", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "template not found\n", w.Body.String())
+}
+
+func TestRenderNoRenderer(t *testing.T) {
+	mux := New()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	err := mux.Render(w, r, http.StatusOK, "page.tmpl", nil)
+	assert.Equal(t, StatusError{Code: http.StatusInternalServerError, Err: ErrNoRenderer}, err)
+}
@@ -0,0 +1,80 @@
+// Package routertest provides helpers for testing routers.
+package routertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+
+	"github.com/ambientkit/away/router/recorder"
+)
+
+// Diff is a difference between a mirrored response and the response to the
+// replayed request.
+type Diff struct {
+	Method string
+	URL    string
+	// Field is status, content-type, or body.
+	Field string
+	Want  string
+	Got   string
+}
+
+// String returns a description of the difference.
+func (d Diff) String() string {
+	return fmt.Sprintf("%s %s: %s: want %q, got %q", d.Method, d.URL, d.Field, d.Want, d.Got)
+}
+
+// Replay sends the requests written to the directory by a recorder.Mirror to
+// the handler, usually a router.Mux, and returns the differences from the
+// mirrored responses. JSON bodies are compared by value so the order of keys
+// doesn't matter. Other headers aren't compared.
+func Replay(h http.Handler, dir string) ([]Diff, error) {
+	entries, err := recorder.ReadMirror(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []Diff
+	for _, e := range entries {
+		req := httptest.NewRequest(e.Method, e.URL, strings.NewReader(e.RequestBody))
+		for name, values := range e.RequestHeader {
+			req.Header[name] = append([]string(nil), values...)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		diff := func(field, want, got string) {
+			diffs = append(diffs, Diff{Method: e.Method, URL: e.URL, Field: field, Want: want, Got: got})
+		}
+		if w.Code != e.Status {
+			diff("status", fmt.Sprint(e.Status), fmt.Sprint(w.Code))
+		}
+		if want, got := e.ResponseHeader.Get("Content-Type"), w.Header().Get("Content-Type"); want != got {
+			diff("content-type", want, got)
+		}
+		if !sameBody([]byte(e.ResponseBody), w.Body.Bytes()) {
+			diff("body", e.ResponseBody, w.Body.String())
+		}
+	}
+	return diffs, nil
+}
+
+// sameBody returns true if the bodies are equal or are JSON with the same
+// value.
+func sameBody(want, got []byte) bool {
+	if bytes.Equal(want, got) {
+		return true
+	}
+
+	var w, g interface{}
+	if json.Unmarshal(want, &w) != nil || json.Unmarshal(got, &g) != nil {
+		return false
+	}
+	return reflect.DeepEqual(w, g)
+}
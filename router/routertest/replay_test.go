@@ -0,0 +1,60 @@
+package routertest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ambientkit/away/router"
+	"github.com/ambientkit/away/router/recorder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	newMux := func(greeting string) *router.Mux {
+		mux := router.New()
+		mux.Post("/greet/{name}", func(w http.ResponseWriter, r *http.Request) error {
+			var body struct {
+				Punctuation string `json:"punctuation"`
+			}
+			if err := mux.Bind(r, &body); err != nil {
+				return err
+			}
+			return mux.Respond(w, r, http.StatusOK, map[string]string{
+				"greeting": greeting + " " + mux.Param(r, "name") + body.Punctuation,
+				"version":  "1",
+			})
+		})
+		return mux
+	}
+
+	mux := newMux("Hello")
+	mux.Use(recorder.NewMirror(recorder.MirrorConfig{Dir: dir}).Middleware)
+	req := httptest.NewRequest("POST", "/greet/ada", strings.NewReader(`{"punctuation":"!"}`))
+	req.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	diffs, err := Replay(newMux("Hello"), dir)
+	assert.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	diffs, err = Replay(newMux("Hi"), dir)
+	assert.NoError(t, err)
+	if assert.Len(t, diffs, 1) {
+		assert.Equal(t, "body", diffs[0].Field)
+		assert.Contains(t, diffs[0].Got, "Hi ada!")
+		assert.Contains(t, diffs[0].String(), "POST /greet/ada: body")
+	}
+
+	_, err = Replay(newMux("Hello"), "missing")
+	assert.NoError(t, err)
+}
+
+func TestSameBody(t *testing.T) {
+	assert.True(t, sameBody([]byte(`{"a":1,"b":2}`), []byte(`{"b":2, "a":1}`)))
+	assert.False(t, sameBody([]byte(`{"a":1}`), []byte(`{"a":2}`)))
+	assert.False(t, sameBody([]byte("a"), []byte("b")))
+}
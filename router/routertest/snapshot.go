@@ -0,0 +1,110 @@
+package routertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// update rewrites the golden files instead of comparing with them.
+var update = flag.Bool("update-snapshots", false, "rewrite routertest golden files")
+
+// Scrubber replaces the parts of a rendered response that change between
+// runs, such as dates and IDs.
+type Scrubber func(s string) string
+
+// ScrubRegexp returns a scrubber that replaces the matches of the pattern.
+func ScrubRegexp(pattern string, replacement string) Scrubber {
+	re := regexp.MustCompile(pattern)
+	return func(s string) string {
+		return re.ReplaceAllString(s, replacement)
+	}
+}
+
+var (
+	// ScrubTimes replaces RFC 3339 timestamps with <TIME>.
+	ScrubTimes = ScrubRegexp(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`, "<TIME>")
+	// ScrubUUIDs replaces UUIDs with <UUID>.
+	ScrubUUIDs = ScrubRegexp(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`, "<UUID>")
+
+	// DefaultScrubbers run before the scrubbers passed to Snapshot.
+	DefaultScrubbers = []Scrubber{ScrubTimes, ScrubUUIDs}
+)
+
+// Snapshot serves the request with the handler, usually a router.Mux, and
+// compares the rendered response with the golden file after running the
+// scrubbers. The rendered response has the status, the sorted headers
+// except Date, and the body, with JSON indented. Run the tests with
+// -update-snapshots to write the golden files.
+func Snapshot(t testing.TB, h http.Handler, req *http.Request, golden string, scrubbers ...Scrubber) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	got := render(w)
+	for _, scrub := range DefaultScrubbers {
+		got = scrub(got)
+	}
+	for _, scrub := range scrubbers {
+		got = scrub(got)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("routertest: golden file %s doesn't exist, run the tests with -update-snapshots to write it", golden)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(want), got, "routertest: response doesn't match %s", golden)
+}
+
+// render returns the response as text.
+func render(w *httptest.ResponseRecorder) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s\n", w.Code, http.StatusText(w.Code))
+
+	names := make([]string, 0, len(w.Header()))
+	for name := range w.Header() {
+		if name != "Date" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range w.Header()[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, v)
+		}
+	}
+	b.WriteString("\n")
+
+	body := w.Body.Bytes()
+	var indented bytes.Buffer
+	if json.Valid(body) && json.Indent(&indented, body, "", "  ") == nil {
+		body = append(bytes.TrimSpace(indented.Bytes()), '\n')
+	}
+	b.Write(body)
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package routertest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ambientkit/away/router"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failed = true
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failed = true
+}
+
+func TestSnapshot(t *testing.T) {
+	mux := router.New()
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Date", time.Now().Format(http.TimeFormat))
+		w.Header().Set("X-Request-Id", fmt.Sprint(time.Now().UnixNano()))
+		return mux.Respond(w, r, http.StatusOK, map[string]interface{}{
+			"id":      mux.Param(r, "id"),
+			"token":   "0b5e2f1c-8a47-4f3e-9c1d-6b2a7e9d4c10",
+			"created": time.Now().UTC(),
+		})
+	})
+	requestID := ScrubRegexp(`X-Request-Id: \d+`, "X-Request-Id: <ID>")
+
+	Snapshot(t, mux, httptest.NewRequest("GET", "/users/1", nil), "testdata/user_get.golden", requestID)
+
+	ft := &fakeT{TB: t}
+	Snapshot(ft, mux, httptest.NewRequest("GET", "/users/2", nil), "testdata/user_get.golden", requestID)
+	assert.True(t, ft.failed)
+
+	ft = &fakeT{TB: t}
+	Snapshot(ft, mux, httptest.NewRequest("GET", "/users/1", nil), "testdata/missing.golden")
+	assert.True(t, ft.failed)
+}
@@ -0,0 +1,167 @@
+package schema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generate returns a schema for the type of v using the json struct tags.
+// Struct fields are required unless they are pointers or tagged omitempty,
+// and pointer fields can be null.
+// A struct type that contains itself is added to $defs and referenced with
+// $ref. Byte slices are base64 strings like encoding/json.
+func Generate(v interface{}) *Schema {
+	g := &generator{
+		visiting:  make(map[reflect.Type]bool),
+		recursive: make(map[reflect.Type]bool),
+		names:     make(map[reflect.Type]string),
+	}
+	s := g.generate(reflect.TypeOf(v))
+	s.Defs = g.defs
+	return s
+}
+
+// generator tracks the struct types being generated to detect recursion.
+type generator struct {
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	names     map[reflect.Type]string
+	defs      map[string]*Schema
+}
+
+// generate returns the schema for a type.
+func (g *generator) generate(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: g.generate(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		if g.visiting[t] {
+			g.recursive[t] = true
+			return &Schema{Ref: "#/$defs/" + g.name(t)}
+		}
+
+		g.visiting[t] = true
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		g.addFields(s, t)
+		delete(g.visiting, t)
+
+		if g.recursive[t] {
+			if g.defs == nil {
+				g.defs = make(map[string]*Schema)
+			}
+			g.defs[g.name(t)] = s
+			return &Schema{Ref: "#/$defs/" + g.name(t)}
+		}
+		return s
+	}
+
+	return &Schema{}
+}
+
+// nullable returns the schema allowing null too, like encoding/json does for
+// pointers. References can't have a type so they are wrapped in anyOf.
+func nullable(s *Schema) *Schema {
+	switch {
+	case s.Ref != "":
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+	case s.Type != "":
+		s.Nullable = true
+	}
+	return s
+}
+
+// name returns the unique $defs name of a struct type.
+func (g *generator) name(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	base := t.Name()
+	if base == "" {
+		base = "Type"
+	}
+	name := base
+	for i := 2; g.taken(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.names[t] = name
+	return name
+}
+
+// taken returns true if a $defs name is used by another type.
+func (g *generator) taken(name string) bool {
+	for _, n := range g.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addFields adds the fields of a struct to an object schema.
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		// Embedded structs without a name are flattened like encoding/json.
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.addFields(s, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.generate(f.Type)
+		if f.Type.Kind() == reflect.Ptr {
+			fs = nullable(fs)
+		}
+		s.Properties[name] = fs
+
+		omitempty := false
+		for _, p := range parts[1:] {
+			if p == "omitempty" {
+				omitempty = true
+			}
+		}
+		if !omitempty && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// Package schema validates JSON values against a subset of JSON Schema and
+// generates schemas from Go types.
+//
+// The supported keywords are type, properties, required,
+// additionalProperties, items, enum, minimum, maximum, minLength,
+// maxLength, pattern, minItems, maxItems, and anyOf, and $ref to the root
+// (#) or to $defs. A type can be one name or a name and "null", such as
+// ["string", "null"]. Format and contentEncoding are annotations and not
+// validated.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	// Nullable also allows null for the type. It is written as a type
+	// array with "null".
+	Nullable bool `json:"-"`
+
+	once    sync.Once
+	pattern *regexp.Regexp
+	err     error
+}
+
+// Error is a value that failed validation.
+type Error struct {
+	// Pointer is the JSON Pointer to the value, e.g. /address/city.
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// schemaJSON is a Schema without its JSON methods.
+type schemaJSON Schema
+
+// MarshalJSON writes the type of a nullable schema as a type array.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if !s.Nullable || s.Type == "" || s.Type == "null" {
+		return json.Marshal((*schemaJSON)(s))
+	}
+	return json.Marshal(struct {
+		*schemaJSON
+		Type []string `json:"type"`
+	}{(*schemaJSON)(s), []string{s.Type, "null"}})
+}
+
+// UnmarshalJSON reads a type that is a name or an array of a name and
+// "null".
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*schemaJSON
+		Type json.RawMessage `json:"type,omitempty"`
+	}{schemaJSON: (*schemaJSON)(s)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if len(v.Type) == 0 {
+		return nil
+	}
+	if v.Type[0] == '"' {
+		return json.Unmarshal(v.Type, &s.Type)
+	}
+
+	var types []string
+	if err := json.Unmarshal(v.Type, &types); err != nil {
+		return err
+	}
+	for _, t := range types {
+		switch {
+		case t == "null" && len(types) > 1:
+			s.Nullable = true
+		case s.Type != "":
+			return fmt.Errorf("schema: type %v isn't supported", strings.Join(types, ", "))
+		default:
+			s.Type = t
+		}
+	}
+	return nil
+}
+
+// Parse returns the schema in a JSON document.
+func Parse(b []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MustParse is like Parse but panics if the schema is invalid.
+func MustParse(b []byte) *Schema {
+	s, err := Parse(b)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// compile checks the patterns and references of the schema and its
+// children.
+func (s *Schema) compile(root *Schema) error {
+	if err := s.compiledPattern(); err != nil {
+		return err
+	}
+	seen := make(map[*Schema]bool)
+	for t := s; t.Ref != ""; {
+		if seen[t] {
+			return fmt.Errorf("schema: circular $ref %q", t.Ref)
+		}
+		seen[t] = true
+		if t = root.resolve(t.Ref); t == nil {
+			return fmt.Errorf("schema: unknown $ref %q", s.Ref)
+		}
+	}
+	for _, d := range s.Defs {
+		if err := d.compile(root); err != nil {
+			return err
+		}
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(root); err != nil {
+			return err
+		}
+	}
+	for _, a := range s.AnyOf {
+		if err := a.compile(root); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(root)
+	}
+	return nil
+}
+
+// resolve returns the schema a $ref of the root schema points to, or nil.
+func (s *Schema) resolve(ref string) *Schema {
+	if ref == "#" {
+		return s
+	}
+	if name := strings.TrimPrefix(ref, "#/$defs/"); name != ref {
+		return s.Defs[unescape(name)]
+	}
+	return nil
+}
+
+// compiledPattern compiles the pattern once.
+func (s *Schema) compiledPattern() error {
+	s.once.Do(func() {
+		if s.Pattern != "" {
+			s.pattern, s.err = regexp.Compile(s.Pattern)
+		}
+	})
+	return s.err
+}
+
+// Validate returns the errors for a value decoded from JSON. Numbers may be
+// float64 or json.Number.
+func (s *Schema) Validate(v interface{}) []Error {
+	errs := make([]Error, 0)
+	s.validate(s, v, "", &errs)
+	return errs
+}
+
+// validate appends the errors for a value at a pointer. References are
+// resolved against the root schema.
+func (s *Schema) validate(root *Schema, v interface{}, pointer string, errs *[]Error) {
+	p := pointer
+	if p == "" {
+		p = "/"
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, Error{Pointer: p, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Nullable && v == nil {
+		return
+	}
+
+	if len(s.AnyOf) > 0 {
+		// If no schema matches, the errors of the first one the value has
+		// the type of are reported.
+		var nested []Error
+		matched := false
+		for _, a := range s.AnyOf {
+			var e []Error
+			a.validate(root, v, pointer, &e)
+			if len(e) == 0 {
+				matched = true
+				break
+			}
+			if nested == nil && !failsAt(e, p) {
+				nested = e
+			}
+		}
+		if !matched {
+			if nested != nil {
+				*errs = append(*errs, nested...)
+			} else {
+				fail("must match one of the allowed schemas")
+			}
+		}
+	}
+
+	if s.Ref != "" {
+		ref := root.resolve(s.Ref)
+		if ref == nil {
+			fail("references unknown schema %v", s.Ref)
+			return
+		}
+		ref.validate(root, v, pointer, errs)
+	}
+
+	if s.Type != "" && !hasType(v, s.Type) {
+		fail("must be of type %v", s.Type)
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		fail("must be one of the allowed values")
+	}
+
+	switch t := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(t)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %v characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %v characters", *s.MaxLength)
+		}
+		if s.compiledPattern() == nil && s.pattern != nil && !s.pattern.MatchString(t) {
+			fail("must match the pattern %v", s.Pattern)
+		}
+	case float64, json.Number:
+		n, _ := toFloat(t)
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(t) < *s.MinItems {
+			fail("must have at least %v items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(t) > *s.MaxItems {
+			fail("must have at most %v items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range t {
+				s.Items.validate(root, item, pointer+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := t[name]; !ok {
+				*errs = append(*errs, Error{Pointer: pointer + "/" + escape(name), Message: "is required"})
+			}
+		}
+
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			p, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, Error{Pointer: pointer + "/" + escape(k), Message: "is not allowed"})
+				}
+				continue
+			}
+			p.validate(root, t[k], pointer+"/"+escape(k), errs)
+		}
+	}
+}
+
+// failsAt returns true if one of the errors is for the value at the pointer
+// itself.
+func failsAt(errs []Error, pointer string) bool {
+	for _, e := range errs {
+		if e.Pointer == pointer {
+			return true
+		}
+	}
+	return false
+}
+
+// hasType returns true if v is of the JSON Schema type.
+func hasType(v interface{}, typ string) bool {
+	switch typ {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(v)
+		return ok
+	case "integer":
+		n, ok := toFloat(v)
+		return ok && n == math.Trunc(n)
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// toFloat converts a JSON number to a float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// inEnum returns true if v equals one of the values.
+func inEnum(v interface{}, values []interface{}) bool {
+	if n, ok := toFloat(v); ok {
+		v = n
+	}
+	for _, e := range values {
+		if n, ok := toFloat(e); ok {
+			e = n
+		}
+		if reflect.DeepEqual(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// escape escapes a key for use in a JSON Pointer.
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// unescape reverses escape for a JSON Pointer token.
+func unescape(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
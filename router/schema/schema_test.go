@@ -0,0 +1,190 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var userSchema = MustParse([]byte(`{
+	"type": "object",
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "maxLength": 5},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"role": {"enum": ["admin", "user"]},
+		"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+		"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+		"a/b": {"type": "boolean"}
+	}
+}`))
+
+func decode(s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	assert.Empty(t, userSchema.Validate(decode(`{"name":"john","age":42,"role":"admin","tags":["a"]}`)))
+
+	assert.Equal(t, []Error{
+		{Pointer: "/age", Message: "is required"},
+		{Pointer: "/a~1b", Message: "must be of type boolean"},
+		{Pointer: "/email", Message: "must match the pattern ^[^@]+@[^@]+$"},
+		{Pointer: "/extra", Message: "is not allowed"},
+		{Pointer: "/name", Message: "must be at most 5 characters"},
+		{Pointer: "/role", Message: "must be one of the allowed values"},
+		{Pointer: "/tags", Message: "must have at most 2 items"},
+		{Pointer: "/tags/2", Message: "must be of type string"},
+	}, userSchema.Validate(decode(`{"name":"johnny","role":"owner","email":"x","tags":["a","b",1],"a/b":1,"extra":true}`)))
+
+	assert.Equal(t, []Error{
+		{Pointer: "/age", Message: "must be of type integer"},
+	}, userSchema.Validate(decode(`{"name":"john","age":1.5}`)))
+
+	assert.Equal(t, []Error{
+		{Pointer: "/", Message: "must be of type object"},
+	}, userSchema.Validate(decode(`[]`)))
+
+	assert.Equal(t, []Error{
+		{Pointer: "/age", Message: "must be at least 0"},
+	}, userSchema.Validate(map[string]interface{}{"name": "john", "age": json.Number("-1")}))
+}
+
+func TestParseInvalidPattern(t *testing.T) {
+	_, err := Parse([]byte(`{"properties":{"a":{"pattern":"("}}}`))
+	assert.Error(t, err)
+}
+
+type address struct {
+	City string `json:"city"`
+}
+
+type request struct {
+	Name    string    `json:"name"`
+	Age     int       `json:"age,omitempty"`
+	Score   float64   `json:"score"`
+	Nick    *string   `json:"nick"`
+	Tags    []string  `json:"tags"`
+	Created time.Time `json:"created"`
+	Address address   `json:"address"`
+	Ignored string    `json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(request{})
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"required": ["name", "score", "tags", "created", "address"],
+		"properties": {
+			"name": {"type": "string"},
+			"age": {"type": "integer"},
+			"score": {"type": "number"},
+			"nick": {"type": ["string", "null"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"created": {"type": "string", "format": "date-time"},
+			"address": {"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}}}
+		}
+	}`, string(b))
+
+	assert.Equal(t, []Error{
+		{Pointer: "/score", Message: "is required"},
+		{Pointer: "/tags", Message: "is required"},
+		{Pointer: "/created", Message: "is required"},
+		{Pointer: "/address", Message: "is required"},
+	}, s.Validate(decode(`{"name":"john"}`)))
+
+	// Pointer fields accept null like encoding/json.
+	assert.Equal(t, []Error{
+		{Pointer: "/name", Message: "must be of type string"},
+	}, s.Validate(decode(`{"name":null,"nick":null,"score":1,"tags":[],"created":"","address":{"city":""}}`)))
+}
+
+type node struct {
+	Value    string  `json:"value"`
+	Next     *node   `json:"next"`
+	Children []node  `json:"children,omitempty"`
+	Data     []byte  `json:"data,omitempty"`
+	Leaf     *leaf   `json:"leaf,omitempty"`
+	Sums     [2]byte `json:"sums,omitempty"`
+}
+
+type leaf struct {
+	Parent *node `json:"parent"`
+}
+
+func TestGenerateRecursive(t *testing.T) {
+	s := Generate(node{})
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$ref": "#/$defs/node",
+		"$defs": {
+			"node": {
+				"type": "object",
+				"required": ["value"],
+				"properties": {
+					"value": {"type": "string"},
+					"next": {"anyOf": [{"$ref": "#/$defs/node"}, {"type": "null"}]},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/node"}},
+					"data": {"type": "string", "contentEncoding": "base64"},
+					"leaf": {"type": ["object", "null"], "properties": {"parent": {"anyOf": [{"$ref": "#/$defs/node"}, {"type": "null"}]}}},
+					"sums": {"type": "array", "items": {"type": "integer"}}
+				}
+			}
+		}
+	}`, string(b))
+
+	assert.Equal(t, []Error{
+		{Pointer: "/next/value", Message: "is required"},
+		{Pointer: "/next/next/value", Message: "must be of type string"},
+	}, s.Validate(decode(`{"value":"a","next":{"next":{"value":1}}}`)))
+
+	parsed, err := Parse(b)
+	assert.NoError(t, err)
+	assert.Empty(t, parsed.Validate(decode(`{"value":"a","next":{"value":"b","data":"AQI="}}`)))
+	assert.Empty(t, parsed.Validate(decode(`{"value":"a","next":null,"leaf":null}`)))
+	assert.Equal(t, []Error{
+		{Pointer: "/leaf", Message: "must be of type object"},
+		{Pointer: "/next", Message: "must match one of the allowed schemas"},
+	}, parsed.Validate(decode(`{"value":"a","next":1,"leaf":"b"}`)))
+}
+
+func TestParseRef(t *testing.T) {
+	s, err := Parse([]byte(`{"properties":{"child":{"$ref":"#"}},"required":["id"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Error{
+		{Pointer: "/child/id", Message: "is required"},
+	}, s.Validate(decode(`{"id":1,"child":{}}`)))
+
+	_, err = Parse([]byte(`{"$ref":"#/$defs/missing"}`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`{"$ref":"#/$defs/a","$defs":{"a":{"$ref":"#/$defs/a"}}}`))
+	assert.Error(t, err)
+}
+
+func TestParseNullable(t *testing.T) {
+	s, err := Parse([]byte(`{"type":"object","properties":{"n":{"type":["integer","null"]},"z":{"type":["null"]}}}`))
+	assert.NoError(t, err)
+	assert.True(t, s.Properties["n"].Nullable)
+	assert.Empty(t, s.Validate(decode(`{"n":null,"z":null}`)))
+	assert.Equal(t, []Error{
+		{Pointer: "/n", Message: "must be of type integer"},
+		{Pointer: "/z", Message: "must be of type null"},
+	}, s.Validate(decode(`{"n":"a","z":1}`)))
+
+	b, err := json.Marshal(s.Properties["n"])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":["integer","null"]}`, string(b))
+
+	_, err = Parse([]byte(`{"type":["string","integer"]}`))
+	assert.Error(t, err)
+}
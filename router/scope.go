@@ -0,0 +1,111 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// scopesKey is the route value key for required scopes.
+type scopesKey struct{}
+
+// ScopedPrincipal is a principal with the scopes granted by its token, such
+// as a user logged in with OpenID Connect.
+type ScopedPrincipal interface {
+	Principal
+	Scopes() []string
+}
+
+// InsufficientScopeError is returned when the principal doesn't have all the
+// scopes required by a route.
+type InsufficientScopeError struct {
+	Missing []string
+}
+
+// Error returns the missing scopes.
+func (e InsufficientScopeError) Error() string {
+	return "router: missing scope " + strings.Join(e.Missing, " ")
+}
+
+// Scopes sets the token scopes, such as posts:write, a principal needs to
+// access the route. They are enforced by the EnforceScopes middleware.
+func (rt *Route) Scopes(scopes ...string) *Route {
+	existing, _ := rt.route.Value(scopesKey{}).([]string)
+	rt.route.WithValue(scopesKey{}, append(append([]string(nil), existing...), scopes...))
+	return rt
+}
+
+// RouteScopes returns the scopes required by a route.
+func RouteScopes(route *away.Route) []string {
+	if route == nil {
+		return nil
+	}
+	scopes, _ := route.Value(scopesKey{}).([]string)
+	return scopes
+}
+
+// PrincipalScopes returns the scopes of the request. They are the scopes of
+// the API key set by the APIKeys middleware, or else of the principal if it
+// is a ScopedPrincipal.
+func PrincipalScopes(r *http.Request) []string {
+	if key, ok := CurrentAPIKey(r); ok {
+		return key.Scopes
+	}
+	if p, ok := CurrentPrincipal(r).(ScopedPrincipal); ok {
+		return p.Scopes()
+	}
+	return nil
+}
+
+// scopeProblem is the problem details body of an InsufficientScopeError
+// written by ServeProblem.
+type scopeProblem struct {
+	Problem
+	MissingScopes []string `json:"missingScopes"`
+}
+
+// EnforceScopes returns middleware for Use that enforces the scopes set with
+// Scopes. It must run after the authentication middleware. Requests without
+// a principal are passed to the ServeHTTP function as a 401 StatusError.
+// Requests missing scopes are passed to the ServeHTTP function as a 403
+// StatusError wrapping an InsufficientScopeError, with a WWW-Authenticate
+// header for bearer tokens. ServeProblem lists the missing scopes.
+func (m *Mux) EnforceScopes() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required := RouteScopes(away.CurrentRoute(r.Context()))
+			if len(required) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if CurrentPrincipal(r) == nil {
+				m.serveError(w, r, StatusError{Code: http.StatusUnauthorized, Err: ErrUnauthenticated, ErrorCode: ErrorCodeUnauthenticated})
+				return
+			}
+
+			granted := make(map[string]bool)
+			for _, s := range PrincipalScopes(r) {
+				granted[s] = true
+			}
+			var missing []string
+			for _, s := range required {
+				if !granted[s] {
+					missing = append(missing, s)
+				}
+			}
+			if len(missing) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(required, " ")+`"`)
+			m.serveError(w, r, StatusError{
+				Code:      http.StatusForbidden,
+				Err:       InsufficientScopeError{Missing: missing},
+				ErrorCode: ErrorCodeInsufficientScope,
+			})
+		})
+	}
+}
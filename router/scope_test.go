@@ -0,0 +1,89 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testScopedPrincipal struct {
+	testPrincipal
+	scopes []string
+}
+
+func (p testScopedPrincipal) Scopes() []string {
+	return p.scopes
+}
+
+func TestEnforceScopes(t *testing.T) {
+	store := NewMemoryKeyStore()
+	store.Add("reader", APIKey{ID: "key1", Principal: testScopedPrincipal{scopes: []string{"posts:write"}}, Scopes: []string{"posts:read"}})
+
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.APIKeys(APIKeyConfig{Store: store}), func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-User") == "writer" {
+				r = WithPrincipal(r, testScopedPrincipal{testPrincipal{id: "1"}, []string{"posts:read", "posts:write"}})
+			} else if r.Header.Get("X-User") == "plain" {
+				r = WithPrincipal(r, testPrincipal{id: "2"})
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, mux.EnforceScopes())
+
+	h := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	mux.Get("/posts", h)
+	posts := mux.Group("/posts").Scopes("posts:read")
+	posts.Get("/drafts", h)
+	posts.Post("/{id}", h).Scopes("posts:write")
+
+	for _, tc := range []struct {
+		method, path, header, value string
+		status                      int
+	}{
+		{"GET", "/posts", "", "", http.StatusOK},
+		{"GET", "/posts/drafts", "", "", http.StatusUnauthorized},
+		{"GET", "/posts/drafts", "X-API-Key", "reader", http.StatusOK},
+		{"POST", "/posts/1", "X-API-Key", "reader", http.StatusForbidden},
+		{"POST", "/posts/1", "X-User", "writer", http.StatusOK},
+		{"GET", "/posts/drafts", "X-User", "plain", http.StatusForbidden},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.header != "" {
+			r.Header.Set(tc.header, tc.value)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.method+" "+tc.path+" "+tc.value)
+	}
+
+	// Scope failures go through the ServeHTTP function.
+	var served error
+	mux.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		if err != nil {
+			served = err
+		}
+		ServeProblem(w, r, err)
+	})
+	r := httptest.NewRequest("POST", "/posts/1", nil)
+	r.Header.Set("X-User", "plain")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	var scopeErr InsufficientScopeError
+	if assert.ErrorAs(t, served, &scopeErr) {
+		assert.Equal(t, []string{"posts:read", "posts:write"}, scopeErr.Missing)
+	}
+	assert.Equal(t, ErrorCodeInsufficientScope, ErrorCode(served))
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `Bearer error="insufficient_scope", scope="posts:read posts:write"`, w.Header().Get("WWW-Authenticate"))
+	var problem map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, float64(http.StatusForbidden), problem["status"])
+	assert.Equal(t, []interface{}{"posts:read", "posts:write"}, problem["missingScopes"])
+	assert.Equal(t, "The token is missing a required scope.", problem["detail"])
+	assert.Equal(t, "insufficient_scope", problem["code"])
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrListenerClosed is returned by Accept after a listener or its ConnMux is
+// closed.
+var ErrListenerClosed = errors.New("server: listener closed")
+
+// peekSize is the buffer size of a connection, which limits how much of it
+// matchers can peek, such as the first request of an HTTP/2 connection.
+const peekSize = 16 << 10
+
+// http2Preface is the client connection preface of HTTP/2.
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// Matcher reports whether a connection belongs to a listener. A matcher
+// should only Peek the reader so the next matcher sees the same bytes.
+type Matcher func(r *bufio.Reader) bool
+
+// HTTP/2 frame types and flags read by GRPC.
+const (
+	frameHeaders      = 0x1
+	frameContinuation = 0x9
+
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+)
+
+// HTTP2 matches connections that start with the HTTP/2 preface, which
+// includes cleartext HTTP/2 (h2c) with prior knowledge of both gRPC and
+// other clients.
+func HTTP2() Matcher {
+	return hasHTTP2Preface
+}
+
+// GRPC matches cleartext HTTP/2 connections whose first request has a
+// content type of application/grpc, such as application/grpc+proto. Other
+// HTTP/2 connections are left for the next listener.
+//
+// The matcher only reads what the client sends, so clients that wait for
+// the server's SETTINGS frame before sending the first request aren't
+// matched.
+func GRPC() Matcher {
+	return func(r *bufio.Reader) bool {
+		return strings.HasPrefix(http2ContentType(r), "application/grpc")
+	}
+}
+
+// hasHTTP2Preface returns true if the connection starts with the HTTP/2
+// preface. It peeks one more byte at a time so it doesn't wait for more
+// than a shorter HTTP/1 request sends.
+func hasHTTP2Preface(r *bufio.Reader) bool {
+	for i := 1; i <= len(http2Preface); i++ {
+		b, err := r.Peek(i)
+		if err != nil || b[i-1] != http2Preface[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+// http2ContentType returns the content type of the first request of an
+// HTTP/2 connection or an empty string if it can't be read.
+func http2ContentType(r *bufio.Reader) string {
+	if !hasHTTP2Preface(r) {
+		return ""
+	}
+
+	var (
+		block   []byte
+		started bool
+	)
+	for off := len(http2Preface); ; {
+		b, err := r.Peek(off + 9)
+		if err != nil {
+			return ""
+		}
+		h := b[off:]
+		length := int(h[0])<<16 | int(h[1])<<8 | int(h[2])
+		typ, flags := h[3], h[4]
+
+		b, err = r.Peek(off + 9 + length)
+		if err != nil {
+			return ""
+		}
+		payload := b[off+9:]
+		off += 9 + length
+
+		switch {
+		case typ == frameHeaders && !started:
+			if flags&flagPadded != 0 {
+				if len(payload) < 1 || int(payload[0]) >= len(payload) {
+					return ""
+				}
+				payload = payload[1 : len(payload)-int(payload[0])]
+			}
+			if flags&flagPriority != 0 {
+				if len(payload) < 5 {
+					return ""
+				}
+				payload = payload[5:]
+			}
+		case typ == frameContinuation && started:
+		case !started:
+			// Skip the SETTINGS, WINDOW_UPDATE, and other frames sent
+			// before the first request.
+			continue
+		default:
+			return ""
+		}
+
+		// Copy the payload because a later Peek can move the buffer.
+		block = append(block, payload...)
+		started = true
+		if flags&flagEndHeaders != 0 {
+			break
+		}
+	}
+
+	fields, err := newHPACKDecoder().decode(block)
+	if err != nil {
+		return ""
+	}
+	for _, f := range fields {
+		if f.name == "content-type" {
+			return f.value
+		}
+	}
+	return ""
+}
+
+// Any matches every connection.
+func Any() Matcher {
+	return func(r *bufio.Reader) bool {
+		return true
+	}
+}
+
+// ConnMux splits the connections of one listener between listeners by the
+// first bytes each connection sends.
+type ConnMux struct {
+	// ReadTimeout is the time a connection has to send enough bytes to be
+	// matched. Zero means no limit.
+	ReadTimeout time.Duration
+
+	root      net.Listener
+	listeners []*muxListener
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewConnMux returns a ConnMux for the listener.
+func NewConnMux(l net.Listener) *ConnMux {
+	return &ConnMux{
+		ReadTimeout: 5 * time.Second,
+		root:        l,
+		done:        make(chan struct{}),
+	}
+}
+
+// Match returns a listener that accepts the connections matched by any of
+// the matchers. Listeners are tried in the order Match is called so Any
+// should be last.
+func (m *ConnMux) Match(matchers ...Matcher) net.Listener {
+	l := &muxListener{
+		Listener: m.root,
+		mux:      m,
+		matchers: matchers,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+	m.listeners = append(m.listeners, l)
+	return l
+}
+
+// Serve accepts connections and hands them to the matching listener. Serve
+// returns nil after Close is called.
+func (m *ConnMux) Serve() error {
+	for {
+		c, err := m.root.Accept()
+		if err != nil {
+			select {
+			case <-m.done:
+				return nil
+			default:
+			}
+			m.Close()
+			return err
+		}
+
+		go m.serve(c)
+	}
+}
+
+// Close closes the underlying listener and all matched listeners.
+func (m *ConnMux) Close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		close(m.done)
+		err = m.root.Close()
+	})
+	return err
+}
+
+// serve matches a connection to a listener or closes it.
+func (m *ConnMux) serve(c net.Conn) {
+	if m.ReadTimeout > 0 {
+		c.SetReadDeadline(time.Now().Add(m.ReadTimeout))
+	}
+
+	br := bufio.NewReaderSize(c, peekSize)
+	for _, l := range m.listeners {
+		for _, match := range l.matchers {
+			if match(br) {
+				c.SetReadDeadline(time.Time{})
+				l.deliver(&bufferedConn{Conn: c, r: br})
+				return
+			}
+		}
+	}
+
+	c.Close()
+}
+
+// muxListener is a listener returned by ConnMux.Match.
+type muxListener struct {
+	net.Listener
+	mux       *ConnMux
+	matchers  []Matcher
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for the next matched connection.
+func (l *muxListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, ErrListenerClosed
+	case <-l.mux.done:
+		return nil, ErrListenerClosed
+	}
+}
+
+// Close stops the listener. Connections matched to it afterwards are
+// closed. The underlying listener is closed by ConnMux.Close.
+func (l *muxListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+// deliver passes a connection to Accept or closes it if the listener is
+// closed.
+func (l *muxListener) deliver(c net.Conn) {
+	select {
+	case l.conns <- c:
+	case <-l.done:
+		c.Close()
+	case <-l.mux.done:
+		c.Close()
+	}
+}
+
+// bufferedConn reads the bytes peeked by the matchers before the rest of
+// the connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// Read reads from the buffer and then the connection.
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
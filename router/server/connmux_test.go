@@ -0,0 +1,60 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// http2Frame returns an HTTP/2 frame on stream 1.
+func http2Frame(typ byte, flags byte, payload []byte) []byte {
+	n := len(payload)
+	b := []byte{byte(n >> 16), byte(n >> 8), byte(n), typ, flags, 0, 0, 0, 1}
+	return append(b, payload...)
+}
+
+// headerBlock returns a header block with the POST method and a literal
+// content type.
+func headerBlock(contentType string) []byte {
+	return append([]byte{0x83, 0x0f, 0x10, byte(len(contentType))}, contentType...)
+}
+
+func TestGRPCMatcher(t *testing.T) {
+	settings := []byte{0, 0, 0, 0x4, 0, 0, 0, 0, 0}
+	grpc := headerBlock("application/grpc+proto")
+
+	for _, tc := range []struct {
+		name  string
+		conn  []byte
+		match bool
+	}{
+		{"grpc", concat(http2Preface, settings, http2Frame(frameHeaders, flagEndHeaders, grpc)), true},
+		{"h2c", concat(http2Preface, settings, http2Frame(frameHeaders, flagEndHeaders, headerBlock("application/json"))), false},
+		{"continuation", concat(http2Preface,
+			http2Frame(frameHeaders, 0, grpc[:3]),
+			http2Frame(frameContinuation, flagEndHeaders, grpc[3:])), true},
+		{"padded", concat(http2Preface,
+			http2Frame(frameHeaders, flagEndHeaders|flagPadded|flagPriority, concat([]byte{2, 0, 0, 0, 0, 0}, grpc, []byte{0, 0}))), true},
+		{"interleaved", concat(http2Preface,
+			http2Frame(frameHeaders, 0, grpc[:3]),
+			settings,
+			http2Frame(frameContinuation, flagEndHeaders, grpc[3:])), false},
+		{"truncated", concat(http2Preface, http2Frame(frameHeaders, flagEndHeaders, grpc)[:12]), false},
+		{"http1", []byte("GET / HTTP/1.0\r\n\r\n"), false},
+	} {
+		r := bufio.NewReaderSize(bytes.NewReader(tc.conn), peekSize)
+		assert.Equal(t, tc.match, GRPC()(r), tc.name)
+		assert.Equal(t, !strings.HasPrefix(tc.name, "http1"), HTTP2()(r), tc.name)
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// ListenFDsEnv is the environment variable with the number of listeners
+// passed to a process by Handoff. They are the file descriptors from 3.
+const ListenFDsEnv = "AWAY_LISTEN_FDS"
+
+// ErrNoListenerFile is returned by Handoff for a listener without a file
+// descriptor, such as a listener returned by ConnMux.Match.
+var ErrNoListenerFile = errors.New("server: listener has no file descriptor")
+
+// inherited holds the listeners passed by Handoff. They are read once
+// because the environment variable is removed so child processes don't
+// reuse it.
+var inherited struct {
+	once      sync.Once
+	mu        sync.Mutex
+	listeners []net.Listener
+	err       error
+}
+
+// Inherited returns the listeners passed to the process by Handoff that
+// haven't been taken by ListenAndServe, in the order they were passed.
+func Inherited() ([]net.Listener, error) {
+	loadInherited()
+
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+	return append([]net.Listener(nil), inherited.listeners...), inherited.err
+}
+
+// loadInherited reads the listeners named by ListenFDsEnv.
+func loadInherited() {
+	inherited.once.Do(func() {
+		v := os.Getenv(ListenFDsEnv)
+		if v == "" {
+			return
+		}
+		os.Unsetenv(ListenFDsEnv)
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			inherited.err = fmt.Errorf("server: invalid %v %q", ListenFDsEnv, v)
+			return
+		}
+		for i := 0; i < n; i++ {
+			f := os.NewFile(uintptr(3+i), "listener"+strconv.Itoa(i))
+			l, err := net.FileListener(f)
+			f.Close()
+			if err != nil {
+				inherited.err = fmt.Errorf("server: inherited listener %v: %w", i, err)
+				return
+			}
+			inherited.listeners = append(inherited.listeners, l)
+		}
+	})
+}
+
+// takeInherited removes and returns the inherited TCP listener on the port
+// of the address.
+func takeInherited(addr string) net.Listener {
+	loadInherited()
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	p, err := net.LookupPort("tcp", port)
+	if err != nil {
+		return nil
+	}
+
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+	for i, l := range inherited.listeners {
+		if a, ok := l.Addr().(*net.TCPAddr); ok && a.Port == p {
+			inherited.listeners = append(inherited.listeners[:i], inherited.listeners[i+1:]...)
+			return l
+		}
+	}
+	return nil
+}
+
+// Handoff starts a new process of the running executable with the same
+// arguments and the listeners passed as inherited file descriptors, so a
+// deploy can swap binaries without refusing connections. The new process
+// picks them up with ListenAndServe or Inherited. Both processes accept
+// connections until the old one calls Shutdown, which drains its in-flight
+// requests.
+func Handoff(listeners ...net.Listener) (*os.Process, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return startWithListeners(path, os.Args[1:], os.Environ(), listeners)
+}
+
+// startWithListeners starts the command with the listeners as inherited
+// file descriptors.
+func startWithListeners(path string, args []string, env []string, listeners []net.Listener) (*os.Process, error) {
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, l := range listeners {
+		fl, ok := l.(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, ErrNoListenerFile
+		}
+		f, err := fl.File()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Env = append(withoutEnv(env, ListenFDsEnv), ListenFDsEnv+"="+strconv.Itoa(len(files)))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd.Process, nil
+}
+
+// withoutEnv returns the environment without the variable.
+func withoutEnv(env []string, name string) []string {
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if len(kv) > len(name) && kv[:len(name)+1] == name+"=" {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
+
+// Handoff passes the listener of the server to a new process of the running
+// executable. See Handoff. Call Shutdown afterwards to stop the old process
+// from accepting connections and let its requests finish.
+func (s *Server) Handoff() (*os.Process, error) {
+	s.mu.Lock()
+	l := s.listener
+	s.mu.Unlock()
+	if l == nil {
+		return nil, errors.New("server: not serving")
+	}
+	return Handoff(l)
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHandoffChild is the process started by TestHandoff. It serves one
+// request on the inherited listener.
+func TestHandoffChild(t *testing.T) {
+	addr := os.Getenv("HANDOFF_TEST_ADDR")
+	if addr == "" {
+		t.Skip("started by TestHandoff")
+	}
+
+	var s *Server
+	s = New(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("child"))
+		go s.Shutdown(context.Background())
+	}))
+	if err := s.ListenAndServe(); err != http.ErrServerClosed {
+		t.Fatal(err)
+	}
+	if os.Getenv(ListenFDsEnv) != "" {
+		t.Fatal("environment variable not removed")
+	}
+}
+
+func TestHandoff(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	parent := New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("parent"))
+	}))
+	go parent.Serve(l)
+	assert.Eventually(t, func() bool {
+		parent.mu.Lock()
+		defer parent.mu.Unlock()
+		return parent.listener != nil
+	}, time.Second, time.Millisecond)
+
+	addr := l.Addr().String()
+	p, err := startWithListeners(os.Args[0], []string{"-test.run=^TestHandoffChild$"},
+		append(os.Environ(), "HANDOFF_TEST_ADDR="+addr, ListenFDsEnv+"=9"), []net.Listener{l})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The old process stops accepting, and the child serves the next
+	// connection on the same socket.
+	assert.NoError(t, parent.Shutdown(context.Background()))
+	resp, err := http.Get("http://" + addr)
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "child", string(b))
+	}
+
+	state, err := p.Wait()
+	assert.NoError(t, err)
+	assert.True(t, state.Success())
+
+	_, err = startWithListeners(os.Args[0], nil, nil, []net.Listener{NewConnMux(l).Match(Any())})
+	assert.Equal(t, ErrNoListenerFile, err)
+}
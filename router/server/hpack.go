@@ -0,0 +1,384 @@
+package server
+
+import (
+	"errors"
+	"sync"
+)
+
+// errHPACK is returned for a header block that can't be decoded.
+var errHPACK = errors.New("server: invalid HPACK header block")
+
+// hpackField is a decoded header field.
+type hpackField struct {
+	name  string
+	value string
+}
+
+// hpackDecoder decodes the header blocks of one HTTP/2 connection as
+// described in RFC 7541. It is only used to read the first request headers
+// of a connection so it doesn't limit the size of fields.
+type hpackDecoder struct {
+	// dynamic is the dynamic table with the newest field first.
+	dynamic []hpackField
+	size    int
+	maxSize int
+}
+
+// newHPACKDecoder returns a decoder with the default dynamic table size.
+func newHPACKDecoder() *hpackDecoder {
+	return &hpackDecoder{maxSize: 4096}
+}
+
+// decode returns the fields of a header block.
+func (d *hpackDecoder) decode(p []byte) ([]hpackField, error) {
+	var fields []hpackField
+	for len(p) > 0 {
+		var (
+			f   hpackField
+			err error
+		)
+		switch b := p[0]; {
+		case b&0x80 != 0:
+			// Indexed header field.
+			var i uint64
+			if i, p, err = readHPACKInt(p, 7); err != nil {
+				return nil, err
+			}
+			if f, err = d.at(i); err != nil {
+				return nil, err
+			}
+		case b&0xc0 == 0x40:
+			// Literal header field with incremental indexing.
+			if f, p, err = d.literal(p, 6); err != nil {
+				return nil, err
+			}
+			d.add(f)
+		case b&0xe0 == 0x20:
+			// Dynamic table size update.
+			var size uint64
+			if size, p, err = readHPACKInt(p, 5); err != nil {
+				return nil, err
+			}
+			if size > 4096 {
+				return nil, errHPACK
+			}
+			d.maxSize = int(size)
+			d.evict()
+			continue
+		default:
+			// Literal header field without indexing or never indexed.
+			if f, p, err = d.literal(p, 4); err != nil {
+				return nil, err
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// literal reads a literal header field with an index prefix of n bits.
+func (d *hpackDecoder) literal(p []byte, n uint) (hpackField, []byte, error) {
+	i, p, err := readHPACKInt(p, n)
+	if err != nil {
+		return hpackField{}, nil, err
+	}
+
+	var f hpackField
+	if i == 0 {
+		if f.name, p, err = readHPACKString(p); err != nil {
+			return hpackField{}, nil, err
+		}
+	} else {
+		indexed, err := d.at(i)
+		if err != nil {
+			return hpackField{}, nil, err
+		}
+		f.name = indexed.name
+	}
+	if f.value, p, err = readHPACKString(p); err != nil {
+		return hpackField{}, nil, err
+	}
+	return f, p, nil
+}
+
+// at returns the field at an index of the static and dynamic tables.
+func (d *hpackDecoder) at(i uint64) (hpackField, error) {
+	switch {
+	case i == 0:
+		return hpackField{}, errHPACK
+	case i <= uint64(len(hpackStaticTable)):
+		return hpackStaticTable[i-1], nil
+	case i-uint64(len(hpackStaticTable)) <= uint64(len(d.dynamic)):
+		return d.dynamic[i-uint64(len(hpackStaticTable))-1], nil
+	}
+	return hpackField{}, errHPACK
+}
+
+// add inserts a field into the dynamic table.
+func (d *hpackDecoder) add(f hpackField) {
+	d.dynamic = append([]hpackField{f}, d.dynamic...)
+	d.size += hpackFieldSize(f)
+	d.evict()
+}
+
+// evict removes the oldest fields until the dynamic table fits.
+func (d *hpackDecoder) evict() {
+	for d.size > d.maxSize && len(d.dynamic) > 0 {
+		last := len(d.dynamic) - 1
+		d.size -= hpackFieldSize(d.dynamic[last])
+		d.dynamic = d.dynamic[:last]
+	}
+}
+
+// hpackFieldSize returns the size of a field in the dynamic table.
+func hpackFieldSize(f hpackField) int {
+	return len(f.name) + len(f.value) + 32
+}
+
+// readHPACKInt reads an integer with a prefix of n bits.
+func readHPACKInt(p []byte, n uint) (uint64, []byte, error) {
+	if len(p) == 0 {
+		return 0, nil, errHPACK
+	}
+
+	max := uint64(1)<<n - 1
+	i := uint64(p[0]) & max
+	p = p[1:]
+	if i < max {
+		return i, p, nil
+	}
+
+	for m := uint(0); len(p) > 0; m += 7 {
+		if m > 56 {
+			return 0, nil, errHPACK
+		}
+		b := p[0]
+		p = p[1:]
+		i += uint64(b&0x7f) << m
+		if b&0x80 == 0 {
+			return i, p, nil
+		}
+	}
+	return 0, nil, errHPACK
+}
+
+// readHPACKString reads a string literal, which may be Huffman encoded.
+func readHPACKString(p []byte) (string, []byte, error) {
+	if len(p) == 0 {
+		return "", nil, errHPACK
+	}
+
+	huffman := p[0]&0x80 != 0
+	n, p, err := readHPACKInt(p, 7)
+	if err != nil {
+		return "", nil, err
+	}
+	if n > uint64(len(p)) {
+		return "", nil, errHPACK
+	}
+
+	s := p[:n]
+	p = p[n:]
+	if !huffman {
+		return string(s), p, nil
+	}
+
+	decoded, err := huffmanDecode(s)
+	if err != nil {
+		return "", nil, err
+	}
+	return decoded, p, nil
+}
+
+// huffmanNode is a node of the Huffman decoding tree.
+type huffmanNode struct {
+	children [2]*huffmanNode
+	sym      byte
+	leaf     bool
+}
+
+var (
+	huffmanOnce sync.Once
+	huffmanRoot *huffmanNode
+)
+
+// huffmanTree returns the Huffman decoding tree.
+func huffmanTree() *huffmanNode {
+	huffmanOnce.Do(func() {
+		huffmanRoot = &huffmanNode{}
+		for sym, code := range huffmanCodes {
+			n := huffmanRoot
+			for i := int(huffmanCodeLen[sym]) - 1; i >= 0; i-- {
+				bit := code >> uint(i) & 1
+				if n.children[bit] == nil {
+					n.children[bit] = &huffmanNode{}
+				}
+				n = n.children[bit]
+			}
+			n.sym = byte(sym)
+			n.leaf = true
+		}
+	})
+	return huffmanRoot
+}
+
+// huffmanDecode decodes a Huffman encoded string. The padding must be the
+// most significant bits of the EOS code and shorter than a byte.
+func huffmanDecode(p []byte) (string, error) {
+	root := huffmanTree()
+	out := make([]byte, 0, len(p)*8/5)
+
+	n := root
+	depth, ones := 0, true
+	for _, b := range p {
+		for i := 7; i >= 0; i-- {
+			bit := b >> uint(i) & 1
+			if n = n.children[bit]; n == nil {
+				return "", errHPACK
+			}
+			depth++
+			ones = ones && bit == 1
+			if n.leaf {
+				out = append(out, n.sym)
+				n = root
+				depth, ones = 0, true
+			}
+		}
+	}
+	if depth > 7 || !ones {
+		return "", errHPACK
+	}
+	return string(out), nil
+}
+
+// huffmanCodes and huffmanCodeLen are the Huffman code of each byte from
+// RFC 7541 appendix B.
+var huffmanCodes = [256]uint32{
+	0x1ff8, 0x7fffd8, 0xfffffe2, 0xfffffe3, 0xfffffe4, 0xfffffe5,
+	0xfffffe6, 0xfffffe7, 0xfffffe8, 0xffffea, 0x3ffffffc, 0xfffffe9,
+	0xfffffea, 0x3ffffffd, 0xfffffeb, 0xfffffec, 0xfffffed, 0xfffffee,
+	0xfffffef, 0xffffff0, 0xffffff1, 0xffffff2, 0x3ffffffe, 0xffffff3,
+	0xffffff4, 0xffffff5, 0xffffff6, 0xffffff7, 0xffffff8, 0xffffff9,
+	0xffffffa, 0xffffffb, 0x14, 0x3f8, 0x3f9, 0xffa,
+	0x1ff9, 0x15, 0xf8, 0x7fa, 0x3fa, 0x3fb,
+	0xf9, 0x7fb, 0xfa, 0x16, 0x17, 0x18,
+	0x0, 0x1, 0x2, 0x19, 0x1a, 0x1b,
+	0x1c, 0x1d, 0x1e, 0x1f, 0x5c, 0xfb,
+	0x7ffc, 0x20, 0xffb, 0x3fc, 0x1ffa, 0x21,
+	0x5d, 0x5e, 0x5f, 0x60, 0x61, 0x62,
+	0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+	0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e,
+	0x6f, 0x70, 0x71, 0x72, 0xfc, 0x73,
+	0xfd, 0x1ffb, 0x7fff0, 0x1ffc, 0x3ffc, 0x22,
+	0x7ffd, 0x3, 0x23, 0x4, 0x24, 0x5,
+	0x25, 0x26, 0x27, 0x6, 0x74, 0x75,
+	0x28, 0x29, 0x2a, 0x7, 0x2b, 0x76,
+	0x2c, 0x8, 0x9, 0x2d, 0x77, 0x78,
+	0x79, 0x7a, 0x7b, 0x7ffe, 0x7fc, 0x3ffd,
+	0x1ffd, 0xffffffc, 0xfffe6, 0x3fffd2, 0xfffe7, 0xfffe8,
+	0x3fffd3, 0x3fffd4, 0x3fffd5, 0x7fffd9, 0x3fffd6, 0x7fffda,
+	0x7fffdb, 0x7fffdc, 0x7fffdd, 0x7fffde, 0xffffeb, 0x7fffdf,
+	0xffffec, 0xffffed, 0x3fffd7, 0x7fffe0, 0xffffee, 0x7fffe1,
+	0x7fffe2, 0x7fffe3, 0x7fffe4, 0x1fffdc, 0x3fffd8, 0x7fffe5,
+	0x3fffd9, 0x7fffe6, 0x7fffe7, 0xffffef, 0x3fffda, 0x1fffdd,
+	0xfffe9, 0x3fffdb, 0x3fffdc, 0x7fffe8, 0x7fffe9, 0x1fffde,
+	0x7fffea, 0x3fffdd, 0x3fffde, 0xfffff0, 0x1fffdf, 0x3fffdf,
+	0x7fffeb, 0x7fffec, 0x1fffe0, 0x1fffe1, 0x3fffe0, 0x1fffe2,
+	0x7fffed, 0x3fffe1, 0x7fffee, 0x7fffef, 0xfffea, 0x3fffe2,
+	0x3fffe3, 0x3fffe4, 0x7ffff0, 0x3fffe5, 0x3fffe6, 0x7ffff1,
+	0x3ffffe0, 0x3ffffe1, 0xfffeb, 0x7fff1, 0x3fffe7, 0x7ffff2,
+	0x3fffe8, 0x1ffffec, 0x3ffffe2, 0x3ffffe3, 0x3ffffe4, 0x7ffffde,
+	0x7ffffdf, 0x3ffffe5, 0xfffff1, 0x1ffffed, 0x7fff2, 0x1fffe3,
+	0x3ffffe6, 0x7ffffe0, 0x7ffffe1, 0x3ffffe7, 0x7ffffe2, 0xfffff2,
+	0x1fffe4, 0x1fffe5, 0x3ffffe8, 0x3ffffe9, 0xffffffd, 0x7ffffe3,
+	0x7ffffe4, 0x7ffffe5, 0xfffec, 0xfffff3, 0xfffed, 0x1fffe6,
+	0x3fffe9, 0x1fffe7, 0x1fffe8, 0x7ffff3, 0x3fffea, 0x3fffeb,
+	0x1ffffee, 0x1ffffef, 0xfffff4, 0xfffff5, 0x3ffffea, 0x7ffff4,
+	0x3ffffeb, 0x7ffffe6, 0x3ffffec, 0x3ffffed, 0x7ffffe7, 0x7ffffe8,
+	0x7ffffe9, 0x7ffffea, 0x7ffffeb, 0xffffffe, 0x7ffffec, 0x7ffffed,
+	0x7ffffee, 0x7ffffef, 0x7fffff0, 0x3ffffee,
+}
+
+var huffmanCodeLen = [256]uint8{
+	13, 23, 28, 28, 28, 28, 28, 28, 28, 24, 30, 28, 28, 30, 28, 28,
+	28, 28, 28, 28, 28, 28, 30, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+	6, 10, 10, 12, 13, 6, 8, 11, 10, 10, 8, 11, 8, 6, 6, 6,
+	5, 5, 5, 6, 6, 6, 6, 6, 6, 6, 7, 8, 15, 6, 12, 10,
+	13, 6, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7,
+	7, 7, 7, 7, 7, 7, 7, 7, 8, 7, 8, 13, 19, 13, 14, 6,
+	15, 5, 6, 5, 6, 5, 6, 6, 6, 5, 7, 7, 6, 6, 6, 5,
+	6, 7, 6, 5, 5, 6, 7, 7, 7, 7, 7, 15, 11, 14, 13, 28,
+	20, 22, 20, 20, 22, 22, 22, 23, 22, 23, 23, 23, 23, 23, 24, 23,
+	24, 24, 22, 23, 24, 23, 23, 23, 23, 21, 22, 23, 22, 23, 23, 24,
+	22, 21, 20, 22, 22, 23, 23, 21, 23, 22, 22, 24, 21, 22, 23, 23,
+	21, 21, 22, 21, 23, 22, 23, 23, 20, 22, 22, 22, 23, 22, 22, 23,
+	26, 26, 20, 19, 22, 23, 22, 25, 26, 26, 26, 27, 27, 26, 24, 25,
+	19, 21, 26, 27, 27, 26, 27, 24, 21, 21, 26, 26, 28, 27, 27, 27,
+	20, 24, 20, 21, 22, 21, 21, 23, 22, 22, 25, 25, 24, 24, 26, 23,
+	26, 27, 26, 26, 27, 27, 27, 27, 27, 28, 27, 27, 27, 27, 27, 26,
+}
+
+// hpackStaticTable is the static table from RFC 7541 appendix A.
+var hpackStaticTable = [...]hpackField{
+	{":authority", ""},
+	{":method", "GET"},
+	{":method", "POST"},
+	{":path", "/"},
+	{":path", "/index.html"},
+	{":scheme", "http"},
+	{":scheme", "https"},
+	{":status", "200"},
+	{":status", "204"},
+	{":status", "206"},
+	{":status", "304"},
+	{":status", "400"},
+	{":status", "404"},
+	{":status", "500"},
+	{"accept-charset", ""},
+	{"accept-encoding", "gzip, deflate"},
+	{"accept-language", ""},
+	{"accept-ranges", ""},
+	{"accept", ""},
+	{"access-control-allow-origin", ""},
+	{"age", ""},
+	{"allow", ""},
+	{"authorization", ""},
+	{"cache-control", ""},
+	{"content-disposition", ""},
+	{"content-encoding", ""},
+	{"content-language", ""},
+	{"content-length", ""},
+	{"content-location", ""},
+	{"content-range", ""},
+	{"content-type", ""},
+	{"cookie", ""},
+	{"date", ""},
+	{"etag", ""},
+	{"expect", ""},
+	{"expires", ""},
+	{"from", ""},
+	{"host", ""},
+	{"if-match", ""},
+	{"if-modified-since", ""},
+	{"if-none-match", ""},
+	{"if-range", ""},
+	{"if-unmodified-since", ""},
+	{"last-modified", ""},
+	{"link", ""},
+	{"location", ""},
+	{"max-forwards", ""},
+	{"proxy-authenticate", ""},
+	{"proxy-authorization", ""},
+	{"range", ""},
+	{"referer", ""},
+	{"refresh", ""},
+	{"retry-after", ""},
+	{"server", ""},
+	{"set-cookie", ""},
+	{"strict-transport-security", ""},
+	{"transfer-encoding", ""},
+	{"user-agent", ""},
+	{"vary", ""},
+	{"via", ""},
+	{"www-authenticate", ""},
+}
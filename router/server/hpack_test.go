@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHPACKDecode(t *testing.T) {
+	// The requests with Huffman encoding from RFC 7541 appendix C.4, which
+	// share a dynamic table.
+	d := newHPACKDecoder()
+	for _, tc := range []struct {
+		block  string
+		fields []hpackField
+	}{
+		{"8286 8441 8cf1 e3c2 e5f2 3a6b a0ab 90f4 ff", []hpackField{
+			{":method", "GET"}, {":scheme", "http"}, {":path", "/"}, {":authority", "www.example.com"},
+		}},
+		{"8286 84be 5886 a8eb 1064 9cbf", []hpackField{
+			{":method", "GET"}, {":scheme", "http"}, {":path", "/"}, {":authority", "www.example.com"},
+			{"cache-control", "no-cache"},
+		}},
+		{"8287 85bf 4088 25a8 49e9 5ba9 7d7f 8925 a849 e95b b8e8 b4bf", []hpackField{
+			{":method", "GET"}, {":scheme", "https"}, {":path", "/index.html"}, {":authority", "www.example.com"},
+			{"custom-key", "custom-value"},
+		}},
+	} {
+		b, err := hex.DecodeString(strings.ReplaceAll(tc.block, " ", ""))
+		assert.NoError(t, err)
+		fields, err := d.decode(b)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.fields, fields)
+	}
+	assert.Equal(t, 164, d.size)
+
+	for _, block := range []string{
+		"80",       // Index zero.
+		"ff00",     // Index past the tables.
+		"0f",       // Truncated integer.
+		"0085f1e3", // Truncated string.
+		"008100",   // Huffman padding that isn't all ones.
+		"3fe21f",   // Table size larger than the default.
+	} {
+		b, _ := hex.DecodeString(block)
+		_, err := newHPACKDecoder().decode(b)
+		assert.Equal(t, errHPACK, err, block)
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+)
+
+// HTTP3Server is the part of an HTTP/3 server used by Server. By default this
+// package doesn't depend on a QUIC implementation. Build with the quic tag to
+// use EnableHTTP3, or set the http3.Server of github.com/quic-go/quic-go,
+// which has these methods, so HTTP/3 can be enabled without changing
+// handlers:
+//
+//	s := server.New(":443", mux)
+//	s.HTTP.TLSConfig = tlsConfig
+//	s.HTTP3 = &http3.Server{Addr: ":443", Handler: mux, TLSConfig: tlsConfig}
+//
+// ListenAndServe should return http.ErrServerClosed after Close.
+type HTTP3Server interface {
+	ListenAndServe() error
+	SetQUICHeaders(h http.Header) error
+	Close() error
+}
+
+// AltSvc returns middleware that advertises the HTTP/3 server with the
+// Alt-Svc header on each response. The header is skipped if the server can't
+// provide it, such as before it is listening.
+func AltSvc(s HTTP3Server) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := make(http.Header)
+			if err := s.SetQUICHeaders(h); err == nil {
+				for k, v := range h {
+					w.Header()[k] = v
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
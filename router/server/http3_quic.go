@@ -0,0 +1,25 @@
+//go:build quic
+
+package server
+
+import (
+	"github.com/quic-go/quic-go/http3"
+)
+
+// EnableHTTP3 sets HTTP3 to a quic-go server on the UDP port of the same
+// address, serving the handler of HTTP with its TLS config, which must be
+// set. It is only available when building with the quic tag, which requires
+// github.com/quic-go/quic-go in the go.mod of the app:
+//
+//	s := server.New(":443", mux)
+//	s.HTTP.TLSConfig = tlsConfig
+//	s.EnableHTTP3()
+//
+// Call it after setting the handler and TLS config of HTTP.
+func (s *Server) EnableHTTP3() {
+	s.HTTP3 = &http3.Server{
+		Addr:      s.HTTP.Addr,
+		Handler:   s.HTTP.Handler,
+		TLSConfig: s.HTTP.TLSConfig,
+	}
+}
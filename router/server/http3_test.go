@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testHTTP3 records calls made by Server.
+type testHTTP3 struct {
+	started chan struct{}
+	err     error
+	// headerErr is returned by SetQUICHeaders after setting a partial
+	// header.
+	headerErr error
+	closed    bool
+}
+
+func (s *testHTTP3) ListenAndServe() error {
+	close(s.started)
+	return s.err
+}
+
+func (s *testHTTP3) SetQUICHeaders(h http.Header) error {
+	if s.headerErr != nil {
+		h.Set("Alt-Svc", `h3=":0"`)
+		return s.headerErr
+	}
+	h.Set("Alt-Svc", `h3=":443"; ma=2592000`)
+	return nil
+}
+
+func (s *testHTTP3) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestAltSvc(t *testing.T) {
+	h := AltSvc(&testHTTP3{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, `h3=":443"; ma=2592000`, w.Header().Get("Alt-Svc"))
+
+	h = AltSvc(&testHTTP3{headerErr: errors.New("no port")})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Empty(t, w.Header().Values("Alt-Svc"))
+}
+
+func TestServerHTTP3(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	l.Close()
+
+	h3 := &testHTTP3{started: make(chan struct{})}
+	s := New(l.Addr().String(), http.NotFoundHandler())
+	s.HTTP3 = h3
+	s.GRPC = &testGRPC{}
+	s.HTTP.TLSConfig = &tls.Config{}
+	assert.Equal(t, ErrGRPCWithTLS, s.ListenAndServe())
+
+	s.GRPC = nil
+	s.HTTP.TLSConfig = nil
+	done := make(chan error)
+	go func() { done <- s.ListenAndServe() }()
+	select {
+	case <-h3.started:
+	case err := <-done:
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assert.Equal(t, http.ErrServerClosed, <-done)
+	assert.True(t, h3.closed)
+}
+
+func TestServerHTTP3Error(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	bindErr := errors.New("listen udp: address already in use")
+	h3 := &testHTTP3{started: make(chan struct{}), err: bindErr}
+	s := New("", http.NotFoundHandler())
+	s.HTTP3 = h3
+	assert.Equal(t, bindErr, s.Serve(l))
+	assert.True(t, h3.closed)
+
+	_, err = l.Accept()
+	assert.Error(t, err)
+}
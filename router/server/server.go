@@ -0,0 +1,180 @@
+// Package server runs a router over HTTP and, optionally, gRPC on the same
+// port.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrGRPCWithTLS is returned by Serve when GRPC is set with a TLS config.
+// Over TLS, serve gRPC with GRPCHandler instead.
+var ErrGRPCWithTLS = errors.New("server: GRPC can't be used with TLS, use GRPCHandler")
+
+// GRPCServer is the part of a grpc.Server used to serve gRPC connections.
+type GRPCServer interface {
+	Serve(l net.Listener) error
+	GracefulStop()
+}
+
+// Server serves HTTP requests and, when GRPC is set, gRPC connections on one
+// listener.
+type Server struct {
+	// HTTP serves the HTTP connections. Its Handler is usually a router.Mux.
+	HTTP *http.Server
+	// GRPC serves the cleartext HTTP/2 connections of gRPC clients, matched
+	// by the content type of their first request. Other connections,
+	// including h2c connections of other clients, are served by HTTP.
+	GRPC GRPCServer
+	// HTTP3 serves HTTP/3 alongside the TCP listener, which then
+	// advertises it with the Alt-Svc header. Set it with EnableHTTP3 when
+	// building with the quic tag, or see HTTP3Server. If it fails, such as
+	// when the UDP port can't be bound, Serve returns its error.
+	HTTP3 HTTP3Server
+
+	wrapped bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	mux      *ConnMux
+}
+
+// New returns a Server for the address and handler.
+func New(addr string, handler http.Handler) *Server {
+	return &Server{
+		HTTP: &http.Server{
+			Addr:    addr,
+			Handler: handler,
+		},
+	}
+}
+
+// ListenAndServe listens on the TCP address and calls Serve. A listener on
+// the same port passed by Handoff is used instead of listening again.
+func (s *Server) ListenAndServe() error {
+	addr := s.HTTP.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	if l := takeInherited(addr); l != nil {
+		return s.Serve(l)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve serves the listener until Shutdown is called or an error occurs.
+// Serve returns http.ErrServerClosed after Shutdown.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	if s.HTTP.TLSConfig != nil && s.GRPC != nil {
+		l.Close()
+		return ErrGRPCWithTLS
+	}
+
+	errs := make(chan error, 4)
+	if s.HTTP3 != nil {
+		if !s.wrapped {
+			s.HTTP.Handler = AltSvc(s.HTTP3)(s.HTTP.Handler)
+			s.wrapped = true
+		}
+		go func() {
+			if err := s.HTTP3.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errs <- err
+			}
+		}()
+	}
+
+	switch {
+	case s.HTTP.TLSConfig != nil:
+		go func() { errs <- s.HTTP.ServeTLS(l, "", "") }()
+	case s.GRPC == nil:
+		go func() { errs <- s.HTTP.Serve(l) }()
+	default:
+		mux := NewConnMux(l)
+		grpcL := mux.Match(GRPC())
+		httpL := mux.Match(Any())
+		s.mu.Lock()
+		s.mux = mux
+		s.mu.Unlock()
+
+		go func() { errs <- s.GRPC.Serve(grpcL) }()
+		go func() { errs <- s.HTTP.Serve(httpL) }()
+		go func() { errs <- mux.Serve() }()
+	}
+
+	// Close the listeners so the servers that are still running return too.
+	err := <-errs
+	l.Close()
+	s.mu.Lock()
+	if s.mux != nil {
+		s.mux.Close()
+	}
+	s.mu.Unlock()
+
+	if err == nil || err == ErrListenerClosed {
+		return http.ErrServerClosed
+	}
+	if s.HTTP3 != nil && err != http.ErrServerClosed {
+		s.HTTP3.Close()
+	}
+	return err
+}
+
+// Shutdown gracefully stops the HTTP and gRPC servers and closes the HTTP/3
+// server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.HTTP.Shutdown(ctx)
+	if s.GRPC != nil {
+		s.GRPC.GracefulStop()
+	}
+	if s.HTTP3 != nil {
+		s.HTTP3.Close()
+	}
+	s.mu.Lock()
+	if s.mux != nil {
+		s.mux.Close()
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// GRPCHandler sends HTTP/2 requests with a gRPC content type to grpcHandler
+// and all other requests to h. Use it when HTTP/2 is negotiated over TLS and
+// the grpc.Server is used as an http.Handler.
+func GRPCHandler(grpcHandler http.Handler, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcHandler.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ClientAuth sets the certificate authorities used to verify client
+// certificates during the TLS handshake. Use tls.VerifyClientCertIfGiven
+// when only some routes require a certificate and enforce it per route with
+// the router's ClientCerts middleware.
+func (s *Server) ClientAuth(clientCAs *x509.CertPool, auth tls.ClientAuthType) {
+	if s.HTTP.TLSConfig == nil {
+		s.HTTP.TLSConfig = &tls.Config{}
+	}
+	s.HTTP.TLSConfig.ClientCAs = clientCAs
+	s.HTTP.TLSConfig.ClientAuth = auth
+}
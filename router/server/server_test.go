@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testGRPC answers each connection with the bytes it received.
+type testGRPC struct {
+	l net.Listener
+}
+
+func (s *testGRPC) Serve(l net.Listener) error {
+	s.l = l
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer c.Close()
+			b := make([]byte, len(http2Preface))
+			if _, err := io.ReadFull(c, b); err == nil {
+				c.Write(b)
+			}
+		}()
+	}
+}
+
+func (s *testGRPC) GracefulStop() {
+	if s.l != nil {
+		s.l.Close()
+	}
+}
+
+func TestServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	s := New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http"))
+	}))
+	s.GRPC = &testGRPC{}
+
+	done := make(chan error)
+	go func() { done <- s.Serve(l) }()
+
+	resp, err := http.Get("http://" + l.Addr().String() + "/")
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "http", string(b))
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	_, err = c.Write(concat(http2Preface, http2Frame(frameHeaders, flagEndHeaders, headerBlock("application/grpc"))))
+	assert.NoError(t, err)
+	b, err = ioutil.ReadAll(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http2Preface, b)
+	c.Close()
+
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assert.Equal(t, http.ErrServerClosed, <-done)
+}
+
+// failingGRPC fails to serve.
+type failingGRPC struct{}
+
+func (failingGRPC) Serve(l net.Listener) error { return errors.New("grpc failed") }
+func (failingGRPC) GracefulStop()              {}
+
+func TestServerClosesListeners(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	s := New("", http.NotFoundHandler())
+	s.GRPC = failingGRPC{}
+	assert.EqualError(t, s.Serve(l), "grpc failed")
+
+	_, err = net.Dial("tcp", l.Addr().String())
+	assert.Error(t, err)
+	assert.NoError(t, s.Shutdown(context.Background()))
+}
+
+func TestGRPCHandler(t *testing.T) {
+	h := GRPCHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("grpc"))
+	}), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http"))
+	}))
+
+	r := httptest.NewRequest("POST", "/pkg.Service/Method", nil)
+	r.Header.Set("Content-Type", "application/grpc+proto")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, "http", w.Body.String())
+
+	r.ProtoMajor = 2
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, "grpc", w.Body.String())
+}
+
+func TestClientAuth(t *testing.T) {
+	s := New("", http.NotFoundHandler())
+	pool := x509.NewCertPool()
+	s.ClientAuth(pool, tls.VerifyClientCertIfGiven)
+	assert.Equal(t, pool, s.HTTP.TLSConfig.ClientCAs)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, s.HTTP.TLSConfig.ClientAuth)
+}
@@ -0,0 +1,235 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// sessionKey is the context key for the session.
+type sessionKey struct{}
+
+// SessionData contains the values of a client session.
+type SessionData struct {
+	mu        sync.Mutex
+	values    map[string]string
+	modified  bool
+	destroyed bool
+}
+
+// NewSessionData returns session data with the values. It is used by session
+// stores when loading a session.
+func NewSessionData(values map[string]string) *SessionData {
+	if values == nil {
+		values = make(map[string]string)
+	}
+	return &SessionData{values: values}
+}
+
+// Get returns a value from the session.
+func (s *SessionData) Get(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key]
+}
+
+// Set stores a value in the session.
+func (s *SessionData) Set(key string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.modified = true
+	s.destroyed = false
+}
+
+// Delete removes a value from the session.
+func (s *SessionData) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.modified = true
+	}
+}
+
+// Destroy removes all values and ends the session.
+func (s *SessionData) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = make(map[string]string)
+	s.modified = true
+	s.destroyed = true
+}
+
+// Values returns a copy of the session values.
+func (s *SessionData) Values() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		out[k] = v
+	}
+	return out
+}
+
+// Modified returns true if the session was changed.
+func (s *SessionData) Modified() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modified
+}
+
+// Destroyed returns true if the session was ended with Destroy.
+func (s *SessionData) Destroyed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.destroyed
+}
+
+// SessionStore loads and saves client sessions.
+type SessionStore interface {
+	// Load returns the session for the request or a new empty session.
+	Load(r *http.Request) (*SessionData, error)
+	// Save stores a session that was modified or removes a session that was
+	// destroyed.
+	Save(w http.ResponseWriter, r *http.Request, s *SessionData) error
+}
+
+// Session returns the session loaded by the Sessions middleware or nil.
+func Session(r *http.Request) *SessionData {
+	s, _ := r.Context().Value(sessionKey{}).(*SessionData)
+	return s
+}
+
+// Sessions returns middleware for Use that loads the session before the
+// handler runs and saves it, if modified, before the response is written.
+// If the session can't be saved, the error is passed to the ServeHTTP
+// function as a 500 StatusError and the rest of the response is discarded.
+func (m *Mux) Sessions(store SessionStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := store.Load(r)
+			if err != nil {
+				m.serveError(w, r, StatusError{Code: http.StatusInternalServerError, Err: err})
+				return
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), sessionKey{}, s))
+			sw := &sessionWriter{ResponseWriter: w, save: func() bool {
+				if !s.Modified() {
+					return true
+				}
+				if err := store.Save(w, r, s); err != nil {
+					m.serveError(w, r, StatusError{Code: http.StatusInternalServerError, Err: err})
+					return false
+				}
+				return true
+			}}
+			next.ServeHTTP(sw, r)
+			sw.saveOnce()
+		})
+	}
+}
+
+// sessionWriter saves the session before the response headers are written.
+type sessionWriter struct {
+	http.ResponseWriter
+	save   func() bool
+	saved  bool
+	failed bool
+}
+
+// saveOnce saves the session the first time it is called and returns false
+// if saving failed and the error response was written.
+func (w *sessionWriter) saveOnce() bool {
+	if !w.saved {
+		w.saved = true
+		w.failed = !w.save()
+	}
+	return !w.failed
+}
+
+func (w *sessionWriter) WriteHeader(status int) {
+	if informational(status) {
+		if !w.failed {
+			w.ResponseWriter.WriteHeader(status)
+		}
+		return
+	}
+	if w.saveOnce() {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	if !w.saveOnce() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer.
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// CookieSessionStore saves sessions in an encrypted cookie.
+type CookieSessionStore struct {
+	cookies *Cookies
+	// Name is the cookie name. Defaults to session.
+	Name string
+	// MaxAge is the cookie lifetime in seconds. Zero creates a browser
+	// session cookie.
+	MaxAge int
+	// Secure only sends the cookie over HTTPS.
+	Secure bool
+}
+
+// NewCookieSessionStore returns a session store that saves sessions in an
+// encrypted cookie.
+func NewCookieSessionStore(cookies *Cookies) *CookieSessionStore {
+	return &CookieSessionStore{
+		cookies: cookies,
+		Name:    "session",
+	}
+}
+
+// Load returns the session for the request or a new empty session.
+func (cs *CookieSessionStore) Load(r *http.Request) (*SessionData, error) {
+	value, err := cs.cookies.GetEncrypted(r, cs.Name)
+	if err != nil {
+		return NewSessionData(nil), nil
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return NewSessionData(nil), nil
+	}
+
+	return NewSessionData(values), nil
+}
+
+// Save stores a session in the cookie or removes the cookie if the session
+// was destroyed.
+func (cs *CookieSessionStore) Save(w http.ResponseWriter, r *http.Request, s *SessionData) error {
+	if s.Destroyed() {
+		cs.cookies.Delete(w, cs.Name, "/")
+		return nil
+	}
+
+	b, err := json.Marshal(s.Values())
+	if err != nil {
+		return err
+	}
+
+	return cs.cookies.SetEncrypted(w, &http.Cookie{
+		Name:     cs.Name,
+		Value:    string(b),
+		Path:     "/",
+		MaxAge:   cs.MaxAge,
+		Secure:   cs.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.Sessions(NewCookieSessionStore(NewCookies([]byte("secret")))))
+
+	mux.Post("/login", func(w http.ResponseWriter, r *http.Request) error {
+		Session(r).Set("user", "john")
+		w.Write([]byte("logged in"))
+		return nil
+	})
+	mux.Get("/me", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte(Session(r).Get("user")))
+		return nil
+	})
+	mux.Post("/logout", func(w http.ResponseWriter, r *http.Request) error {
+		Session(r).Destroy()
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+	assert.Equal(t, "logged in", w.Body.String())
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+
+	r := httptest.NewRequest("GET", "/me", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, "john", w.Body.String())
+	// Session wasn't modified so it isn't saved again.
+	assert.Len(t, w.Result().Cookies(), 0)
+
+	r = httptest.NewRequest("POST", "/logout", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, -1, w.Result().Cookies()[0].MaxAge)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/me", nil))
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestSessionsSaveError(t *testing.T) {
+	mux := New()
+	var served error
+	mux.SetServeHTTP(func(w http.ResponseWriter, r *http.Request, err error) {
+		if err != nil {
+			served = err
+		}
+		defaultServeHTTP(w, r, err)
+	})
+	mux.Use(mux.Sessions(NewCookieSessionStore(NewCookies([]byte("secret")))))
+
+	mux.Post("/login", func(w http.ResponseWriter, r *http.Request) error {
+		Session(r).Set("user", strings.Repeat("a", maxCookieSize))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("logged in"))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "logged in")
+	assert.Empty(t, w.Result().Cookies())
+	assert.ErrorIs(t, served, ErrCookieTooLarge)
+}
+
+func TestSessionData(t *testing.T) {
+	s := NewSessionData(map[string]string{"a": "1"})
+	assert.False(t, s.Modified())
+
+	s.Delete("missing")
+	assert.False(t, s.Modified())
+
+	s.Delete("a")
+	assert.True(t, s.Modified())
+	assert.Equal(t, map[string]string{}, s.Values())
+
+	s.Destroy()
+	assert.True(t, s.Destroyed())
+	s.Set("b", "2")
+	assert.False(t, s.Destroyed())
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sitemapKey is the route value key for sitemap options.
+type sitemapKey struct{}
+
+// SitemapOptions marks a GET route as public and describes how it appears in
+// the sitemap.
+type SitemapOptions struct {
+	// LastMod is the time the page was last modified. Optional.
+	LastMod time.Time
+	// ChangeFreq is how often the page changes, e.g. daily. Optional.
+	ChangeFreq string
+	// Priority of the page from 0.0 to 1.0. Optional.
+	Priority float64
+	// Expand returns the URLs for a route with parameters or a prefix, e.g.
+	// one URL for each post slug. Routes with parameters or a prefix are
+	// skipped if Expand is not set.
+	Expand func(r *http.Request) ([]SitemapURL, error)
+}
+
+// SitemapURL is a URL in the sitemap. A Loc that starts with a slash is
+// relative to the base URL.
+type SitemapURL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+// Sitemap includes the route in the sitemap served by SitemapHandler.
+func (rt *Route) Sitemap(options SitemapOptions) *Route {
+	rt.route.WithValue(sitemapKey{}, options)
+	return rt
+}
+
+// SitemapHandler returns a handler that serves a sitemap.xml of the GET
+// routes marked with Sitemap. If baseURL is empty, it is derived from the
+// request.
+func (m *Mux) SitemapHandler(baseURL string) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		base := strings.TrimSuffix(baseURL, "/")
+		if base == "" {
+			scheme := "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+			base = scheme + "://" + r.Host
+		}
+
+		urls, err := m.sitemapURLs(r)
+		if err != nil {
+			return StatusError{Code: http.StatusInternalServerError, Err: err}
+		}
+
+		set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+		for _, u := range urls {
+			loc := u.Loc
+			if strings.HasPrefix(loc, "/") {
+				loc = base + loc
+			}
+
+			entry := sitemapXMLURL{Loc: loc, ChangeFreq: u.ChangeFreq}
+			if !u.LastMod.IsZero() {
+				entry.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+			}
+			if u.Priority > 0 {
+				p := u.Priority
+				entry.Priority = &p
+			}
+			set.URLs = append(set.URLs, entry)
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		if _, err := w.Write([]byte(xml.Header)); err != nil {
+			return err
+		}
+		return xml.NewEncoder(w).Encode(set)
+	}
+}
+
+// sitemapURLs returns the URLs of the routes marked with Sitemap.
+func (m *Mux) sitemapURLs(r *http.Request) ([]SitemapURL, error) {
+	urls := make([]SitemapURL, 0)
+	seen := make(map[string]bool)
+
+	for _, route := range m.router.Routes() {
+		if route.Method() != http.MethodGet {
+			continue
+		}
+		options, ok := route.Value(sitemapKey{}).(SitemapOptions)
+		if !ok {
+			continue
+		}
+
+		var routeURLs []SitemapURL
+		if options.Expand != nil {
+			expanded, err := options.Expand(r)
+			if err != nil {
+				return nil, err
+			}
+			routeURLs = expanded
+		} else {
+			pattern := "/" + strings.Trim(route.Pattern(), "/")
+			if strings.Contains(pattern, ":") || (route.Prefix() && pattern != "/") {
+				continue
+			}
+			routeURLs = []SitemapURL{{Loc: pattern}}
+		}
+
+		for _, u := range routeURLs {
+			if seen[u.Loc] {
+				continue
+			}
+			seen[u.Loc] = true
+
+			if u.LastMod.IsZero() {
+				u.LastMod = options.LastMod
+			}
+			if u.ChangeFreq == "" {
+				u.ChangeFreq = options.ChangeFreq
+			}
+			if u.Priority == 0 {
+				u.Priority = options.Priority
+			}
+			urls = append(urls, u)
+		}
+	}
+
+	return urls, nil
+}
+
+// sitemapURLSet is the root element of a sitemap.
+type sitemapURLSet struct {
+	XMLName xml.Name        `xml:"urlset"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	URLs    []sitemapXMLURL `xml:"url"`
+}
+
+// sitemapXMLURL is a url element of a sitemap.
+type sitemapXMLURL struct {
+	Loc        string   `xml:"loc"`
+	LastMod    string   `xml:"lastmod,omitempty"`
+	ChangeFreq string   `xml:"changefreq,omitempty"`
+	Priority   *float64 `xml:"priority,omitempty"`
+}
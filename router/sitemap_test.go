@@ -0,0 +1,55 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSitemap(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	h := func(w http.ResponseWriter, r *http.Request) error { return nil }
+	lastMod := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	mux.Get("/", h).Sitemap(SitemapOptions{Priority: 1})
+	mux.Get("/about", h).Sitemap(SitemapOptions{LastMod: lastMod, ChangeFreq: "monthly"})
+	mux.Get("/private", h)
+	mux.Post("/contact", h).Sitemap(SitemapOptions{})
+	mux.Get("/user/{id}", h).Sitemap(SitemapOptions{})
+	mux.Get("/posts/{slug}", h).Sitemap(SitemapOptions{
+		ChangeFreq: "weekly",
+		Expand: func(r *http.Request) ([]SitemapURL, error) {
+			return []SitemapURL{
+				{Loc: "/posts/hello"},
+				{Loc: "https://blog.example.com/posts/world", LastMod: lastMod},
+			}, nil
+		},
+	})
+	mux.Get("/sitemap.xml", mux.SitemapHandler("https://example.com/"))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/sitemap.xml", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+
+		`<url><loc>https://example.com/</loc><priority>1</priority></url>`+
+		`<url><loc>https://example.com/about</loc><lastmod>2021-01-02T03:04:05Z</lastmod><changefreq>monthly</changefreq></url>`+
+		`<url><loc>https://example.com/posts/hello</loc><changefreq>weekly</changefreq></url>`+
+		`<url><loc>https://blog.example.com/posts/world</loc><lastmod>2021-01-02T03:04:05Z</lastmod><changefreq>weekly</changefreq></url>`+
+		`</urlset>`, w.Body.String())
+}
+
+func TestSitemapBaseFromRequest(t *testing.T) {
+	mux := New()
+	mux.Get("/about", func(w http.ResponseWriter, r *http.Request) error { return nil }).Sitemap(SitemapOptions{})
+	mux.Get("/sitemap.xml", mux.SitemapHandler(""))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost:8080/sitemap.xml", nil))
+	assert.Contains(t, w.Body.String(), "<loc>http://localhost:8080/about</loc>")
+}
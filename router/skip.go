@@ -0,0 +1,51 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+// skipKey is the route value key for the names of skipped middleware.
+type skipKey struct{}
+
+// Unless returns middleware that runs mw unless skip returns true for the
+// request, such as for health checks or websocket upgrades.
+func Unless(mw func(http.Handler) http.Handler, skip func(r *http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Skippable returns middleware that runs mw unless the matched route skips
+// the name with Skip. Add it with Mux.Use so the route is known.
+func Skippable(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return Unless(mw, func(r *http.Request) bool {
+		route := away.CurrentRoute(r.Context())
+		if route == nil {
+			return false
+		}
+		skipped, _ := route.Value(skipKey{}).([]string)
+		for _, s := range skipped {
+			if s == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Skip excludes the route from the middleware added with Skippable under the
+// names, such as "gzip" for a websocket route.
+func (rt *Route) Skip(names ...string) *Route {
+	existing, _ := rt.route.Value(skipKey{}).([]string)
+	rt.route.WithValue(skipKey{}, append(append([]string(nil), existing...), names...))
+	return rt
+}
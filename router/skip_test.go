@@ -0,0 +1,36 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkip(t *testing.T) {
+	mux := New()
+	mux.Use(
+		Skippable("gzip", testHeader("gzip")),
+		Unless(testHeader("auth"), func(r *http.Request) bool {
+			return r.URL.Path == "/health"
+		}),
+	)
+
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	mux.Get("/", ok)
+	mux.Get("/health", ok).Skip("gzip")
+	mux.Get("/ws", ok).Skip("gzip", "other")
+
+	for path, want := range map[string][]string{
+		"/":       {"gzip", "auth"},
+		"/health": nil,
+		"/ws":     {"auth"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, want, w.Header()["X-Stack"], path)
+	}
+}
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// slowKey is the route value key for the slow request threshold of a route.
+type slowKey struct{}
+
+// SlowConfig contains the settings for the SlowRequests middleware.
+type SlowConfig struct {
+	// Logger receives the slow request entries at warn level. If nil,
+	// slog.Default is used.
+	Logger *slog.Logger
+	// Threshold is the latency of routes without SlowThreshold. Zero only
+	// watches routes with SlowThreshold.
+	Threshold time.Duration
+	// Stack adds the stack of the goroutine serving the request to the entry
+	// logged when the threshold is reached, to diagnose stuck handlers.
+	Stack bool
+}
+
+// SlowThreshold sets the latency after which requests to the route are
+// logged by the SlowRequests middleware. Zero disables it for the route.
+func (rt *Route) SlowThreshold(d time.Duration) *Route {
+	rt.route.WithValue(slowKey{}, d)
+	return rt
+}
+
+// SlowRequests returns middleware for Use that watches requests for
+// exceeding the threshold of their route. When the threshold is reached
+// while the handler is still running, an entry is logged with the method,
+// redacted URL, pattern, and params, and optionally the goroutine stack.
+// A second entry with the status and duration is logged when the request
+// completes.
+func (m *Mux) SlowRequests(config SlowConfig) func(http.Handler) http.Handler {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			threshold := config.Threshold
+			if route != nil {
+				if d, ok := route.Value(slowKey{}).(time.Duration); ok {
+					threshold = d
+				}
+			}
+			if threshold <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var id []byte
+			if config.Stack {
+				id = goroutineID()
+			}
+
+			start := time.Now()
+			attrs := func() []slog.Attr {
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("url", route.RedactURL(r.URL)),
+					slog.Duration("threshold", threshold),
+				}
+				if route != nil {
+					params := make([]any, 0)
+					for name, value := range routeParams(r, route) {
+						params = append(params, slog.String(name, value))
+					}
+					attrs = append(attrs, slog.String("pattern", route.Pattern()), slog.Group("params", params...))
+				}
+				return attrs
+			}
+
+			timer := time.AfterFunc(threshold, func() {
+				a := attrs()
+				if id != nil {
+					a = append(a, slog.String("stack", string(goroutineStack(id))))
+				}
+				logger.LogAttrs(r.Context(), slog.LevelWarn, "slow request running", a...)
+			})
+
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			if timer.Stop() {
+				return
+			}
+			logger.LogAttrs(r.Context(), slog.LevelWarn, "slow request completed",
+				append(attrs(), slog.Int("status", sw.Status()), slog.Duration("duration", time.Since(start)))...)
+		})
+	}
+}
+
+// goroutineID returns the "goroutine N " prefix of the stack of the current
+// goroutine.
+func goroutineID() []byte {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	if i := bytes.IndexByte(buf, '['); i > 0 {
+		return buf[:i]
+	}
+	return nil
+}
+
+// goroutineStack returns the stack of the goroutine with the ID prefix.
+func goroutineStack(id []byte) []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, id) {
+			return stack
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambientkit/away"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a buffer that is safe to write from the watchdog goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSlowRequests(t *testing.T) {
+	buf := new(syncBuffer)
+	mux := New()
+	mux.Use(mux.SlowRequests(SlowConfig{
+		Logger:    slog.New(slog.NewTextHandler(buf, nil)),
+		Threshold: time.Hour,
+		Stack:     true,
+	}))
+
+	release := make(chan struct{})
+	mux.Get("/fast", func(w http.ResponseWriter, r *http.Request) error { return nil })
+	mux.Get("/reports/{token}", func(w http.ResponseWriter, r *http.Request) error {
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}).SlowThreshold(10 * time.Millisecond).Redact("token")
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+	assert.Empty(t, buf.String())
+
+	done := make(chan struct{})
+	go func() {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports/secret", nil))
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "slow request running")
+	}, time.Second, 5*time.Millisecond)
+	close(release)
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	out := buf.String()
+	assert.Contains(t, lines[0], `level=WARN msg="slow request running" method=GET url=/reports/`+away.Redacted+` threshold=10ms pattern=/reports/:token params.token=`+away.Redacted)
+	assert.Contains(t, out, "TestSlowRequests")
+	assert.Contains(t, lines[len(lines)-1], `msg="slow request completed"`)
+	assert.Contains(t, lines[len(lines)-1], "status=202")
+	assert.NotContains(t, out, "secret")
+}
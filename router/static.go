@@ -0,0 +1,315 @@
+package router
+
+import (
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StaticConfig contains the settings for a static file route.
+type StaticConfig struct {
+	// Index enables a directory listing for directories that don't contain an
+	// index.html file.
+	Index bool
+	// ShowHidden includes files that start with a dot in directory listings.
+	ShowHidden bool
+	// IndexTemplate overrides the default directory listing template. It is
+	// executed with a DirListing.
+	IndexTemplate *template.Template
+	// Precompressed serves a .br or .gz sibling of a file, such as
+	// app.js.br for app.js, when it exists and the client accepts the
+	// encoding.
+	Precompressed bool
+}
+
+// precompressedEncodings are the encodings of precompressed files in order
+// of preference.
+var precompressedEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// DirListing is the data passed to the directory listing template.
+type DirListing struct {
+	Path    string
+	Crumbs  []DirCrumb
+	Entries []DirEntry
+	Sort    string
+	Order   string
+}
+
+// DirCrumb is a link to a parent directory in a directory listing.
+type DirCrumb struct {
+	Name string
+	URL  string
+}
+
+// DirEntry is a file or directory in a directory listing.
+type DirEntry struct {
+	Name    string
+	URL     string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// dirListingTemplate is the default directory listing template.
+var dirListingTemplate = template.Must(template.New("dir").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>{{range $i, $c := .Crumbs}}{{if $i}} / {{end}}<a href="{{$c.URL}}">{{$c.Name}}</a>{{end}}</h1>
+<table>
+<tr><th><a href="?sort=name">Name</a></th><th><a href="?sort=size">Size</a></th><th><a href="?sort=time">Modified</a></th></tr>
+{{range .Entries}}<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if not .IsDir}}{{.Size}}{{end}}</td><td>{{.ModTime.Format "2006-01-02 15:04"}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Static registers a GET and HEAD route that serves files from fsys under
+// the path prefix. A prefix of / serves every path.
+func (m *Mux) Static(prefix string, fsys fs.FS, config StaticConfig) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	pattern := strings.TrimSuffix(prefix, "/") + "/"
+	if prefix == "/" {
+		// The root pattern only matches the root path.
+		pattern = "/..."
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request) error {
+		return serveStatic(w, r, prefix, fsys, config)
+	}
+
+	m.Get(pattern, fn)
+	m.Head(pattern, fn)
+}
+
+// serveStatic serves a single file or directory from fsys.
+func serveStatic(w http.ResponseWriter, r *http.Request, prefix string, fsys fs.FS, config StaticConfig) error {
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, prefix)), "/")
+	if name == "" {
+		name = "."
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return StatusError{Code: http.StatusNotFound, Err: err}
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return StatusError{Code: http.StatusInternalServerError, Err: err}
+	}
+
+	if fi.IsDir() {
+		index := path.Join(name, "index.html")
+		if fi, err := fs.Stat(fsys, index); err == nil && !fi.IsDir() {
+			f.Close()
+			f, err = fsys.Open(index)
+			if err != nil {
+				return StatusError{Code: http.StatusInternalServerError, Err: err}
+			}
+			defer f.Close()
+			return serveAsset(w, r, fsys, index, f, fi, config)
+		}
+
+		if !config.Index {
+			return StatusError{Code: http.StatusNotFound, Err: nil}
+		}
+
+		return serveDir(w, r, prefix, name, fsys, config)
+	}
+
+	return serveAsset(w, r, fsys, name, f, fi, config)
+}
+
+// serveAsset serves a file or its precompressed sibling.
+func serveAsset(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, f fs.File, fi fs.FileInfo, config StaticConfig) error {
+	if !config.Precompressed {
+		return serveFile(w, r, f, fi)
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	for _, pe := range precompressedEncodings {
+		if !acceptsEncoding(r.Header.Get("Accept-Encoding"), pe.encoding) {
+			continue
+		}
+
+		cf, err := fsys.Open(name + pe.ext)
+		if err != nil {
+			continue
+		}
+		defer cf.Close()
+
+		cfi, err := cf.Stat()
+		if err != nil || cfi.IsDir() {
+			continue
+		}
+
+		if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+		w.Header().Set("Content-Encoding", pe.encoding)
+		return serveFile(w, r, cf, cfi)
+	}
+
+	return serveFile(w, r, f, fi)
+}
+
+// acceptsEncoding returns true if the Accept-Encoding header allows the
+// encoding.
+func acceptsEncoding(header string, encoding string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		if name == encoding {
+			return q > 0
+		} else if name == "*" {
+			wildcard = q > 0
+		}
+	}
+
+	return wildcard
+}
+
+// serveFile writes the contents of a file to the response.
+func serveFile(w http.ResponseWriter, r *http.Request, f fs.File, fi fs.FileInfo) error {
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, fi.Name(), fi.ModTime(), rs)
+		return nil
+	}
+
+	_, err := io.Copy(w, f)
+	return err
+}
+
+// serveDir writes a directory listing to the response.
+func serveDir(w http.ResponseWriter, r *http.Request, prefix string, name string, fsys fs.FS, config StaticConfig) error {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return StatusError{Code: http.StatusInternalServerError, Err: err}
+	}
+
+	dir := path.Join(prefix, name)
+	listing := DirListing{
+		Path:   dirURL(dir),
+		Crumbs: dirCrumbs(prefix, name),
+		Sort:   r.URL.Query().Get("sort"),
+		Order:  r.URL.Query().Get("order"),
+	}
+
+	for _, entry := range entries {
+		if !config.ShowHidden && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		de := DirEntry{
+			Name:    entry.Name(),
+			URL:     pathURL(path.Join(dir, entry.Name())),
+			IsDir:   entry.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+		if de.IsDir {
+			de.URL += "/"
+		}
+		listing.Entries = append(listing.Entries, de)
+	}
+
+	sortDirEntries(listing.Entries, listing.Sort, listing.Order == "desc")
+
+	tmpl := dirListingTemplate
+	if config.IndexTemplate != nil {
+		tmpl = config.IndexTemplate
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return tmpl.Execute(w, listing)
+}
+
+// dirCrumbs returns links to each parent directory of name.
+func dirCrumbs(prefix string, name string) []DirCrumb {
+	crumbs := []DirCrumb{{Name: prefix, URL: pathURL(dirURL(prefix))}}
+	if name == "." {
+		return crumbs
+	}
+
+	current := prefix
+	for _, seg := range strings.Split(name, "/") {
+		current = path.Join(current, seg)
+		crumbs = append(crumbs, DirCrumb{Name: seg, URL: pathURL(dirURL(current))})
+	}
+
+	return crumbs
+}
+
+// dirURL returns the URL of a directory with a trailing slash, so the root
+// is / rather than the protocol-relative //.
+func dirURL(dir string) string {
+	if strings.HasSuffix(dir, "/") {
+		return dir
+	}
+	return dir + "/"
+}
+
+// pathURL returns a path escaped for a link, so names with characters such
+// as # or ? aren't read as the fragment or query.
+func pathURL(p string) string {
+	return (&url.URL{Path: p}).String()
+}
+
+// sortDirEntries sorts entries by name, size, or time. Directories are always
+// listed before files.
+func sortDirEntries(entries []DirEntry, by string, desc bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if desc {
+			a, b = b, a
+		}
+
+		switch by {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "time":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		}
+
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,189 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var staticFS = fstest.MapFS{
+	"hello.txt":           {Data: []byte("hello"), ModTime: time.Unix(100, 0)},
+	"big.txt":             {Data: []byte("hello world"), ModTime: time.Unix(50, 0)},
+	".secret":             {Data: []byte("secret")},
+	"docs/readme.txt":     {Data: []byte("readme")},
+	"site/index.html":     {Data: []byte("<h1>site</h1>")},
+	"docs/sub/nested.txt": {Data: []byte("nested")},
+}
+
+func staticRequest(mux *Mux, target string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", target, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestStaticFile(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Static("/files", staticFS, StaticConfig{})
+
+	w := staticRequest(mux, "/files/hello.txt")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+
+	w = staticRequest(mux, "/files/site/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>site</h1>", w.Body.String())
+
+	w = staticRequest(mux, "/files/docs/")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = staticRequest(mux, "/files/../router.go")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStaticRoot(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Static("/", staticFS, StaticConfig{})
+
+	w := staticRequest(mux, "/hello.txt")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+
+	w = staticRequest(mux, "/docs/sub/nested.txt")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "nested", w.Body.String())
+
+	w = staticRequest(mux, "/site/")
+	assert.Equal(t, "<h1>site</h1>", w.Body.String())
+
+	w = staticRequest(mux, "/missing.txt")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":    {Data: []byte("plain")},
+		"app.js.br": {Data: []byte("brotli")},
+		"app.js.gz": {Data: []byte("gzip")},
+		"lib.js":    {Data: []byte("lib")},
+	}
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Static("/assets", fsys, StaticConfig{Precompressed: true})
+
+	for _, tc := range []struct {
+		path     string
+		accept   string
+		body     string
+		encoding string
+	}{
+		{"/assets/app.js", "gzip, deflate, br", "brotli", "br"},
+		{"/assets/app.js", "gzip", "gzip", "gzip"},
+		{"/assets/app.js", "br;q=0, *", "gzip", "gzip"},
+		{"/assets/app.js", "", "plain", ""},
+		{"/assets/lib.js", "br", "lib", ""},
+	} {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		r.Header.Set("Accept-Encoding", tc.accept)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, tc.body, w.Body.String(), tc.accept)
+		assert.Equal(t, tc.encoding, w.Header().Get("Content-Encoding"), tc.accept)
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), tc.accept)
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript", tc.accept)
+	}
+}
+
+func TestStaticIndex(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Static("/files", staticFS, StaticConfig{Index: true})
+
+	w := staticRequest(mux, "/files/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `<a href="/files/hello.txt">hello.txt</a>`)
+	assert.Contains(t, body, `<a href="/files/docs/">docs/</a>`)
+	assert.NotContains(t, body, ".secret")
+
+	w = staticRequest(mux, "/files/docs/sub")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body = w.Body.String()
+	assert.Contains(t, body, `<a href="/files/">/files</a> / <a href="/files/docs/">docs</a> / <a href="/files/docs/sub/">sub</a>`)
+	assert.Contains(t, body, `<a href="/files/docs/sub/nested.txt">nested.txt</a>`)
+}
+
+func TestStaticIndexRoot(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Static("/", staticFS, StaticConfig{Index: true})
+
+	w := staticRequest(mux, "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `<title>Index of /</title>`)
+	assert.Contains(t, body, `<a href="/">/</a>`)
+	assert.Contains(t, body, `<a href="/hello.txt">hello.txt</a>`)
+	assert.NotContains(t, body, `//`)
+
+	w = staticRequest(mux, "/docs/sub/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<a href="/">/</a> / <a href="/docs/">docs</a> / <a href="/docs/sub/">sub</a>`)
+}
+
+func TestStaticIndexEscaped(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Static("/files", fstest.MapFS{
+		"a#b?c%.txt":   {Data: []byte("a")},
+		"my dir/x.txt": {Data: []byte("x")},
+	}, StaticConfig{Index: true})
+
+	w := staticRequest(mux, "/files/")
+	assert.Contains(t, w.Body.String(), `<a href="/files/a%23b%3Fc%25.txt">a#b?c%.txt</a>`)
+	assert.Contains(t, w.Body.String(), `<a href="/files/my%20dir/">my dir/</a>`)
+
+	w = staticRequest(mux, "/files/my%20dir/")
+	assert.Contains(t, w.Body.String(), `<a href="/files/my%20dir/">my dir</a>`)
+}
+
+func TestStaticIndexHidden(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Static("/files", staticFS, StaticConfig{Index: true, ShowHidden: true})
+
+	w := staticRequest(mux, "/files/")
+	assert.Contains(t, w.Body.String(), ".secret")
+}
+
+func TestSortDirEntries(t *testing.T) {
+	entries := []DirEntry{
+		{Name: "b", Size: 1, ModTime: time.Unix(300, 0)},
+		{Name: "a", Size: 3, ModTime: time.Unix(100, 0)},
+		{Name: "dir", IsDir: true},
+		{Name: "c", Size: 2, ModTime: time.Unix(200, 0)},
+	}
+
+	names := func() []string {
+		out := make([]string, 0)
+		for _, v := range entries {
+			out = append(out, v.Name)
+		}
+		return out
+	}
+
+	sortDirEntries(entries, "name", false)
+	assert.Equal(t, []string{"dir", "a", "b", "c"}, names())
+	sortDirEntries(entries, "size", false)
+	assert.Equal(t, []string{"dir", "b", "c", "a"}, names())
+	sortDirEntries(entries, "time", true)
+	assert.Equal(t, []string{"dir", "b", "c", "a"}, names())
+	sortDirEntries(entries, "name", true)
+	assert.Equal(t, []string{"dir", "c", "b", "a"}, names())
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// storeKey is the context key for the request store.
+type storeKey struct{}
+
+// store holds the values set with Set for a request.
+type store struct {
+	mu     sync.RWMutex
+	values map[interface{}]interface{}
+}
+
+// withStore returns the request with a store installed.
+func withStore(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(storeKey{}).(*store); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), storeKey{}, &store{}))
+}
+
+// Set stores a value for the rest of the request so middleware can pass data
+// to handlers without defining context keys. Requests served by a Mux share
+// one store, so Set returns r and the value is visible through every copy of
+// the request. Otherwise, Set returns a copy of the request with a new store.
+func Set(r *http.Request, key, value interface{}) *http.Request {
+	r = withStore(r)
+	s := r.Context().Value(storeKey{}).(*store)
+
+	s.mu.Lock()
+	if s.values == nil {
+		s.values = make(map[interface{}]interface{})
+	}
+	s.values[key] = value
+	s.mu.Unlock()
+
+	return r
+}
+
+// Get returns the value stored with Set for the key or nil.
+func Get(r *http.Request, key interface{}) interface{} {
+	s, ok := r.Context().Value(storeKey{}).(*store)
+	if !ok {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[key]
+}
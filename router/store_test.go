@@ -0,0 +1,35 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	mux := New()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r2 := Set(r, "user", "bob")
+			assert.Same(t, r, r2)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	var user interface{}
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		user = Get(r, "user")
+		return nil
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "bob", user)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Nil(t, Get(r, "user"))
+	r2 := Set(r, "user", "alice")
+	assert.NotSame(t, r, r2)
+	assert.Equal(t, "alice", Get(r2, "user"))
+	assert.Nil(t, Get(r, "user"))
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// tenantKey is the context key for the tenant ID.
+type tenantKey struct{}
+
+// TenantResolver returns the tenant ID of a request. An empty ID means the
+// request isn't for a tenant and only matches global routes.
+type TenantResolver func(r *http.Request) string
+
+// TenantFromHost resolves the tenant from the subdomain of the host, such as
+// acme for acme.example.com when the domain is example.com.
+func TenantFromHost(domain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.Trim(domain, "."))
+	return func(r *http.Request) string {
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		return strings.TrimSuffix(host, suffix)
+	}
+}
+
+// TenantFromHeader resolves the tenant from a request header.
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// TenantFromPath resolves the tenant from the first path segment. Combine it
+// with Rewrite("/{tenant}/", "/") so routes are registered without the
+// prefix.
+func TenantFromPath() TenantResolver {
+	return func(r *http.Request) string {
+		return strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+	}
+}
+
+// SetTenantResolver sets the function that resolves the tenant of each
+// request before it is routed. The tenant is available via Tenant.
+func (m *Mux) SetTenantResolver(fn TenantResolver) {
+	m.tenants = fn
+}
+
+// SetTenantNotFound sets the NotFound handler for requests of a tenant.
+// Requests of other tenants use the handler set with SetNotFound.
+func (m *Mux) SetTenantNotFound(tenant string, notFound http.Handler) {
+	d, ok := m.router.NotFound.(*tenantNotFound)
+	if !ok {
+		d = &tenantNotFound{
+			fallback: m.router.NotFound,
+			handlers: make(map[string]http.Handler),
+		}
+		m.router.NotFound = d
+	}
+	d.handlers[tenant] = notFound
+}
+
+// Tenant returns the tenant ID of the request or an empty string.
+func Tenant(r *http.Request) string {
+	t, _ := r.Context().Value(tenantKey{}).(string)
+	return t
+}
+
+// WithTenant returns a copy of the request with the tenant ID.
+func WithTenant(r *http.Request, tenant string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant))
+}
+
+// ForTenant only matches the route for requests of the tenants. Routes
+// without ForTenant match all tenants. Register tenant routes before a
+// global route with the same pattern since routes with the same pattern are
+// tried in the order they are registered.
+func (rt *Route) ForTenant(tenants ...string) *Route {
+	return rt.When(func(r *http.Request) bool {
+		t := Tenant(r)
+		for _, v := range tenants {
+			if v == t {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// resolveTenant returns the request with the resolved tenant.
+func (m *Mux) resolveTenant(r *http.Request) *http.Request {
+	if m.tenants == nil {
+		return r
+	}
+	return WithTenant(r, m.tenants(r))
+}
+
+// tenantNotFound sends unmatched requests to the NotFound handler of their
+// tenant.
+type tenantNotFound struct {
+	fallback http.Handler
+	handlers map[string]http.Handler
+}
+
+func (d *tenantNotFound) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h, ok := d.handlers[Tenant(r)]; ok {
+		h.ServeHTTP(w, r)
+		return
+	}
+	d.fallback.ServeHTTP(w, r)
+}
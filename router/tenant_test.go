@@ -0,0 +1,59 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantResolvers(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://acme.example.com:8080/acme/users", nil)
+	r.Header.Set("X-Tenant", "globex")
+
+	assert.Equal(t, "acme", TenantFromHost("example.com")(r))
+	assert.Equal(t, "", TenantFromHost("example.org")(r))
+	assert.Equal(t, "globex", TenantFromHeader("X-Tenant")(r))
+	assert.Equal(t, "acme", TenantFromPath()(r))
+}
+
+func TestTenant(t *testing.T) {
+	mux := New()
+	mux.SetTenantResolver(TenantFromPath())
+	mux.Rewrite("/{tenant}/", "/")
+	mux.SetNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "global not found")
+	}))
+	mux.SetTenantNotFound("acme", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "acme not found")
+	}))
+
+	mux.Get("/dashboard", func(w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprint(w, "acme dashboard")
+		return nil
+	}).ForTenant("acme")
+	mux.Get("/dashboard", func(w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprint(w, "dashboard for "+Tenant(r))
+		return nil
+	})
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/acme/dashboard", 200, "acme dashboard"},
+		{"/globex/dashboard", 200, "dashboard for globex"},
+		{"/acme/missing", 404, "acme not found"},
+		{"/globex/missing", 404, "global not found"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		assert.Equal(t, tc.body, w.Body.String(), tc.path)
+	}
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// throttleKey is the route value key for the rates by plan.
+type throttleKey struct{}
+
+// Rate is the number of requests allowed in a window.
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ThrottleConfig contains the settings for the Throttle middleware.
+type ThrottleConfig struct {
+	// Plan returns the plan or role of the principal, such as free or pro,
+	// which selects the rate. It is called with a nil principal for
+	// anonymous requests. If nil, every request uses the "" plan.
+	Plan func(r *http.Request, p Principal) string
+	// Default are the rates by plan of routes without RateLimit. Their
+	// requests are counted together.
+	Default map[string]Rate
+}
+
+// RateLimit sets the rate of the route for principals on the plan, such as
+// free or pro. The "" plan is used for plans without a rate and anonymous
+// requests. It is enforced by the Throttle middleware.
+func (rt *Route) RateLimit(plan string, rate Rate) *Route {
+	existing, _ := rt.route.Value(throttleKey{}).(map[string]Rate)
+	rates := make(map[string]Rate, len(existing)+1)
+	for k, v := range existing {
+		rates[k] = v
+	}
+	rates[plan] = rate
+	rt.route.WithValue(throttleKey{}, rates)
+	return rt
+}
+
+// Throttle returns middleware for Use that limits the requests of each
+// principal, or client IP for anonymous requests, to the rate of their plan
+// set with Route.RateLimit or Group.RateLimit. It must run after the
+// authentication middleware that calls WithPrincipal. Requests without a
+// rate for their plan or the "" plan aren't limited. Requests over the
+// limit are passed to the ServeHTTP function as a 429 StatusError.
+func (m *Mux) Throttle(config ThrottleConfig) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	windows := make(map[string]*rateWindow)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rates := config.Default
+			scope := ""
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				if v, ok := route.Value(throttleKey{}).(map[string]Rate); ok {
+					rates = v
+					scope = route.Method() + " " + route.Pattern()
+				}
+			}
+
+			p := CurrentPrincipal(r)
+			plan := ""
+			if config.Plan != nil {
+				plan = config.Plan(r, p)
+			}
+			rate, ok := rates[plan]
+			if !ok {
+				plan = ""
+				rate, ok = rates[plan]
+			}
+			if !ok || rate.Limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if rate.Window <= 0 {
+				rate.Window = time.Minute
+			}
+
+			client := "ip:" + clientIP(r)
+			if p != nil {
+				client = "id:" + p.ID()
+			}
+
+			key := scope + "|" + plan
+			mu.Lock()
+			rw, ok := windows[key]
+			if !ok {
+				rw = &rateWindow{counts: make(map[string]int)}
+				windows[key] = rw
+			}
+			mu.Unlock()
+
+			remaining, reset := rw.take(client, rate.Limit, rate.Window)
+			SetRateLimitHeaders(w, rate.Limit, remaining, reset)
+			if remaining < 0 {
+				m.serveError(w, r, TooManyRequests(w, reset))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
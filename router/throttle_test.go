@@ -0,0 +1,63 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(testAuthenticate, mux.Throttle(ThrottleConfig{
+		Plan: func(r *http.Request, p Principal) string {
+			if p == nil {
+				return ""
+			}
+			return p.(testPrincipal).roles[0]
+		},
+		Default: map[string]Rate{"": {Limit: 1, Window: time.Minute}},
+	}))
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	mux.Get("/", ok)
+	mux.Get("/export", ok).RateLimit("", Rate{Limit: 1}).RateLimit("admin", Rate{Limit: 3})
+	mux.Group("/api").RateLimit("editor", Rate{Limit: 2}).Get("/items", ok)
+
+	serve := func(path, user string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("X-User", user)
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	codes := func(path, user string, n int) []int {
+		out := make([]int, n)
+		for i := range out {
+			out[i] = serve(path, user).Code
+		}
+		return out
+	}
+
+	// Admins get a higher tier on the route.
+	assert.Equal(t, []int{200, 200, 200, 429}, codes("/export", "admin", 4))
+	// Editors have no tier on the route and fall back to the "" plan. The
+	// count is separate from the admin's.
+	assert.Equal(t, []int{200, 429}, codes("/export", "editor", 2))
+
+	// Group tiers, without a "" plan anonymous requests aren't limited.
+	assert.Equal(t, []int{200, 200, 429}, codes("/api/items", "editor", 3))
+	assert.Equal(t, []int{200, 200, 200}, codes("/api/items", "", 3))
+
+	// Default rates by client IP.
+	w := serve("/", "")
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	w = serve("/", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"context"
+	"encoding/hex"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/ambientkit/away"
+)
+
+// traceSampleKey is the route value key for the trace sampling rate of a
+// route and the context key for the sampling decision of a request.
+type traceSampleKey struct{}
+
+// TraceSampleRate sets the fraction of requests to the route that are
+// traced, from 0 to 1, such as 1 for /checkout and 0.01 for /healthz. It is
+// applied by the TraceSampling middleware.
+func (rt *Route) TraceSampleRate(rate float64) *Route {
+	rt.route.WithValue(traceSampleKey{}, rate)
+	return rt
+}
+
+// TraceSampling returns middleware for Use that decides if each request is
+// traced with the rate of its route or the default rate, so tracing cost is
+// controlled where the routes are declared. The sampled flag of an incoming
+// W3C traceparent header is kept, like the OpenTelemetry ParentBased
+// sampler, so a trace isn't cut off partway through the services it spans.
+// Tracers read the decision with TraceSampled, or TraceSampledContext from a
+// custom OpenTelemetry sampler, which only gets the context of the span. The
+// span must be started after this middleware, such as by tracing middleware
+// added after it, so its context has the decision:
+//
+//	type routeSampler struct{}
+//
+//	func (routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+//		decision := sdktrace.Drop
+//		if sampled, _ := router.TraceSampledContext(p.ParentContext); sampled {
+//			decision = sdktrace.RecordAndSample
+//		}
+//		return sdktrace.SamplingResult{
+//			Decision:   decision,
+//			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+//		}
+//	}
+//
+//	func (routeSampler) Description() string { return "routeSampler" }
+func (m *Mux) TraceSampling(defaultRate float64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rate := defaultRate
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				if v, ok := route.Value(traceSampleKey{}).(float64); ok {
+					rate = v
+				}
+			}
+
+			sampled := sampleTrace(r.Header.Get("traceparent"), rate)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), traceSampleKey{}, sampled)))
+		})
+	}
+}
+
+// TraceSampled returns true if the request was sampled for tracing by the
+// TraceSampling middleware. The second value is false if no decision was
+// made, such as for routes not wrapped in the middleware.
+func TraceSampled(r *http.Request) (sampled bool, ok bool) {
+	return TraceSampledContext(r.Context())
+}
+
+// TraceSampledContext returns the sampling decision of TraceSampled from the
+// request context, for tracers that don't have the request.
+func TraceSampledContext(ctx context.Context) (sampled bool, ok bool) {
+	sampled, ok = ctx.Value(traceSampleKey{}).(bool)
+	return sampled, ok
+}
+
+// sampleTrace returns the sampling decision for a request with the
+// traceparent header and rate.
+func sampleTrace(traceparent string, rate float64) bool {
+	if flags, ok := traceFlags(traceparent); ok {
+		return flags&1 == 1
+	}
+	if rate >= 1 {
+		return true
+	} else if rate <= 0 {
+		return false
+	}
+	return rand.Float64() < rate
+}
+
+// traceFlags returns the trace flags of a W3C traceparent header or false
+// if the header is missing or invalid.
+func traceFlags(header string) (byte, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return 0, false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || strings.Trim(parts[1], "0") == "" {
+		return 0, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return 0, false
+	}
+	return flags[0], true
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceSampling(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.TraceSampling(0.5))
+
+	var sampled, decided bool
+	capture := func(w http.ResponseWriter, r *http.Request) error {
+		sampled, decided = TraceSampled(r)
+		fromContext, _ := TraceSampledContext(r.Context())
+		assert.Equal(t, sampled, fromContext)
+		return nil
+	}
+	mux.Get("/checkout", capture).TraceSampleRate(1)
+	mux.Get("/healthz", capture).TraceSampleRate(0)
+	mux.Get("/", capture)
+
+	serve := func(target string, traceparent string) bool {
+		sampled, decided = false, false
+		r := httptest.NewRequest("GET", target, nil)
+		if traceparent != "" {
+			r.Header.Set("traceparent", traceparent)
+		}
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		assert.True(t, decided)
+		return sampled
+	}
+
+	for i := 0; i < 20; i++ {
+		assert.True(t, serve("/checkout", ""))
+		assert.False(t, serve("/healthz", ""))
+	}
+
+	hits := 0
+	for i := 0; i < 1000; i++ {
+		if serve("/", "") {
+			hits++
+		}
+	}
+	assert.InDelta(t, 500, hits, 100)
+
+	// The decision of the parent is kept.
+	assert.True(t, serve("/healthz", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	assert.False(t, serve("/checkout", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"))
+	assert.True(t, serve("/checkout", "00-00000000000000000000000000000000-00f067aa0ba902b7-00"))
+	assert.False(t, serve("/healthz", "garbage"))
+
+	_, ok := TraceSampled(httptest.NewRequest("GET", "/", nil))
+	assert.False(t, ok)
+	_, ok = TraceSampledContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/ambientkit/away"
+	"github.com/ambientkit/away/router/paramconvert"
+)
+
+// trailKey is the route value key for the prefixes of the groups a route
+// was registered with, outermost first.
+type trailKey struct{}
+
+// TrailStep is a step in the trail of the matched route.
+type TrailStep struct {
+	// Pattern is the group prefix or route pattern, such as /admin/users or
+	// /admin/users/:id.
+	Pattern string
+	// Path is the part of the request path matched by the pattern, such as
+	// /admin/users/42 for /admin/users/:id.
+	Path string
+	// Label is the last segment of Path, a default breadcrumb text.
+	Label string
+	// Current is true for the last step, the matched route.
+	Current bool
+}
+
+// Trail returns the chain of group prefixes of the matched route followed
+// by the route itself, such as /admin, /admin/users and /admin/users/:id,
+// with the paths of the request, so HTML apps can render breadcrumbs and
+// highlight navigation from routing data. Routes registered outside a group
+// only have one step. It returns nil if no route matched.
+func Trail(r *http.Request) []TrailStep {
+	route := away.CurrentRoute(r.Context())
+	if route == nil {
+		return nil
+	}
+
+	prefixes, _ := route.Value(trailKey{}).([]string)
+	pattern := route.Pattern()
+	steps := make([]TrailStep, 0, len(prefixes)+1)
+	for _, prefix := range prefixes {
+		prefix = paramconvert.BraceToColon(prefix)
+		if prefix == pattern || prefix+"/" == pattern {
+			break
+		}
+		p := trailPath(r.URL.Path, prefix)
+		steps = append(steps, TrailStep{Pattern: prefix, Path: p, Label: path.Base(p)})
+	}
+
+	return append(steps, TrailStep{
+		Pattern: pattern,
+		Path:    r.URL.Path,
+		Label:   path.Base(r.URL.Path),
+		Current: true,
+	})
+}
+
+// trailPath returns the leading segments of the request path matched by a
+// prefix, which has a segment for each path segment.
+func trailPath(urlPath string, prefix string) string {
+	n := strings.Count(strings.Trim(prefix, "/"), "/") + 1
+	segs := strings.Split(strings.TrimPrefix(urlPath, "/"), "/")
+	if n > len(segs) {
+		n = len(segs)
+	}
+	return "/" + strings.Join(segs[:n], "/")
+}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrail(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+
+	var trail []TrailStep
+	capture := func(w http.ResponseWriter, r *http.Request) error {
+		trail = Trail(r)
+		return nil
+	}
+
+	admin := mux.Group("/admin")
+	admin.Get("", capture)
+	users := admin.Group("/users")
+	users.Get("", capture)
+	users.Get("/{id}", capture)
+	users.Group("/{id}/keys").Get("/{key}", capture)
+	mux.Get("/about", capture)
+
+	serve := func(target string) []TrailStep {
+		trail = nil
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+		return trail
+	}
+
+	assert.Equal(t, []TrailStep{
+		{Pattern: "/admin", Path: "/admin", Label: "admin"},
+		{Pattern: "/admin/users", Path: "/admin/users", Label: "users"},
+		{Pattern: "/admin/users/:id", Path: "/admin/users/42", Label: "42", Current: true},
+	}, serve("/admin/users/42"))
+
+	assert.Equal(t, []TrailStep{
+		{Pattern: "/admin", Path: "/admin", Label: "admin"},
+		{Pattern: "/admin/users", Path: "/admin/users", Label: "users", Current: true},
+	}, serve("/admin/users"))
+
+	assert.Equal(t, []TrailStep{
+		{Pattern: "/admin", Path: "/admin", Label: "admin"},
+		{Pattern: "/admin/users", Path: "/admin/users", Label: "users"},
+		{Pattern: "/admin/users/:id/keys", Path: "/admin/users/42/keys", Label: "keys"},
+		{Pattern: "/admin/users/:id/keys/:key", Path: "/admin/users/42/keys/k1", Label: "k1", Current: true},
+	}, serve("/admin/users/42/keys/k1"))
+
+	assert.Equal(t, []TrailStep{
+		{Pattern: "/about", Path: "/about", Label: "about", Current: true},
+	}, serve("/about"))
+
+	assert.Nil(t, Trail(httptest.NewRequest("GET", "/", nil)))
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ambientkit/away"
+)
+
+// transformRequestKey is the route value key for the request transforms.
+type transformRequestKey struct{}
+
+// transformResponseKey is the route value key for the response header
+// transforms.
+type transformResponseKey struct{}
+
+// TransformRequest adds a function that changes the request before the
+// handler of the route runs, such as to normalize headers or map legacy
+// query parameters. Transforms run in the order they were added.
+func (rt *Route) TransformRequest(fn func(r *http.Request) *http.Request) *Route {
+	existing, _ := rt.route.Value(transformRequestKey{}).([]func(*http.Request) *http.Request)
+	fns := make([]func(*http.Request) *http.Request, 0, len(existing)+1)
+	rt.route.WithValue(transformRequestKey{}, append(append(fns, existing...), fn))
+	return rt
+}
+
+// TransformResponse adds a function that changes the response headers of the
+// route before they are written, including for error responses. Transforms
+// run in the order they were added.
+func (rt *Route) TransformResponse(fn func(header http.Header)) *Route {
+	existing, _ := rt.route.Value(transformResponseKey{}).([]func(http.Header))
+	fns := make([]func(http.Header), 0, len(existing)+1)
+	rt.route.WithValue(transformResponseKey{}, append(append(fns, existing...), fn))
+	return rt
+}
+
+// withTransforms returns a handler that applies the transforms of the
+// matched route around h.
+func withTransforms(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := away.CurrentRoute(r.Context())
+		if route == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if fns, ok := route.Value(transformRequestKey{}).([]func(*http.Request) *http.Request); ok {
+			for _, fn := range fns {
+				r = fn(r)
+			}
+		}
+
+		if fns, ok := route.Value(transformResponseKey{}).([]func(http.Header)); ok {
+			tw := &transformWriter{ResponseWriter: w, fns: fns}
+			h.ServeHTTP(tw, r)
+			tw.apply()
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// transformWriter applies the response header transforms before the headers
+// are written.
+type transformWriter struct {
+	http.ResponseWriter
+	fns     []func(http.Header)
+	applied bool
+}
+
+// apply runs the transforms once.
+func (w *transformWriter) apply() {
+	if w.applied {
+		return
+	}
+	w.applied = true
+	for _, fn := range w.fns {
+		fn(w.Header())
+	}
+}
+
+func (w *transformWriter) WriteHeader(status int) {
+	if !informational(status) {
+		w.apply()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *transformWriter) Write(b []byte) (int, error) {
+	w.apply()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer.
+func (w *transformWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Get("/items", func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("fail") != "" {
+			return StatusError{Code: http.StatusBadRequest, Err: errors.New("bad")}
+		}
+		w.Header().Set("X-Legacy-Id", "1")
+		w.Write([]byte(r.Header.Get("X-Api-Version") + " " + r.URL.Query().Get("page")))
+		return nil
+	}).TransformRequest(func(r *http.Request) *http.Request {
+		// Map the legacy p parameter to page.
+		q := r.URL.Query()
+		if p := q.Get("p"); p != "" {
+			q.Set("page", p)
+			r.URL.RawQuery = q.Encode()
+		}
+		return r
+	}).TransformRequest(func(r *http.Request) *http.Request {
+		if r.Header.Get("X-Api-Version") == "" {
+			r.Header.Set("X-Api-Version", "v1")
+		}
+		return r
+	}).TransformResponse(func(header http.Header) {
+		if id := header.Get("X-Legacy-Id"); id != "" {
+			header.Set("X-Item-Id", id)
+			header.Del("X-Legacy-Id")
+		}
+	}).TransformResponse(func(header http.Header) {
+		header.Set("X-Transformed", "true")
+	})
+	mux.Get("/plain", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/items?p=2", nil))
+	assert.Equal(t, "v1 2", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Item-Id"))
+	assert.Empty(t, w.Header().Get("X-Legacy-Id"))
+	assert.Equal(t, "true", w.Header().Get("X-Transformed"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/items?fail=1", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Transformed"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/plain", nil))
+	assert.Empty(t, w.Header().Get("X-Transformed"))
+}
@@ -0,0 +1,514 @@
+package router
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ambientkit/away"
+)
+
+var (
+	// ErrUploadNotFound is returned by an UploadStore for an unknown upload.
+	ErrUploadNotFound = errors.New("router: upload not found")
+	// ErrUploadOffset is returned when a chunk doesn't start at the offset
+	// of the upload.
+	ErrUploadOffset = errors.New("router: upload offset mismatch")
+	// ErrUploadTooLarge is returned when an upload is larger than its
+	// declared length or UploadConfig.MaxSize.
+	ErrUploadTooLarge = errors.New("router: upload too large")
+	// ErrUploadCompleting is returned when a chunk finishes an upload that
+	// another request is already completing.
+	ErrUploadCompleting = errors.New("router: upload is being completed")
+)
+
+// tusVersion is the version of the tus resumable upload protocol.
+const tusVersion = "1.0.0"
+
+// UploadInfo describes a resumable upload.
+type UploadInfo struct {
+	ID       string            `json:"id"`
+	Length   int64             `json:"length"`
+	Offset   int64             `json:"offset"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Completed is true once OnComplete succeeded for the upload.
+	Completed bool `json:"completed,omitempty"`
+}
+
+// UploadStore persists resumable uploads.
+type UploadStore interface {
+	// Create saves a new upload with an offset of zero.
+	Create(info UploadInfo) error
+	// Info returns the upload with the ID or ErrUploadNotFound.
+	Info(id string) (UploadInfo, error)
+	// Append writes the data at the offset, which must be the current
+	// offset of the upload or ErrUploadOffset is returned. It returns the
+	// number of bytes written, which are kept even if reading the data
+	// fails, so the client can resume.
+	Append(id string, offset int64, data io.Reader) (int64, error)
+	// Complete marks the upload as completed.
+	Complete(id string) error
+	// Open returns the data of the upload.
+	Open(id string) (io.ReadCloser, error)
+	// Delete removes the upload.
+	Delete(id string) error
+}
+
+// UploadConfig contains the settings for ResumableUploads.
+type UploadConfig struct {
+	// Store persists the uploads.
+	Store UploadStore
+	// MaxSize is the largest upload allowed. Zero means no limit.
+	MaxSize int64
+	// OnComplete is called once after the last chunk of an upload is
+	// written, before the response to that chunk. An error is returned to
+	// the client, which can retry with HEAD and an empty PATCH.
+	OnComplete func(r *http.Request, info UploadInfo) error
+}
+
+// ResumableUploads registers routes under the prefix, such as /uploads, for
+// resumable uploads with the tus protocol, so clients can continue large
+// uploads after a dropped connection:
+//
+//	POST   {prefix}       creates an upload with the Upload-Length and
+//	                      Upload-Metadata headers, responding with its
+//	                      Location
+//	HEAD   {prefix}/{id}  returns the Upload-Offset to resume from
+//	PATCH  {prefix}/{id}  appends an application/offset+octet-stream body
+//	                      at the Upload-Offset header
+//	DELETE {prefix}/{id}  removes the upload
+//
+// The routes are wrapped in the middleware, such as authentication. The
+// returned group can register more routes under the prefix.
+func (m *Mux) ResumableUploads(prefix string, config UploadConfig, mw ...func(http.Handler) http.Handler) *Group {
+	if config.Store == nil {
+		panic("router: ResumableUploads requires a store")
+	}
+
+	g := m.Group(prefix).Use(mw...)
+	u := &uploads{config: config, prefix: g.prefix, completing: make(map[string]bool)}
+
+	g.Post("", u.create)
+	g.Head("/{id}", u.head)
+	g.Patch("/{id}", u.patch)
+	g.Delete("/{id}", u.delete)
+
+	return g
+}
+
+// uploads serves the resumable upload routes.
+type uploads struct {
+	config UploadConfig
+	prefix string
+
+	mu         sync.Mutex
+	completing map[string]bool
+}
+
+// create creates an upload.
+func (u *uploads) create(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return StatusError{Code: http.StatusBadRequest, Err: errors.New("router: invalid Upload-Length")}
+	}
+	if u.config.MaxSize > 0 && length > u.config.MaxSize {
+		return StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrUploadTooLarge, ErrorCode: ErrorCodeBodyTooLarge}
+	}
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		return StatusError{Code: http.StatusBadRequest, Err: err}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	info := UploadInfo{ID: hex.EncodeToString(b), Length: length, Metadata: metadata}
+	if err := u.config.Store.Create(info); err != nil {
+		return err
+	}
+
+	if length == 0 {
+		if err := u.complete(r, info); err != nil {
+			return err
+		}
+	}
+
+	w.Header().Set("Location", u.prefix+"/"+info.ID)
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// head returns the offset of an upload.
+func (u *uploads) head(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+
+	info, err := u.info(r)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// patch appends a chunk to an upload.
+func (u *uploads) patch(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		return StatusError{Code: http.StatusUnsupportedMediaType, Err: ErrUnsupportedMediaType}
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return StatusError{Code: http.StatusBadRequest, Err: errors.New("router: invalid Upload-Offset")}
+	}
+
+	info, err := u.info(r)
+	if err != nil {
+		return err
+	}
+	if offset != info.Offset {
+		return StatusError{Code: http.StatusConflict, Err: ErrUploadOffset, ErrorCode: ErrorCodeConflict}
+	}
+
+	n, err := u.config.Store.Append(info.ID, offset, io.LimitReader(r.Body, info.Length-offset))
+	info.Offset += n
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	if errors.Is(err, ErrUploadOffset) {
+		return StatusError{Code: http.StatusConflict, Err: err, ErrorCode: ErrorCodeConflict}
+	} else if err != nil {
+		return readError(err)
+	}
+
+	if info.Offset == info.Length {
+		if more, _ := r.Body.Read(make([]byte, 1)); more > 0 {
+			return StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrUploadTooLarge, ErrorCode: ErrorCodeBodyTooLarge}
+		}
+		if err := u.complete(r, info); err != nil {
+			return err
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// delete removes an upload.
+func (u *uploads) delete(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	info, err := u.info(r)
+	if err != nil {
+		return err
+	}
+	if err := u.config.Store.Delete(info.ID); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// info returns the upload of the request.
+func (u *uploads) info(r *http.Request) (UploadInfo, error) {
+	info, err := u.config.Store.Info(away.Param(r.Context(), "id"))
+	if errors.Is(err, ErrUploadNotFound) {
+		return info, StatusError{Code: http.StatusNotFound, Err: err, ErrorCode: ErrorCodeNotFound}
+	}
+	return info, err
+}
+
+// complete calls the completion callback and marks the upload as completed,
+// unless it already is. A completion already running for the upload is a
+// 409 StatusError.
+func (u *uploads) complete(r *http.Request, info UploadInfo) error {
+	if info.Completed {
+		return nil
+	}
+
+	u.mu.Lock()
+	if u.completing[info.ID] {
+		u.mu.Unlock()
+		return StatusError{Code: http.StatusConflict, Err: ErrUploadCompleting, ErrorCode: ErrorCodeConflict}
+	}
+	u.completing[info.ID] = true
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		delete(u.completing, info.ID)
+		u.mu.Unlock()
+	}()
+
+	// Another request may have completed the upload since info was read.
+	current, err := u.config.Store.Info(info.ID)
+	if err != nil {
+		return err
+	}
+	if current.Completed {
+		return nil
+	}
+
+	if u.config.OnComplete != nil {
+		if err := u.config.OnComplete(r, info); err != nil {
+			return err
+		}
+	}
+	return u.config.Store.Complete(info.ID)
+}
+
+// parseUploadMetadata parses the comma separated keys and base64 values of
+// the Upload-Metadata header.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	if strings.TrimSpace(header) == "" {
+		return nil, nil
+	}
+
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			v, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("router: invalid Upload-Metadata value for %v", fields[0])
+			}
+			metadata[fields[0]] = string(v)
+		default:
+			return nil, errors.New("router: invalid Upload-Metadata")
+		}
+	}
+	return metadata, nil
+}
+
+// FileUploadStore is an UploadStore that keeps each upload in a data file
+// and an info file in a directory.
+type FileUploadStore struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*uploadLock
+}
+
+// uploadLock is the lock of an upload with the number of callers holding or
+// waiting for it, so it can be dropped when there are none.
+type uploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewFileUploadStore returns an UploadStore in the directory, which is
+// created if it doesn't exist.
+func NewFileUploadStore(dir string) (*FileUploadStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileUploadStore{dir: dir, locks: make(map[string]*uploadLock)}, nil
+}
+
+// lock locks the upload with the ID and returns the unlock function. IDs are
+// validated first so invalid IDs don't add locks.
+func (s *FileUploadStore) lock(id string) (func(), error) {
+	if _, err := s.path(id, ""); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = new(uploadLock)
+		s.locks[id] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.mu.Unlock()
+	}, nil
+}
+
+// path returns the path of a file of the upload. IDs are hex so they can't
+// escape the directory.
+func (s *FileUploadStore) path(id string, ext string) (string, error) {
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return "", ErrUploadNotFound
+	}
+	return filepath.Join(s.dir, id+ext), nil
+}
+
+// Create saves a new upload with an empty data file.
+func (s *FileUploadStore) Create(info UploadInfo) error {
+	unlock, err := s.lock(info.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := s.path(info.ID, ".bin")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(data, nil, 0o644); err != nil {
+		return err
+	}
+	info.Offset = 0
+	info.Completed = false
+	return s.writeInfo(info)
+}
+
+// Info returns the upload with the ID, with the offset set to the size of
+// the data file.
+func (s *FileUploadStore) Info(id string) (UploadInfo, error) {
+	unlock, err := s.lock(id)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+	defer unlock()
+	return s.readInfo(id)
+}
+
+// readInfo reads the info file of the upload.
+func (s *FileUploadStore) readInfo(id string) (UploadInfo, error) {
+	p, err := s.path(id, ".json")
+	if err != nil {
+		return UploadInfo{}, err
+	}
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return UploadInfo{}, ErrUploadNotFound
+	} else if err != nil {
+		return UploadInfo{}, err
+	}
+
+	var info UploadInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return UploadInfo{}, err
+	}
+
+	data, _ := s.path(id, ".bin")
+	fi, err := os.Stat(data)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+	info.Offset = fi.Size()
+	return info, nil
+}
+
+// writeInfo writes the info file of the upload.
+func (s *FileUploadStore) writeInfo(info UploadInfo) error {
+	p, err := s.path(info.ID, ".json")
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, b, 0o644)
+}
+
+// Append writes the data to the end of the data file if it is at the
+// offset.
+func (s *FileUploadStore) Append(id string, offset int64, data io.Reader) (int64, error) {
+	unlock, err := s.lock(id)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	info, err := s.readInfo(id)
+	if err != nil {
+		return 0, err
+	}
+	if info.Offset != offset {
+		return 0, ErrUploadOffset
+	}
+
+	p, _ := s.path(id, ".bin")
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// Complete marks the upload as completed in its info file.
+func (s *FileUploadStore) Complete(id string) error {
+	unlock, err := s.lock(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	info, err := s.readInfo(id)
+	if err != nil {
+		return err
+	}
+	info.Completed = true
+	return s.writeInfo(info)
+}
+
+// Open returns the data file of the upload.
+func (s *FileUploadStore) Open(id string) (io.ReadCloser, error) {
+	p, err := s.path(id, ".bin")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, ErrUploadNotFound
+	}
+	return f, err
+}
+
+// Delete removes the files of the upload.
+func (s *FileUploadStore) Delete(id string) error {
+	unlock, err := s.lock(id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, ext := range []string{".json", ".bin"} {
+		p, err := s.path(id, ext)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(p); os.IsNotExist(err) {
+			return ErrUploadNotFound
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
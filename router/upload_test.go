@@ -0,0 +1,127 @@
+package router
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumableUploads(t *testing.T) {
+	store, err := NewFileUploadStore(t.TempDir())
+	assert.NoError(t, err)
+
+	var completed []UploadInfo
+	fail := true
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.ResumableUploads("/uploads", UploadConfig{
+		Store:   store,
+		MaxSize: 100,
+		OnComplete: func(r *http.Request, info UploadInfo) error {
+			if fail {
+				fail = false
+				return errors.New("storage unavailable")
+			}
+			completed = append(completed, info)
+			return nil
+		},
+	})
+
+	serve := func(method, target, body string, headers ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	chunk := "application/offset+octet-stream"
+
+	w := serve("POST", "/uploads", "", "Upload-Length", "101")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	w = serve("POST", "/uploads", "", "Upload-Length", "10", "Upload-Metadata", "filename aGVsbG8udHh0,private")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1.0.0", w.Header().Get("Tus-Resumable"))
+	loc := w.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(loc, "/uploads/"))
+
+	w = serve("PATCH", loc, "hello", "Content-Type", chunk, "Upload-Offset", "0")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Upload-Offset"))
+
+	// The client resumes from the offset after a dropped connection.
+	w = serve("PATCH", loc, "hello", "Content-Type", chunk, "Upload-Offset", "0")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = serve("PATCH", loc, "world", "Upload-Offset", "5")
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w = serve("HEAD", loc, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Upload-Offset"))
+	assert.Equal(t, "10", w.Header().Get("Upload-Length"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	// A failed completion is retried with an empty chunk.
+	w = serve("PATCH", loc, "world", "Content-Type", chunk, "Upload-Offset", "5")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, completed)
+	w = serve("PATCH", loc, "", "Content-Type", chunk, "Upload-Offset", "10")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Completion isn't repeated.
+	w = serve("PATCH", loc, "", "Content-Type", chunk, "Upload-Offset", "10")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	if assert.Len(t, completed, 1) {
+		info := completed[0]
+		assert.Equal(t, int64(10), info.Offset)
+		assert.Equal(t, map[string]string{"filename": "hello.txt", "private": ""}, info.Metadata)
+
+		f, err := store.Open(info.ID)
+		if assert.NoError(t, err) {
+			b, _ := ioutil.ReadAll(f)
+			f.Close()
+			assert.Equal(t, "helloworld", string(b))
+		}
+	}
+
+	w = serve("DELETE", loc, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = serve("HEAD", loc, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve("HEAD", "/uploads/..", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve("HEAD", "/uploads/abcd", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Locks are dropped when they aren't held.
+	store.mu.Lock()
+	assert.Empty(t, store.locks)
+	store.mu.Unlock()
+}
+
+func TestResumableUploadsTooLarge(t *testing.T) {
+	store, err := NewFileUploadStore(t.TempDir())
+	assert.NoError(t, err)
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.ResumableUploads("/uploads", UploadConfig{Store: store})
+
+	r := httptest.NewRequest("POST", "/uploads", nil)
+	r.Header.Set("Upload-Length", "3")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	loc := w.Header().Get("Location")
+
+	r = httptest.NewRequest("PATCH", loc, strings.NewReader("abcdef"))
+	r.Header.Set("Content-Type", "application/offset+octet-stream")
+	r.Header.Set("Upload-Offset", "0")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Upload-Offset"))
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ambientkit/away"
+	"github.com/ambientkit/away/router/schema"
+)
+
+// schemaKey is the route value key for the request body schema.
+type schemaKey struct{}
+
+// Schema sets the JSON Schema the request body must match. It is enforced by
+// the ValidateSchema middleware. Use schema.Generate to create a schema from
+// the request struct.
+func (rt *Route) Schema(s *schema.Schema) *Route {
+	rt.route.WithValue(schemaKey{}, s)
+	return rt
+}
+
+// ValidateSchema returns middleware for Use that validates request bodies
+// against the schema set with Schema before the handler runs. A body that
+// isn't a single JSON value is passed to the ServeHTTP function as a 400
+// StatusError and a body that doesn't match the schema as a 422 StatusError
+// that wraps FieldErrors with JSON Pointer fields. Errors reading the body
+// keep their status, such as the 408 of BodyTimeout.
+func (m *Mux) ValidateSchema() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := away.CurrentRoute(r.Context())
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			s, ok := route.Value(schemaKey{}).(*schema.Schema)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			b, err := readBody(r.Body, m.jsonOptions.MaxBytes)
+			if err != nil {
+				m.serveError(w, r, err)
+				return
+			}
+
+			var v interface{}
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.UseNumber()
+			if err := dec.Decode(&v); err != nil {
+				m.serveError(w, r, StatusError{Code: http.StatusBadRequest, Err: err})
+				return
+			}
+			if dec.More() {
+				m.serveError(w, r, StatusError{Code: http.StatusBadRequest, Err: errSingleJSONValue})
+				return
+			}
+
+			if errs := s.Validate(v); len(errs) > 0 {
+				fe := make(FieldErrors, 0, len(errs))
+				for _, e := range errs {
+					fe = append(fe, FieldError{Field: e.Pointer, Message: e.Message})
+				}
+				m.serveError(w, r, StatusError{Code: http.StatusUnprocessableEntity, Err: fe})
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(b))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package router
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ambientkit/away/router/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSchema(t *testing.T) {
+	mux := New()
+	mux.SetServeHTTP(defaultServeHTTP)
+	mux.Use(mux.ValidateSchema())
+
+	body := ""
+	mux.Post("/users", func(w http.ResponseWriter, r *http.Request) error {
+		b, err := ioutil.ReadAll(r.Body)
+		body = string(b)
+		return err
+	}).Schema(schema.Generate(testUser{}))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"john"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"name":"john"}`, body)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":5}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "/name: must be of type string\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/users", strings.NewReader(`{`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"john"} {}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Read errors keep their status.
+	r := httptest.NewRequest("POST", "/users", iotest.ErrReader(StatusError{Code: http.StatusRequestTimeout, Err: ErrBodyTimeout}))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestTimeout, w.Code)
+}
@@ -0,0 +1,148 @@
+package router
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ambientkit/away"
+)
+
+// RouteWebhookConfig contains the settings for the routing table webhook.
+type RouteWebhookConfig struct {
+	// URL receives a POST with a JSON RouteDiff.
+	URL string
+	// Secret signs the body with HMAC-SHA256 in the X-Away-Signature header
+	// as sha256=<hex>. Optional.
+	Secret []byte
+	// Client sends the webhook. http.DefaultClient is used if nil.
+	Client *http.Client
+	// Debounce is how long changes are collected into one webhook, such as
+	// all the routes of a plugin that was enabled. Defaults to 100ms.
+	Debounce time.Duration
+	// OnError is called when a webhook can't be sent or the response status
+	// isn't 2xx. Failed webhooks aren't retried. Optional.
+	OnError func(err error)
+}
+
+// RouteDiff is the body of the routing table webhook.
+type RouteDiff struct {
+	Added   []away.RouteInfo `json:"added"`
+	Removed []away.RouteInfo `json:"removed"`
+	Swapped []away.RouteInfo `json:"swapped"`
+	// Routes is the routing table after the changes.
+	Routes []away.RouteInfo `json:"routes"`
+}
+
+// routeWebhook collects route changes and sends them.
+type routeWebhook struct {
+	config RouteWebhookConfig
+	mux    *Mux
+
+	mu      sync.Mutex
+	pending RouteDiff
+	timer   *time.Timer
+
+	// sending serializes flushes so a receiver never gets an older
+	// routing table after a newer one.
+	sending sync.Mutex
+}
+
+// RouteWebhook sends a signed webhook with the changes when routes are
+// registered, removed, or swapped so API gateways and documentation can
+// resync. Changes within the debounce are sent together. Use OnChange to
+// call a function instead.
+func (m *Mux) RouteWebhook(config RouteWebhookConfig) {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Debounce <= 0 {
+		config.Debounce = 100 * time.Millisecond
+	}
+
+	wh := &routeWebhook{config: config, mux: m}
+	m.OnChange(wh.record)
+}
+
+// record adds the change to the pending diff.
+func (wh *routeWebhook) record(event away.RouteEvent) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	switch event.Type {
+	case away.RouteRegistered:
+		wh.pending.Added = append(wh.pending.Added, event.Route)
+	case away.RouteRemoved:
+		wh.pending.Removed = append(wh.pending.Removed, event.Route)
+	case away.RouteSwapped:
+		wh.pending.Swapped = append(wh.pending.Swapped, event.Route)
+	}
+
+	if wh.timer == nil {
+		wh.timer = time.AfterFunc(wh.config.Debounce, wh.flush)
+	}
+}
+
+// flush sends the pending diff.
+func (wh *routeWebhook) flush() {
+	wh.sending.Lock()
+	defer wh.sending.Unlock()
+
+	wh.mu.Lock()
+	diff := wh.pending
+	wh.pending = RouteDiff{}
+	wh.timer = nil
+	wh.mu.Unlock()
+
+	diff.Routes = make([]away.RouteInfo, 0)
+	wh.mux.Walk(func(route away.RouteInfo) error {
+		diff.Routes = append(diff.Routes, route)
+		return nil
+	})
+
+	if err := wh.send(diff); err != nil && wh.config.OnError != nil {
+		wh.config.OnError(err)
+	}
+}
+
+// send posts the diff to the webhook URL.
+func (wh *routeWebhook) send(diff RouteDiff) error {
+	body, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, wh.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(wh.config.Secret) > 0 {
+		req.Header.Set("X-Away-Signature", "sha256="+SignWebhook(wh.config.Secret, body))
+	}
+
+	resp, err := wh.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("router: route webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of the body, for receivers to
+// compare with the X-Away-Signature header using hmac.Equal.
+func SignWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
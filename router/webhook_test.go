@@ -0,0 +1,92 @@
+package router
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteWebhook(t *testing.T) {
+	secret := []byte("secret")
+	diffs := make(chan RouteDiff, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "sha256="+SignWebhook(secret, body), r.Header.Get("X-Away-Signature"))
+
+		var diff RouteDiff
+		assert.NoError(t, json.Unmarshal(body, &diff))
+		diffs <- diff
+	}))
+	defer srv.Close()
+
+	mux := New()
+	mux.Get("/old", testNamedHandler)
+	mux.RouteWebhook(RouteWebhookConfig{URL: srv.URL, Secret: secret, Debounce: 10 * time.Millisecond})
+
+	mux.Get("/users", testNamedHandler)
+	mux.Post("/users", testNamedHandler)
+	mux.Replace("GET", "/users", testNamedHandler)
+	mux.Clear("GET", "/old")
+
+	select {
+	case diff := <-diffs:
+		assert.Len(t, diff.Added, 2)
+		assert.Len(t, diff.Swapped, 1)
+		if assert.Len(t, diff.Removed, 1) {
+			assert.Equal(t, "/old", diff.Removed[0].Pattern)
+		}
+		assert.Len(t, diff.Routes, 2)
+	case <-time.After(time.Second):
+		t.Fatal("webhook not sent")
+	}
+}
+
+func TestRouteWebhookSerialized(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	routes := make(chan int, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		var diff RouteDiff
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&diff))
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		routes <- len(diff.Routes)
+	}))
+	defer srv.Close()
+
+	mux := New()
+	mux.RouteWebhook(RouteWebhookConfig{URL: srv.URL, Debounce: time.Millisecond})
+
+	// The second change happens while the first webhook is being sent.
+	mux.Get("/a", testNamedHandler)
+	time.Sleep(20 * time.Millisecond)
+	mux.Get("/b", testNamedHandler)
+
+	for _, want := range []int{1, 2} {
+		select {
+		case n := <-routes:
+			assert.Equal(t, want, n)
+		case <-time.After(time.Second):
+			t.Fatal("webhook not sent")
+		}
+	}
+	mu.Lock()
+	assert.Equal(t, 1, maxInFlight)
+	mu.Unlock()
+}
@@ -0,0 +1,47 @@
+package away
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// segmentPool holds scratch slices for the path segments of requests so
+// matching doesn't allocate.
+var segmentPool = sync.Pool{
+	New: func() interface{} {
+		segs := make([]string, 0, 16)
+		return &segs
+	},
+}
+
+// putSegments clears the segments and returns the slice to the pool.
+func putSegments(scratch *[]string, segs []string) {
+	for i := range segs {
+		segs[i] = ""
+	}
+	*scratch = segs[:0]
+	segmentPool.Put(scratch)
+}
+
+// splitPath appends the segments of the path to segs. It is the same as
+// strings.Split(strings.Trim(p, "/"), "/") without allocating a new slice.
+func splitPath(segs []string, p string) []string {
+	p = strings.Trim(p, "/")
+	for {
+		i := strings.IndexByte(p, '/')
+		if i < 0 {
+			return append(segs, p)
+		}
+		segs = append(segs, p[:i])
+		p = p[i+1:]
+	}
+}
+
+// routeHandler serves a matched route. Converting a *Route to a
+// *routeHandler doesn't allocate like the method value route.serve does.
+type routeHandler Route
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	(*Route)(h).serve(w, req)
+}
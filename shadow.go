@@ -0,0 +1,88 @@
+package away
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// MaxShadowBody is the largest request body, in bytes, that is buffered for
+// a shadow handler. Requests with larger bodies aren't shadowed.
+const MaxShadowBody = 1 << 20
+
+// Shadow sets a handler that receives a copy of every request served by the
+// route. The shadow handler runs asynchronously after the primary handler
+// with a buffered copy of the request body and its response is discarded.
+func (r *Route) Shadow(handler http.Handler) *Route {
+	r.configure(func(c *routeConfig) {
+		c.shadow = handler
+	})
+	return r
+}
+
+// serve calls the route handler and the shadow handler if one is set.
+func (r *Route) serve(w http.ResponseWriter, req *http.Request) {
+	shadow := r.settings().shadow
+	if shadow == nil || req.ContentLength > MaxShadowBody {
+		r.handler.ServeHTTP(w, req)
+		return
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(io.LimitReader(req.Body, MaxShadowBody+1))
+		if err != nil {
+			// The primary handler must not process a partial body.
+			req.Body.Close()
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if len(b) > MaxShadowBody {
+			// Too large to shadow, so pass the rest of the body through.
+			req.Body = readCloser{io.MultiReader(bytes.NewReader(b), req.Body), req.Body}
+			r.handler.ServeHTTP(w, req)
+			return
+		}
+		req.Body.Close()
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// The shadow request outlives the original request.
+	shadowReq := req.Clone(context.WithoutCancel(req.Context()))
+	r.handler.ServeHTTP(w, req)
+
+	if body != nil {
+		shadowReq.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	go func() {
+		defer func() {
+			// A failing shadow handler must not affect the server.
+			recover()
+		}()
+		shadow.ServeHTTP(&discardWriter{header: make(http.Header)}, shadowReq)
+	}()
+}
+
+// readCloser reads from a reader and closes the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// discardWriter is a http.ResponseWriter that discards the response.
+type discardWriter struct {
+	header http.Header
+}
+
+func (d *discardWriter) Header() http.Header {
+	return d.header
+}
+
+func (d *discardWriter) Write(b []byte) (int, error) {
+	return io.Discard.Write(b)
+}
+
+func (d *discardWriter) WriteHeader(statusCode int) {}
@@ -0,0 +1,133 @@
+package away
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// suggestionsContextKey is the context key for storing suggestions.
+type suggestionsContextKey struct{}
+
+// maxSuggestionDistance is the largest edit distance between a path and a
+// route for the route to be suggested.
+const maxSuggestionDistance = 3
+
+// Suggestion is a route that is close to a request that did not match.
+type Suggestion struct {
+	Method  string
+	Pattern string
+	// Distance is the edit distance between the request path and the route.
+	// It is 0 when the path matches but the method doesn't.
+	Distance int
+}
+
+// SetSuggestions enables up to max suggestions of similar routes to be
+// passed to the NotFound handler, available via Suggestions. Use 0 to
+// disable suggestions.
+func (r *Router) SetSuggestions(max int) {
+	r.suggestions = max
+}
+
+// Suggestions returns the routes similar to a request that did not match.
+// Returns nil if suggestions are disabled with SetSuggestions.
+func Suggestions(ctx context.Context) []Suggestion {
+	s, _ := ctx.Value(suggestionsContextKey{}).([]Suggestion)
+	return s
+}
+
+// suggest returns the routes closest to the request.
+func (r *Router) suggest(req *http.Request, segs []string) []Suggestion {
+	return r.nearest(req, segs, r.suggestions)
+}
+
+// nearest returns up to max routes closest to the request.
+func (r *Router) nearest(req *http.Request, segs []string, max int) []Suggestion {
+	path := "/" + strings.Join(segs, "/")
+	out := make([]Suggestion, 0)
+	seen := make(map[string]bool)
+
+	for _, route := range r.snapshot() {
+		key := route.method + " " + route.pattern
+		if seen[key] {
+			continue
+		}
+
+		distance := -1
+		if _, ok := route.match(req.Context(), r, segs); ok {
+			if route.method == strings.ToLower(req.Method) || route.method == MethodAny {
+				// The route was skipped by When.
+				continue
+			}
+			distance = 0
+		} else if d := editDistance(path, route.fill(segs)); d <= maxSuggestionDistance {
+			distance = d
+		}
+
+		if distance >= 0 {
+			seen[key] = true
+			out = append(out, Suggestion{
+				Method:   route.Method(),
+				Pattern:  route.pattern,
+				Distance: distance,
+			})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Distance < out[j].Distance
+	})
+	if len(out) > max {
+		out = out[:max]
+	}
+
+	return out
+}
+
+// fill returns the route pattern with parameters replaced by the matching
+// request segments so only the static parts of the route are compared.
+func (r *Route) fill(segs []string) string {
+	out := make([]string, len(r.segs))
+	for i, seg := range r.segs {
+		if strings.HasPrefix(seg, ":") && i < len(segs) {
+			out[i] = segs[i]
+			continue
+		}
+		out[i] = seg
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
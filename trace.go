@@ -0,0 +1,87 @@
+package away
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TraceResult is the outcome of comparing a request to a route.
+type TraceResult string
+
+const (
+	// TraceMatched means the route matched and will serve the request.
+	TraceMatched TraceResult = "matched"
+	// TraceMethodMismatch means the route is for a different method.
+	TraceMethodMismatch TraceResult = "method mismatch"
+	// TracePathMismatch means the path didn't match the pattern. The
+	// TraceEvent Reason explains why.
+	TracePathMismatch TraceResult = "path mismatch"
+	// TraceConditionFalse means a When function returned false.
+	TraceConditionFalse TraceResult = "condition false"
+	// TraceDisabled means the route was disabled with SetEnabled.
+	TraceDisabled TraceResult = "disabled"
+	// TraceNotFound means no route matched. The TraceEvent Route is empty.
+	TraceNotFound TraceResult = "not found"
+)
+
+// TraceEvent describes a route considered for a request.
+type TraceEvent struct {
+	Method string
+	Path   string
+	Route  RouteInfo
+	Result TraceResult
+	// Reason describes why the path didn't match.
+	Reason string
+	// Segment is the index of the path segment that didn't match or -1.
+	Segment int
+}
+
+// SetTrace sets a function that is called for every route considered for a
+// request and why it was rejected. Use nil to disable tracing.
+func (r *Router) SetTrace(fn func(event TraceEvent)) {
+	r.trace = fn
+}
+
+// traceRoute reports the result of comparing a request to a route.
+func (r *Router) traceRoute(req *http.Request, route *Route, result TraceResult, segs []string) {
+	if r.trace == nil {
+		return
+	}
+
+	event := TraceEvent{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Result:  result,
+		Segment: -1,
+	}
+	if route != nil {
+		event.Route = route.Info()
+	}
+	if result == TracePathMismatch {
+		event.Reason, event.Segment = route.mismatch(segs)
+	}
+
+	r.trace(event)
+}
+
+// mismatch explains why the path segments don't match the route.
+func (r *Route) mismatch(segs []string) (string, int) {
+	if len(segs) > len(r.segs) && !r.prefix {
+		return "path has more segments than the pattern", len(r.segs)
+	}
+	for i, seg := range r.segs {
+		if i > len(segs)-1 {
+			return "path has fewer segments than the pattern", i
+		}
+		if strings.HasPrefix(seg, ":") {
+			continue
+		}
+		if strings.HasSuffix(seg, "...") && strings.HasPrefix(segs[i], seg[:len(seg)-3]) {
+			break
+		}
+		if seg != segs[i] {
+			return "segment " + segs[i] + " doesn't match " + seg, i
+		}
+	}
+	return "", -1
+}
@@ -0,0 +1,83 @@
+package away
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// UnmatchedResult is the reason no route matched a request.
+type UnmatchedResult string
+
+const (
+	// UnmatchedNotFound means no route matched the path.
+	UnmatchedNotFound UnmatchedResult = "not found"
+	// UnmatchedMethodNotAllowed means the path matched routes of other
+	// methods.
+	UnmatchedMethodNotAllowed UnmatchedResult = "method not allowed"
+	// UnmatchedNotImplemented means no route is registered for the method.
+	UnmatchedNotImplemented UnmatchedResult = "not implemented"
+)
+
+// UnmatchedEvent describes a request that no route matched.
+type UnmatchedEvent struct {
+	Method string
+	Path   string
+	Result UnmatchedResult
+}
+
+// UnmatchedStats are the number of requests that no route matched by
+// result.
+type UnmatchedStats struct {
+	NotFound         uint64
+	MethodNotAllowed uint64
+	NotImplemented   uint64
+}
+
+// unmatchedCounters count unmatched requests.
+type unmatchedCounters struct {
+	notFound         uint64
+	methodNotAllowed uint64
+	notImplemented   uint64
+}
+
+// OnUnmatched adds a listener that is called for each request that no route
+// matches, before the NotFound, MethodNotAllowed, or NotImplemented handler.
+// Listeners are called synchronously so they should be fast.
+func (r *Router) OnUnmatched(fn func(event UnmatchedEvent)) {
+	r.unmatchedListeners = append(r.unmatchedListeners, fn)
+}
+
+// UnmatchedStats returns the number of requests that no route matched since
+// the router was created.
+func (r *Router) UnmatchedStats() UnmatchedStats {
+	return UnmatchedStats{
+		NotFound:         atomic.LoadUint64(&r.unmatchedCounters.notFound),
+		MethodNotAllowed: atomic.LoadUint64(&r.unmatchedCounters.methodNotAllowed),
+		NotImplemented:   atomic.LoadUint64(&r.unmatchedCounters.notImplemented),
+	}
+}
+
+// countUnmatched counts an unmatched request and calls the listeners.
+func (r *Router) countUnmatched(req *http.Request, result UnmatchedResult) {
+	switch result {
+	case UnmatchedMethodNotAllowed:
+		atomic.AddUint64(&r.unmatchedCounters.methodNotAllowed, 1)
+	case UnmatchedNotImplemented:
+		atomic.AddUint64(&r.unmatchedCounters.notImplemented, 1)
+	default:
+		atomic.AddUint64(&r.unmatchedCounters.notFound, 1)
+	}
+
+	if len(r.unmatchedListeners) == 0 {
+		return
+	}
+
+	event := UnmatchedEvent{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Result: result,
+	}
+	for _, fn := range r.unmatchedListeners {
+		fn(event)
+	}
+}
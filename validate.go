@@ -0,0 +1,220 @@
+package away
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidPattern is returned by ValidatePattern for patterns that
+	// can't match as intended.
+	ErrInvalidPattern = errors.New("away: invalid pattern")
+	// ErrMidPatternWildcard is returned by ValidatePattern for patterns with
+	// segments after a ... wildcard, such as /images.../:id. The wildcard
+	// matches the rest of the path, so Handle always panics for them.
+	ErrMidPatternWildcard = fmt.Errorf("%w: segments after ... wildcard", ErrInvalidPattern)
+)
+
+// DefaultMaxDepth is the maximum number of segments in a pattern if one isn't
+// set with SetMaxDepth.
+const DefaultMaxDepth = 32
+
+// SetStrict enables strict mode where Handle panics if the pattern is
+// rejected by ValidatePattern, so registration bugs surface at startup
+// instead of as requests that never match.
+func (r *Router) SetStrict(strict bool) {
+	r.strict = strict
+}
+
+// SetMaxDepth sets the maximum number of segments in a pattern checked by
+// ValidatePattern.
+func (r *Router) SetMaxDepth(max int) {
+	r.maxDepth = max
+}
+
+// ValidatePattern returns an error if the pattern doesn't start with /, has
+// an empty segment or parameter name, repeats a parameter name, has segments
+// after a ... wildcard, or has more segments than the maximum depth. The empty
+// pattern is the root pattern /.
+func (r *Router) ValidatePattern(pattern string) error {
+	pattern = rootPattern(pattern)
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("%w %q: must start with /", ErrInvalidPattern, pattern)
+	}
+
+	maxDepth := r.maxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	segs := strings.Split(pattern[1:], "/")
+	if len(segs) > maxDepth {
+		return fmt.Errorf("%w %q: more than %v segments", ErrInvalidPattern, pattern, maxDepth)
+	}
+
+	params := make(map[string]bool)
+	for i, seg := range segs {
+		last := i == len(segs)-1
+		switch {
+		case seg == "":
+			// The root pattern and a trailing slash are allowed.
+			if !last {
+				return fmt.Errorf("%w %q: empty segment", ErrInvalidPattern, pattern)
+			}
+		case strings.HasPrefix(seg, ":"):
+			name := seg[1:]
+			if name == "" {
+				return fmt.Errorf("%w %q: empty parameter name", ErrInvalidPattern, pattern)
+			} else if params[name] {
+				return fmt.Errorf("%w %q: duplicate parameter %v", ErrInvalidPattern, pattern, name)
+			}
+			params[name] = true
+		case strings.HasSuffix(seg, "..."):
+			// A trailing slash after the wildcard adds no segment.
+			trailingSlash := i == len(segs)-2 && segs[i+1] == ""
+			if !last && !trailingSlash {
+				return fmt.Errorf("%w %q: %v", ErrMidPatternWildcard, pattern, seg)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mustValidate panics if the pattern has segments after a ... wildcard or,
+// in strict mode, is invalid.
+func (r *Router) mustValidate(pattern string) {
+	err := r.ValidatePattern(pattern)
+	if err != nil && (r.strict || errors.Is(err, ErrMidPatternWildcard)) {
+		panic(err)
+	}
+}
+
+// IssueKind is the kind of problem found by Validate.
+type IssueKind string
+
+const (
+	// IssueInvalidPattern is a pattern rejected by ValidatePattern.
+	IssueInvalidPattern IssueKind = "invalid-pattern"
+	// IssueConflict is a route with the same method and path shape as an
+	// earlier route, such as /users/:id and /users/:name.
+	IssueConflict IssueKind = "conflict"
+	// IssueShadowed is a route that never matches because an earlier route
+	// matches every path it does.
+	IssueShadowed IssueKind = "shadowed"
+	// IssueDuplicateName is a name set with Name on more than one route.
+	IssueDuplicateName IssueKind = "duplicate-name"
+	// IssueConfig is a missing router setting.
+	IssueConfig IssueKind = "config"
+)
+
+// Issue is a problem with the routing table found by Validate.
+type Issue struct {
+	Kind IssueKind
+	// Route is the route with the problem. It is empty for IssueConfig.
+	Route RouteInfo
+	// Other is the earlier route for IssueConflict, IssueShadowed, and
+	// IssueDuplicateName.
+	Other   RouteInfo
+	Message string
+}
+
+// Validate returns the problems with the routing table: invalid patterns,
+// conflicting routes, routes shadowed by an earlier route, and duplicate
+// route names. Routes with a When condition don't conflict with or shadow
+// other routes. Apps can assert the report is empty in a test or log it at
+// startup.
+func (r *Router) Validate() []Issue {
+	routes := r.snapshot()
+	issues := make([]Issue, 0)
+	names := make(map[string]*Route)
+
+	for i, route := range routes {
+		if err := r.ValidatePattern(route.pattern); err != nil {
+			issues = append(issues, Issue{Kind: IssueInvalidPattern, Route: route.Info(), Message: err.Error()})
+		}
+
+		if name := route.settings().name; name != "" {
+			if other, ok := names[name]; ok {
+				issues = append(issues, Issue{
+					Kind:    IssueDuplicateName,
+					Route:   route.Info(),
+					Other:   other.Info(),
+					Message: fmt.Sprintf("%v %v has the same name as %v %v: %v", route.Method(), route.pattern, other.Method(), other.pattern, name),
+				})
+			} else {
+				names[name] = route
+			}
+		}
+
+		for _, earlier := range routes[:i] {
+			if earlier.settings().when != nil || route.settings().when != nil {
+				continue
+			}
+			if earlier.method != MethodAny && earlier.method != route.method {
+				continue
+			}
+
+			if earlier.method == route.method && sameShape(earlier, route) {
+				issues = append(issues, Issue{
+					Kind:    IssueConflict,
+					Route:   route.Info(),
+					Other:   earlier.Info(),
+					Message: fmt.Sprintf("%v %v conflicts with %v", route.Method(), route.pattern, earlier.pattern),
+				})
+				break
+			}
+			if covers(earlier, route) {
+				issues = append(issues, Issue{
+					Kind:    IssueShadowed,
+					Route:   route.Info(),
+					Other:   earlier.Info(),
+					Message: fmt.Sprintf("%v %v is shadowed by %v %v", route.Method(), route.pattern, earlier.Method(), earlier.pattern),
+				})
+				break
+			}
+		}
+	}
+
+	return issues
+}
+
+// sameShape returns true if the routes have the same segments ignoring the
+// parameter names.
+func sameShape(a, b *Route) bool {
+	if a.prefix != b.prefix || len(a.segs) != len(b.segs) {
+		return false
+	}
+	for i, seg := range a.segs {
+		if strings.HasPrefix(seg, ":") && strings.HasPrefix(b.segs[i], ":") {
+			continue
+		}
+		if seg != b.segs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// covers returns true if a matches every path that b matches.
+func covers(a, b *Route) bool {
+	if len(b.segs) < len(a.segs) || (!a.prefix && (b.prefix || len(b.segs) != len(a.segs))) {
+		return false
+	}
+
+	for i, seg := range a.segs {
+		bseg := b.segs[i]
+		bparam := strings.HasPrefix(bseg, ":")
+		switch {
+		case strings.HasPrefix(seg, ":"):
+		case a.prefix && i == len(a.segs)-1 && strings.HasSuffix(seg, "..."):
+			if bparam || !strings.HasPrefix(strings.TrimSuffix(bseg, "..."), seg[:len(seg)-3]) {
+				return false
+			}
+		case bparam || seg != bseg:
+			return false
+		}
+	}
+	return true
+}
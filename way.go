@@ -4,20 +4,52 @@ package away
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-// wayContextKey is the context key type for storing
-// parameters in context.Context.
-type wayContextKey string
+// routeContextKey is the context key for storing the matched route.
+type routeContextKey struct{}
 
 // Router routes HTTP requests.
 type Router struct {
-	routes routeList
+	// unmatchedCounters is first so the counters are 64-bit aligned for
+	// atomic access on 32-bit platforms.
+	unmatchedCounters unmatchedCounters
+	// mu guards routes. The slice is replaced rather than modified so a
+	// request can match against a snapshot without holding the lock.
+	mu         sync.RWMutex
+	routes     routeList
+	middleware []func(http.Handler) http.Handler
+	listeners  []func(event RouteEvent)
+	// suggestions is the number of similar routes to pass to NotFound.
+	suggestions int
+	// trace receives the result of each route considered for a request.
+	trace func(event TraceEvent)
+	// unmatchedListeners are called for requests that no route matches.
+	unmatchedListeners []func(event UnmatchedEvent)
+	// strict panics on invalid patterns.
+	strict bool
+	// maxDepth is the maximum number of segments in a pattern.
+	maxDepth int
+	// escapedPath matches against the escaped path decoded per segment.
+	escapedPath bool
+	// redacted are the names redacted for every route.
+	redacted redactSet
 	// NotFound is the http.Handler to call when no routes
 	// match. By default uses http.NotFoundHandler().
 	NotFound http.Handler
+	// MethodNotAllowed is the http.Handler to call when no routes match but
+	// the path matches routes of other methods. The Allow header is set
+	// before it is called. If nil, NotFound is called.
+	MethodNotAllowed http.Handler
+	// NotImplemented is the http.Handler to call when no route is
+	// registered for the request method. If nil, MethodNotAllowed or
+	// NotFound is called.
+	NotImplemented http.Handler
 }
 
 // NewRouter makes a new Router.
@@ -31,84 +63,483 @@ func (r *Router) pathSegments(p string) []string {
 	return strings.Split(strings.Trim(p, "/"), "/")
 }
 
-// Remove an entry from the router.
-func (r *Router) Remove(method string, p string) {
-	for index, v := range r.routes {
-		if v.pattern == p && strings.EqualFold(v.method, method) {
-			r.routes = removeIndex(r.routes, index)
+// SetEscapedPath enables matching against the escaped path of the request
+// with each segment decoded separately, so a parameter can contain an
+// encoded slash such as a%2Fb. By default the decoded path is matched and
+// a%2Fb is the same as a/b.
+func (r *Router) SetEscapedPath(enabled bool) {
+	r.escapedPath = enabled
+}
+
+// requestSegments appends the path segments of the request to segs.
+func (r *Router) requestSegments(req *http.Request, segs []string) []string {
+	if !r.escapedPath {
+		return splitPath(segs, req.URL.Path)
+	}
+
+	segs = splitPath(segs, req.URL.EscapedPath())
+	for i, seg := range segs {
+		if v, err := url.PathUnescape(seg); err == nil {
+			segs[i] = v
 		}
 	}
+	return segs
+}
+
+// Remove removes the routes with the method and pattern and returns the
+// number of routes removed. Parameters in the pattern can be written as :id
+// or {id}.
+func (r *Router) Remove(method string, p string) int {
+	return r.remove(func(route *Route) bool {
+		return strings.EqualFold(route.method, method)
+	}, p)
+}
+
+// RemoveAll removes the routes with the pattern for all methods and returns
+// the number of routes removed.
+func (r *Router) RemoveAll(p string) int {
+	return r.remove(func(route *Route) bool {
+		return true
+	}, p)
+}
+
+// SetEnabled enables or disables the route with the method and pattern and
+// returns false if there isn't one. Disabled routes stay registered but are
+// skipped when matching, so requests fall through to the next candidate or
+// the NotFound handler. Parameters in the pattern can be written as :id or
+// {id}.
+func (r *Router) SetEnabled(method string, p string, enabled bool) bool {
+	p = braceToColon(p)
+	method = strings.ToLower(method)
+
+	r.mu.Lock()
+	for i, route := range r.routes {
+		if route.method != method || route.pattern != p {
+			continue
+		}
+		if route.disabled == !enabled {
+			r.mu.Unlock()
+			return true
+		}
+
+		swapped := route.clone()
+		swapped.disabled = !enabled
+		routes := make(routeList, len(r.routes))
+		copy(routes, r.routes)
+		routes[i] = swapped
+		r.routes = routes
+		r.mu.Unlock()
+
+		r.emit(RouteSwapped, swapped)
+		return true
+	}
+	r.mu.Unlock()
+	return false
+}
+
+// remove removes the routes with the pattern that match fn.
+func (r *Router) remove(fn func(route *Route) bool, p string) int {
+	p = braceToColon(p)
+	r.mu.Lock()
+	kept := make(routeList, 0, len(r.routes))
+	removed := make([]*Route, 0)
+	for _, route := range r.routes {
+		if route.pattern == p && fn(route) {
+			removed = append(removed, route)
+			continue
+		}
+		kept = append(kept, route)
+	}
+	r.routes = kept
+	r.mu.Unlock()
+
+	for _, route := range removed {
+		r.emit(RouteRemoved, route)
+	}
+
+	return len(removed)
+}
+
+// braceToColon converts parameters written as {id} to :id.
+func braceToColon(p string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			segs[i] = ":" + seg[1:len(seg)-1]
+		}
+	}
+	return strings.Join(segs, "/")
 }
 
 // Count returns the number of routes.
 func (r *Router) Count() int {
-	return len(r.routes)
+	return len(r.snapshot())
+}
+
+// Routes returns a snapshot of the routes in the order they are matched.
+func (r *Router) Routes() []*Route {
+	routes := r.snapshot()
+	out := make([]*Route, len(routes))
+	copy(out, routes)
+	return out
+}
+
+// snapshot returns the current routes. The slice must not be modified.
+func (r *Router) snapshot() routeList {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.routes
 }
 
-func removeIndex(s []*route, index int) []*route {
-	return append(s[:index], s[index+1:]...)
+// Walk calls fn for each route in the order routes are matched. Walk uses a
+// snapshot of the routing table so fn may register or remove routes. If fn
+// returns an error, Walk stops and returns it.
+func (r *Router) Walk(fn func(route RouteInfo) error) error {
+	for _, route := range r.Routes() {
+		if err := fn(route.Info()); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Handle adds a handler with the specified method and pattern.
-// Method can be any HTTP method string or "*" to match all methods.
+// Method can be any HTTP method string or MethodAny to match all methods.
 // Pattern can contain path segments such as: /item/:id which is
 // accessible via the Param function.
-// If pattern ends with trailing /, it acts as a prefix.
-func (r *Router) Handle(method, pattern string, handler http.Handler) {
-	route := &route{
-		pattern: pattern,
-		method:  strings.ToLower(method),
-		segs:    r.pathSegments(pattern),
-		handler: handler,
-		prefix:  strings.HasSuffix(pattern, "/") || strings.HasSuffix(pattern, "..."),
+// If pattern ends with trailing /, it acts as a prefix, except for the root
+// pattern / which only matches the root. An empty pattern is the same as /.
+// Use /... to match every path.
+// If the last segment ends with ..., such as /images..., it matches any path
+// that starts with the pattern. Handle panics if ... is followed by more
+// segments and, in strict mode, if the pattern is invalid.
+func (r *Router) Handle(method, pattern string, handler http.Handler) *Route {
+	return r.HandleWith(method, pattern, handler, nil)
+}
+
+// HandleWith adds a handler like Handle and calls configure with the route
+// before it is registered, so a route added while the router serves
+// requests is never matched without its conditions and values.
+func (r *Router) HandleWith(method, pattern string, handler http.Handler, configure func(route *Route)) *Route {
+	pattern = rootPattern(pattern)
+	r.mustValidate(pattern)
+
+	route := r.newRoute(method, pattern, handler)
+	if configure != nil {
+		configure(route)
+	}
+
+	r.mu.Lock()
+	r.insert(route)
+	r.mu.Unlock()
+	r.emit(RouteRegistered, route)
+
+	return route
+}
+
+// Replace swaps the handler of the route with the method and pattern in a
+// single step, keeping its position and metadata, or registers a new route
+// if there isn't one. Requests in flight finish with the old handler. The
+// returned route replaces the old one for further configuration.
+func (r *Router) Replace(method, pattern string, handler http.Handler) *Route {
+	pattern = rootPattern(braceToColon(pattern))
+	r.mustValidate(pattern)
+	method = strings.ToLower(method)
+
+	r.mu.Lock()
+	for i, route := range r.routes {
+		if route.method != method || route.pattern != pattern {
+			continue
+		}
+
+		swapped := route.clone()
+		swapped.handler = handler
+		routes := make(routeList, len(r.routes))
+		copy(routes, r.routes)
+		routes[i] = swapped
+		r.routes = routes
+		r.mu.Unlock()
+
+		r.emit(RouteSwapped, swapped)
+		return swapped
 	}
-	r.routes = append(r.routes, route)
 
-	// Sort so the routes are in the proper order.
-	sort.Sort(r.routes)
+	route := r.newRoute(method, pattern, handler)
+	r.insert(route)
+	r.mu.Unlock()
+	r.emit(RouteRegistered, route)
+
+	return route
+}
+
+// newRoute returns a route that isn't registered yet.
+func (r *Router) newRoute(method, pattern string, handler http.Handler) *Route {
+	return &Route{
+		pattern:  pattern,
+		method:   strings.ToLower(method),
+		segs:     r.pathSegments(pattern),
+		handler:  handler,
+		prefix:   (strings.HasSuffix(pattern, "/") && pattern != "/") || strings.HasSuffix(pattern, "..."),
+		redacted: &r.redacted,
+	}
+}
+
+// rootPattern returns / for the empty pattern.
+func rootPattern(pattern string) string {
+	if pattern == "" {
+		return "/"
+	}
+	return pattern
+}
+
+// insert adds a route in a new copy of the routes. The lock must be held.
+func (r *Router) insert(route *Route) {
+	routes := make(routeList, len(r.routes), len(r.routes)+1)
+	copy(routes, r.routes)
+	routes = append(routes, route)
+
+	// Sort so the routes are in the proper order. Routes with the same
+	// pattern keep their registration order.
+	sort.Stable(routes)
+	r.routes = routes
+}
+
+// Use adds middleware that wraps the handler after a request is routed. The
+// matched route is available to the middleware via CurrentRoute. Middleware
+// also wraps the NotFound handler.
+func (r *Router) Use(mw ...func(http.Handler) http.Handler) {
+	r.middleware = append(r.middleware, mw...)
 }
 
 // HandleFunc is the http.HandlerFunc alternative to http.Handle.
-func (r *Router) HandleFunc(method, pattern string, fn http.HandlerFunc) {
-	r.Handle(method, pattern, fn)
+func (r *Router) HandleFunc(method, pattern string, fn http.HandlerFunc) *Route {
+	return r.Handle(method, pattern, fn)
 }
 
 // ServeHTTP routes the incoming http.Request based on method and path
 // extracting path parameters as it goes.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	method := strings.ToLower(req.Method)
-	segs := r.pathSegments(req.URL.Path)
-	for _, route := range r.routes {
-		if route.method != method && route.method != "*" {
+	scratch := segmentPool.Get().(*[]string)
+	segs := r.requestSegments(req, (*scratch)[:0])
+	defer putSegments(scratch, segs)
+
+	for _, route := range r.snapshot() {
+		if route.method != MethodAny && !strings.EqualFold(route.method, req.Method) {
+			r.traceRoute(req, route, TraceMethodMismatch, segs)
+			continue
+		}
+		if route.disabled {
+			r.traceRoute(req, route, TraceDisabled, segs)
 			continue
 		}
-		if ctx, ok := route.match(req.Context(), r, segs); ok {
-			route.handler.ServeHTTP(w, req.WithContext(ctx))
+		ctx, ok := route.match(req.Context(), r, segs)
+		if !ok {
+			r.traceRoute(req, route, TracePathMismatch, segs)
+			continue
+		}
+		config := route.settings()
+		if config.when != nil && !config.when(req.WithContext(ctx)) {
+			r.traceRoute(req, route, TraceConditionFalse, segs)
+			continue
+		}
+		r.traceRoute(req, route, TraceMatched, segs)
+		ctx = context.WithValue(ctx, routeContextKey{}, route)
+		if config.index != nil && route.isIndex(segs) {
+			r.wrap(config.index).ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
+		r.wrap((*routeHandler)(route)).ServeHTTP(w, req.WithContext(ctx))
+		return
+	}
+	r.traceRoute(req, nil, TraceNotFound, segs)
+	if h := r.unmatched(w, req, segs); h != nil {
+		r.wrap(h).ServeHTTP(w, req)
+		return
+	}
+	if r.suggestions > 0 {
+		req = req.WithContext(context.WithValue(req.Context(), suggestionsContextKey{}, r.suggest(req, segs)))
+	}
+	r.wrap(r.NotFound).ServeHTTP(w, r.withNotFound(req, segs))
+}
+
+// wrap applies the middleware to a handler.
+func (r *Router) wrap(h http.Handler) http.Handler {
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
 	}
-	r.NotFound.ServeHTTP(w, req)
+	return h
 }
 
 // Param gets the path parameter from the specified Context.
-// Returns an empty string if the parameter was not found.
+// Returns an empty string if the parameter was not found. If the name
+// appears more than once in the pattern, the last value is returned.
 func Param(ctx context.Context, param string) string {
-	vStr, ok := ctx.Value(wayContextKey(param)).(string)
-	if !ok {
-		return ""
+	params, _ := ctx.Value(paramsKey{}).([]PathParam)
+	for i := len(params) - 1; i >= 0; i-- {
+		if params[i].Name == param {
+			return params[i].Value
+		}
 	}
-	return vStr
+	return ""
+}
+
+// CurrentRoute returns the route matched for the request context or nil if
+// no route matched.
+func CurrentRoute(ctx context.Context) *Route {
+	route, _ := ctx.Value(routeContextKey{}).(*Route)
+	return route
 }
 
-type route struct {
+// Route is a registered route.
+type Route struct {
 	pattern string
 	method  string
 	segs    []string
 	handler http.Handler
 	prefix  bool
+	// config holds the settings that can change while the route is served.
+	// They are replaced as a whole so requests never see a partial update.
+	config atomic.Pointer[routeConfig]
+	// disabled routes are skipped when matching.
+	disabled bool
+	// redacted are the names redacted for every route of the router.
+	redacted *redactSet
+}
+
+// routeConfig contains the settings of a route added after it is created.
+// A routeConfig is never modified once it is stored on a route.
+type routeConfig struct {
+	when   func(r *http.Request) bool
+	shadow http.Handler
+	values map[interface{}]interface{}
+	name   string
+	// index serves the requests for the path of a prefix route itself.
+	index http.Handler
+}
+
+// settings returns the current settings of the route.
+func (r *Route) settings() *routeConfig {
+	if c := r.config.Load(); c != nil {
+		return c
+	}
+	return &routeConfig{}
+}
+
+// configure changes a copy of the settings of the route with fn and stores
+// it, so the route can be configured while it serves requests.
+func (r *Route) configure(fn func(c *routeConfig)) {
+	for {
+		old := r.config.Load()
+		c := &routeConfig{}
+		if old != nil {
+			*c = *old
+		}
+		c.values = make(map[interface{}]interface{}, len(c.values)+1)
+		if old != nil {
+			for k, v := range old.values {
+				c.values[k] = v
+			}
+		}
+		fn(c)
+		if r.config.CompareAndSwap(old, c) {
+			return
+		}
+	}
+}
+
+// Method returns the upper case HTTP method of the route or MethodAny if the
+// route matches all methods.
+func (r *Route) Method() string {
+	return strings.ToUpper(r.method)
+}
+
+// Pattern returns the pattern of the route.
+func (r *Route) Pattern() string {
+	return r.pattern
+}
+
+// Disabled returns true if the route was disabled with SetEnabled.
+func (r *Route) Disabled() bool {
+	return r.disabled
+}
+
+// Prefix returns true if the route matches any path that starts with the
+// pattern.
+func (r *Route) Prefix() bool {
+	return r.prefix
+}
+
+// WithValue stores a value on the route. Values are static configuration
+// that middleware and handlers can read from the matched route. Values can
+// be added while the router serves requests.
+func (r *Route) WithValue(key, value interface{}) *Route {
+	r.configure(func(c *routeConfig) {
+		c.values[key] = value
+	})
+	return r
+}
+
+// Value returns the value stored on the route for key or nil.
+func (r *Route) Value(key interface{}) interface{} {
+	return r.settings().values[key]
+}
+
+// clone returns a copy of the route. The settings are shared until either
+// route is configured.
+func (r *Route) clone() *Route {
+	c := &Route{
+		pattern:  r.pattern,
+		method:   r.method,
+		segs:     r.segs,
+		handler:  r.handler,
+		prefix:   r.prefix,
+		disabled: r.disabled,
+		redacted: r.redacted,
+	}
+	c.config.Store(r.config.Load())
+	return c
+}
+
+// Index sets a handler for requests for the path of a prefix route itself,
+// such as /docs or /docs/ for the pattern /docs/ and /images for the pattern
+// /images..., like the index page of a directory. Other requests that match
+// the prefix are served by the route handler. It has no effect on routes
+// that aren't prefixes.
+func (r *Route) Index(handler http.Handler) *Route {
+	r.configure(func(c *routeConfig) {
+		c.index = handler
+	})
+	return r
 }
 
-type routeList []*route
+// isIndex returns true if the path segments are the path of the prefix
+// route itself.
+func (r *Route) isIndex(segs []string) bool {
+	if !r.prefix || len(segs) != len(r.segs) {
+		return false
+	}
+	last := r.segs[len(r.segs)-1]
+	return !strings.HasSuffix(last, "...") || segs[len(segs)-1] == last[:len(last)-3]
+}
+
+// When only matches the route if fn returns true for the request. If fn
+// returns false, the next candidate route is tried. Calling When more than
+// once requires all functions to return true.
+func (r *Route) When(fn func(r *http.Request) bool) *Route {
+	r.configure(func(c *routeConfig) {
+		if prev := c.when; prev != nil {
+			c.when = func(req *http.Request) bool {
+				return prev(req) && fn(req)
+			}
+			return
+		}
+		c.when = fn
+	})
+	return r
+}
+
+type routeList []*Route
 
 func (s routeList) Len() int {
 	return len(s)
@@ -139,10 +570,11 @@ func (s routeList) Less(i, j int) bool {
 	return siLower < sjLower
 }
 
-func (r *route) match(ctx context.Context, router *Router, segs []string) (context.Context, bool) {
-	if len(segs) > len(r.segs) && !r.prefix {
+func (r *Route) match(ctx context.Context, router *Router, segs []string) (context.Context, bool) {
+	if len(segs) != len(r.segs) && !r.prefix {
 		return nil, false
 	}
+	var params []PathParam
 	for i, seg := range r.segs {
 		if i > len(segs)-1 {
 			return nil, false
@@ -153,9 +585,9 @@ func (r *route) match(ctx context.Context, router *Router, segs []string) (conte
 			seg = strings.TrimPrefix(seg, ":")
 		}
 		if !isParam { // verbatim check
-			if strings.HasSuffix(seg, "...") {
+			if i == len(r.segs)-1 && strings.HasSuffix(seg, "...") {
 				if strings.HasPrefix(segs[i], seg[:len(seg)-3]) {
-					return ctx, true
+					return withParams(ctx, params), true
 				}
 			}
 			if seg != segs[i] {
@@ -163,8 +595,12 @@ func (r *route) match(ctx context.Context, router *Router, segs []string) (conte
 			}
 		}
 		if isParam {
-			ctx = context.WithValue(ctx, wayContextKey(seg), segs[i])
+			if len(segs) == 1 && segs[0] == "" {
+				// Only the root pattern matches the root path.
+				return nil, false
+			}
+			params = append(params, PathParam{Name: seg, Value: segs[i]})
 		}
 	}
-	return ctx, true
+	return withParams(ctx, params), true
 }
@@ -2,11 +2,16 @@ package away_test
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sort"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/ambientkit/away"
 	"github.com/stretchr/testify/assert"
@@ -303,3 +308,879 @@ func TestAway(t *testing.T) {
 	assert.Equal(t, arr[0].pattern, "/")
 	assert.Equal(t, arr[len(arr)-1].pattern, "/:slug")
 }
+
+func TestWhen(t *testing.T) {
+	r := away.NewRouter()
+	var match string
+	enabled := false
+	r.HandleFunc(http.MethodGet, "/checkout", func(w http.ResponseWriter, r *http.Request) {
+		match = "new"
+	}).When(func(r *http.Request) bool {
+		return enabled
+	})
+	r.HandleFunc(http.MethodGet, "/checkout", func(w http.ResponseWriter, r *http.Request) {
+		match = "old"
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "old", match)
+
+	enabled = true
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "new", match)
+}
+
+func TestWhenMultiple(t *testing.T) {
+	r := away.NewRouter()
+	match := false
+	r.HandleFunc(http.MethodGet, "/both", func(w http.ResponseWriter, r *http.Request) {
+		match = true
+	}).When(func(r *http.Request) bool {
+		return true
+	}).When(func(r *http.Request) bool {
+		return false
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/both", nil))
+	assert.False(t, match)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestShadow(t *testing.T) {
+	r := away.NewRouter()
+	shadowed := make(chan string, 1)
+	r.HandleFunc(http.MethodPost, "/orders/:id", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte("primary " + string(b)))
+	}).Shadow(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte("ignored"))
+		shadowed <- away.Param(r.Context(), "id") + " " + string(b)
+	}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/5", strings.NewReader("body"))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "primary body", w.Body.String())
+
+	select {
+	case v := <-shadowed:
+		assert.Equal(t, "5 body", v)
+	case <-time.After(time.Second):
+		t.Fatal("shadow handler not called")
+	}
+}
+
+func TestShadowBody(t *testing.T) {
+	r := away.NewRouter()
+	shadowed := make(chan int, 1)
+	var primary int
+	r.HandleFunc(http.MethodPost, "/upload", func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		primary = len(b)
+	}).Shadow(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shadowed <- 1
+	}))
+
+	// A body over the limit is passed through without shadowing.
+	big := strings.Repeat("x", away.MaxShadowBody+10)
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(big))
+	req.ContentLength = -1
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, len(big), primary)
+
+	// A body that fails to read isn't passed on partially.
+	primary = -1
+	req = httptest.NewRequest(http.MethodPost, "/upload", iotest.ErrReader(errors.New("reset")))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, -1, primary)
+
+	select {
+	case <-shadowed:
+		t.Fatal("shadow handler called")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestUse(t *testing.T) {
+	r := away.NewRouter()
+	patterns := make([]string, 0)
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := away.CurrentRoute(r.Context()); route != nil {
+				patterns = append(patterns, route.Method()+" "+route.Pattern())
+			} else {
+				patterns = append(patterns, "none")
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.HandleFunc("get", "/user/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user/1", nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, []string{"GET /user/:id", "none"}, patterns)
+}
+
+func TestOnChange(t *testing.T) {
+	r := away.NewRouter()
+	events := make([]away.RouteEvent, 0)
+	r.OnChange(func(event away.RouteEvent) {
+		events = append(events, event)
+	})
+
+	r.HandleFunc("get", "/user/:id", testHandler)
+	r.HandleFunc("*", "/all", testHandler)
+	r.Remove("GET", "/user/:id")
+	r.Remove("GET", "/missing")
+
+	assert.Equal(t, []away.RouteEvent{
+		{Type: away.RouteRegistered, Route: away.RouteInfo{Method: "GET", Pattern: "/user/:id", Handler: "away_test.testHandler"}},
+		{Type: away.RouteRegistered, Route: away.RouteInfo{Method: "*", Pattern: "/all", Handler: "away_test.testHandler"}},
+		{Type: away.RouteRemoved, Route: away.RouteInfo{Method: "GET", Pattern: "/user/:id", Handler: "away_test.testHandler"}},
+	}, events)
+}
+
+func TestWalk(t *testing.T) {
+	r := away.NewRouter()
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	r.HandleFunc("GET", "/:slug", h)
+	r.HandleFunc("GET", "/about", h)
+	r.HandleFunc("POST", "/user/:id", h)
+
+	routes := make([]string, 0)
+	err := r.Walk(func(route away.RouteInfo) error {
+		routes = append(routes, route.Method+" "+route.Pattern)
+		// Changing the router while walking doesn't affect the walk.
+		r.HandleFunc("GET", "/new"+route.Pattern, h)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GET /about", "POST /user/:id", "GET /:slug"}, routes)
+	assert.Equal(t, 6, r.Count())
+
+	errStop := errors.New("stop")
+	count := 0
+	err = r.Walk(func(route away.RouteInfo) error {
+		count++
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestSuggestions(t *testing.T) {
+	r := away.NewRouter()
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	r.HandleFunc("GET", "/users/:id", h)
+	r.HandleFunc("DELETE", "/users/:id", h)
+	r.HandleFunc("POST", "/posts", h)
+	r.HandleFunc("GET", "/about", h)
+	r.HandleFunc("GET", "/completely/different", h)
+
+	var suggestions []away.Suggestion
+	r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suggestions = away.Suggestions(r.Context())
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/user/5", nil))
+	assert.Nil(t, suggestions)
+
+	r.SetSuggestions(5)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/user/5", nil))
+	assert.Equal(t, []away.Suggestion{
+		{Method: "GET", Pattern: "/users/:id", Distance: 1},
+		{Method: "DELETE", Pattern: "/users/:id", Distance: 1},
+	}, suggestions)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/posts", nil))
+	assert.Equal(t, []away.Suggestion{
+		{Method: "POST", Pattern: "/posts", Distance: 0},
+	}, suggestions)
+
+	r.SetSuggestions(1)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/users/5", nil))
+	assert.Equal(t, []away.Suggestion{
+		{Method: "GET", Pattern: "/users/:id", Distance: 0},
+	}, suggestions)
+}
+
+func TestTrace(t *testing.T) {
+	r := away.NewRouter()
+	h := testHandler
+	r.HandleFunc("GET", "/users/:id/posts", h)
+	r.HandleFunc("POST", "/users/:id", h)
+	r.HandleFunc("GET", "/users/:id", h).When(func(r *http.Request) bool {
+		return away.Param(r.Context(), "id") != "0"
+	})
+	r.HandleFunc("GET", "/users", h)
+
+	events := make([]away.TraceEvent, 0)
+	r.SetTrace(func(event away.TraceEvent) {
+		events = append(events, event)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/5", nil))
+	assert.Equal(t, []away.TraceEvent{
+		{Method: "GET", Path: "/users/5", Route: away.RouteInfo{Method: "GET", Pattern: "/users", Handler: "away_test.testHandler"}, Result: away.TracePathMismatch, Reason: "path has more segments than the pattern", Segment: 1},
+		{Method: "GET", Path: "/users/5", Route: away.RouteInfo{Method: "POST", Pattern: "/users/:id", Handler: "away_test.testHandler"}, Result: away.TraceMethodMismatch, Segment: -1},
+		{Method: "GET", Path: "/users/5", Route: away.RouteInfo{Method: "GET", Pattern: "/users/:id", Handler: "away_test.testHandler"}, Result: away.TraceMatched, Segment: -1},
+	}, events)
+
+	events = events[:0]
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/0", nil))
+	assert.Equal(t, away.TraceConditionFalse, events[2].Result)
+	assert.Equal(t, away.TraceEvent{Method: "GET", Path: "/users/0", Route: away.RouteInfo{Method: "GET", Pattern: "/users/:id/posts", Handler: "away_test.testHandler"}, Result: away.TracePathMismatch, Reason: "path has fewer segments than the pattern", Segment: 2}, events[3])
+	assert.Equal(t, away.TraceEvent{Method: "GET", Path: "/users/0", Result: away.TraceNotFound, Segment: -1}, events[4])
+
+	events = events[:0]
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/posts", nil))
+	assert.Equal(t, "segment posts doesn't match users", events[0].Reason)
+	assert.Equal(t, 0, events[0].Segment)
+
+	r.SetTrace(nil)
+	events = events[:0]
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/posts", nil))
+	assert.Len(t, events, 0)
+}
+
+func TestRedact(t *testing.T) {
+	r := away.NewRouter()
+	route := r.HandleFunc("GET", "/reset/:token", func(w http.ResponseWriter, r *http.Request) {}).Redact("Token", "X-API-Key")
+
+	assert.True(t, route.IsRedacted("token"))
+	assert.True(t, route.IsRedacted("authorization"))
+	assert.False(t, route.IsRedacted("id"))
+	var none *away.Route
+	assert.True(t, none.IsRedacted("Cookie"))
+	assert.False(t, none.IsRedacted("token"))
+
+	h := http.Header{}
+	h.Set("X-Api-Key", "secret")
+	h.Set("Accept", "text/html")
+	redacted := route.RedactHeader(h)
+	assert.Equal(t, away.Redacted, redacted.Get("X-Api-Key"))
+	assert.Equal(t, "text/html", redacted.Get("Accept"))
+	assert.Equal(t, "secret", h.Get("X-Api-Key"))
+
+	u, _ := url.Parse("/reset/abc?token=abc&page=2")
+	assert.Equal(t, "/reset/REDACTED?page=2&token=REDACTED", route.RedactURL(u))
+	u, _ = url.Parse("/other?page=2")
+	assert.Equal(t, "/other?page=2", none.RedactURL(u))
+}
+
+func TestRouterRedact(t *testing.T) {
+	r := away.NewRouter()
+	before := r.HandleFunc("GET", "/a", func(w http.ResponseWriter, r *http.Request) {})
+	r.Redact("X-Key", "")
+	after := r.HandleFunc("GET", "/b", func(w http.ResponseWriter, r *http.Request) {})
+
+	assert.True(t, before.IsRedacted("x-key"))
+	assert.True(t, after.IsRedacted("X-KEY"))
+	assert.False(t, after.IsRedacted(""))
+	assert.False(t, away.NewRouter().HandleFunc("GET", "/a", nil).IsRedacted("x-key"))
+}
+
+func testHandler(w http.ResponseWriter, r *http.Request) {}
+
+func TestHandlerName(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/", testHandler)
+	r.Handle("GET", "/files/", http.FileServer(http.Dir(".")))
+	r.HandleFunc("GET", "/named", testHandler).Name("named")
+
+	names := make([]string, 0)
+	r.Walk(func(route away.RouteInfo) error {
+		names = append(names, route.Handler)
+		return nil
+	})
+	assert.Equal(t, []string{"away_test.testHandler", "http.fileHandler", "named"}, names)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/users/:id", testHandler)
+	r.HandleFunc("DELETE", "/users/:id", testHandler)
+	r.HandleFunc("POST", "/users", testHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/1", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	r.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	r.NotImplemented = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotImplemented)
+	})
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{"POST", "/users/1", 405, "DELETE, GET"},
+		{"GET", "/users", 405, "POST"},
+		{"BREW", "/users/1", 501, ""},
+		{"GET", "/missing", 404, ""},
+		{"PUT", "/missing", 501, ""},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.method+" "+tc.path)
+		assert.Equal(t, tc.allow, w.Header().Get("Allow"), tc.method+" "+tc.path)
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/users/:id", testHandler)
+	r.HandleFunc("DELETE", "/users/:id", testHandler)
+	r.HandleFunc("POST", "/users", testHandler)
+	r.HandleFunc("GET", "/static/", testHandler)
+	r.HandleFunc("*", "/any", testHandler)
+
+	assert.Equal(t, []string{"DELETE", "GET"}, r.AllowedMethods("/users/1"))
+	assert.Equal(t, []string{"POST"}, r.AllowedMethods("/users"))
+	assert.Equal(t, []string{"GET"}, r.AllowedMethods("/static/css/site.css"))
+	assert.Empty(t, r.AllowedMethods("/any"))
+	assert.Empty(t, r.AllowedMethods("/missing"))
+
+	assert.True(t, r.AnyMethod("/any"))
+	assert.False(t, r.AnyMethod("/users/1"))
+	r.SetEnabled(away.MethodAny, "/any", false)
+	assert.False(t, r.AnyMethod("/any"))
+}
+
+func TestMethodAnyNotAllowed(t *testing.T) {
+	r := away.NewRouter()
+	r.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	r.HandleFunc("GET", "/files/:name", testHandler)
+	r.HandleFunc(away.MethodAny, "/files/:name", testHandler).When(func(r *http.Request) bool {
+		return r.Header.Get("X-Upload") != ""
+	})
+
+	// The path allows every method, so a request that fails the condition
+	// isn't a 405.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/files/a", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Allow"))
+
+	req := httptest.NewRequest("PUT", "/files/a", nil)
+	req.Header.Set("X-Upload", "1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Disabled wildcard routes don't count.
+	r.SetEnabled(away.MethodAny, "/files/:name", false)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/files/a", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET", w.Header().Get("Allow"))
+}
+
+func TestSetEnabled(t *testing.T) {
+	r := away.NewRouter()
+	var served string
+	r.HandleFunc("GET", "/users/me", func(w http.ResponseWriter, r *http.Request) {
+		served = "me"
+	})
+	r.HandleFunc("GET", "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		served = "id"
+	})
+
+	var events []away.RouteEvent
+	r.OnChange(func(event away.RouteEvent) {
+		events = append(events, event)
+	})
+
+	assert.True(t, r.SetEnabled("GET", "/users/me", false))
+	assert.False(t, r.SetEnabled("GET", "/missing", false))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/me", nil))
+	assert.Equal(t, "id", served)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, away.RouteSwapped, events[0].Type)
+		assert.True(t, events[0].Route.Disabled)
+	}
+
+	assert.True(t, r.SetEnabled("GET", "/users/{id}", false))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, r.AllowedMethods("/users/1"))
+
+	assert.True(t, r.SetEnabled("GET", "/users/me", true))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/me", nil))
+	assert.Equal(t, "me", served)
+}
+
+func TestEscapedPath(t *testing.T) {
+	r := away.NewRouter()
+	var name string
+	r.HandleFunc("GET", "/files/:name", func(w http.ResponseWriter, r *http.Request) {
+		name = away.Param(r.Context(), "name")
+	})
+	r.HandleFunc("GET", "/files/:dir/:name", func(w http.ResponseWriter, r *http.Request) {
+		name = away.Param(r.Context(), "dir") + "|" + away.Param(r.Context(), "name")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/files/a%2Fb", nil))
+	assert.Equal(t, "a|b", name)
+
+	r.SetEscapedPath(true)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/files/a%2Fb", nil))
+	assert.Equal(t, "a/b", name)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/files/a/b%20c", nil))
+	assert.Equal(t, "a|b c", name)
+}
+
+func TestRemove(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/users/:id", testHandler)
+	r.HandleFunc("GET", "/users/:id", testHandler)
+	r.HandleFunc("DELETE", "/users/:id", testHandler)
+	r.HandleFunc("GET", "/about", testHandler)
+
+	assert.Equal(t, 0, r.Remove("GET", "/missing"))
+	assert.Equal(t, 2, r.Remove("get", "/users/{id}"))
+	assert.Equal(t, 2, r.Count())
+
+	r.HandleFunc("POST", "/users/:id", testHandler)
+	assert.Equal(t, 2, r.RemoveAll("/users/:id"))
+	assert.Equal(t, 1, r.Count())
+}
+
+func TestReplace(t *testing.T) {
+	r := away.NewRouter()
+	events := make([]away.RouteEventType, 0)
+	r.OnChange(func(event away.RouteEvent) {
+		events = append(events, event.Type)
+	})
+
+	write := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+	r.HandleFunc("GET", "/users/:id", write("v1")).WithValue("key", "value").When(func(r *http.Request) bool {
+		return away.Param(r.Context(), "id") != "0"
+	})
+	r.HandleFunc("GET", "/users/:id", write("fallback"))
+
+	route := r.Replace("GET", "/users/:id", write("v2"))
+	assert.Equal(t, "value", route.Value("key"))
+	assert.Equal(t, 2, r.Count())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	assert.Equal(t, "v2", w.Body.String())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/0", nil))
+	assert.Equal(t, "fallback", w.Body.String())
+
+	r.Replace("POST", "/users", write("created"))
+	assert.Equal(t, 3, r.Count())
+	assert.Equal(t, []away.RouteEventType{away.RouteRegistered, away.RouteRegistered, away.RouteSwapped, away.RouteRegistered}, events)
+
+	// Brace parameters match the route like Remove and SetEnabled.
+	r.Replace("GET", "/users/{id}", write("v3"))
+	assert.Equal(t, 3, r.Count())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	assert.Equal(t, "v3", w.Body.String())
+}
+
+func TestReplaceConcurrent(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/", testHandler)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			r.Replace("GET", "/", http.HandlerFunc(testHandler))
+			r.HandleFunc("GET", "/other", testHandler)
+			r.Remove("GET", "/other")
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	<-done
+}
+
+func TestRouteConfigureConcurrent(t *testing.T) {
+	type key struct{}
+	r := away.NewRouter()
+	route := r.HandleFunc("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+		away.CurrentRoute(r.Context()).Value(key{})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			route.WithValue(key{}, i)
+			route.When(func(r *http.Request) bool { return true })
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	<-done
+	assert.Equal(t, 99, route.Value(key{}))
+}
+
+func TestHandleWith(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleWith("GET", "/", http.HandlerFunc(testHandler), func(route *away.Route) {
+		assert.Zero(t, r.Count())
+		route.When(func(r *http.Request) bool { return false })
+	})
+	assert.Equal(t, 1, r.Count())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOnUnmatched(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/users", testHandler)
+	events := make([]away.UnmatchedEvent, 0)
+	r.OnUnmatched(func(event away.UnmatchedEvent) {
+		events = append(events, event)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/wp-admin", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	r.MethodNotAllowed = http.HandlerFunc(testHandler)
+	r.NotImplemented = http.HandlerFunc(testHandler)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/.env", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+
+	assert.Equal(t, []away.UnmatchedEvent{
+		{Method: "GET", Path: "/wp-admin", Result: away.UnmatchedNotFound},
+		{Method: "GET", Path: "/.env", Result: away.UnmatchedNotFound},
+		{Method: "POST", Path: "/users", Result: away.UnmatchedNotImplemented},
+	}, events)
+	assert.Equal(t, away.UnmatchedStats{NotFound: 2, NotImplemented: 1}, r.UnmatchedStats())
+
+	r.HandleFunc("POST", "/other", testHandler)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+	assert.Equal(t, away.UnmatchedMethodNotAllowed, events[3].Result)
+	assert.Equal(t, uint64(1), r.UnmatchedStats().MethodNotAllowed)
+}
+
+func TestValidatePattern(t *testing.T) {
+	r := away.NewRouter()
+	for _, tc := range []struct {
+		pattern string
+		valid   bool
+	}{
+		{"/", true},
+		{"/users/:id", true},
+		{"/files/", true},
+		{"/images...", true},
+		{"users", false},
+		{"/users//posts", false},
+		{"/users/:", false},
+		{"/users/:id/posts/:id", false},
+		{"/images.../:id", false},
+		{"/a.../b...", false},
+		{"/" + strings.Repeat("a/", away.DefaultMaxDepth) + "b", false},
+	} {
+		err := r.ValidatePattern(tc.pattern)
+		if tc.valid {
+			assert.NoError(t, err, tc.pattern)
+		} else {
+			assert.True(t, errors.Is(err, away.ErrInvalidPattern), tc.pattern)
+		}
+	}
+
+	r.SetMaxDepth(2)
+	assert.Error(t, r.ValidatePattern("/a/b/c"))
+
+	r.HandleFunc("GET", "/users//posts", testHandler)
+	r.SetStrict(true)
+	assert.Panics(t, func() {
+		r.HandleFunc("GET", "/users/:id/posts/:id", testHandler)
+	})
+	assert.NotPanics(t, func() {
+		r.HandleFunc("GET", "/users/:id", testHandler)
+	})
+}
+
+func TestParams(t *testing.T) {
+	r := away.NewRouter()
+	var params map[string][]string
+	var id string
+	r.HandleFunc("GET", "/users/:id/friends/:id", func(w http.ResponseWriter, r *http.Request) {
+		params = away.Params(r.Context())
+		id = away.Param(r.Context(), "id")
+	})
+	r.HandleFunc("GET", "/about", func(w http.ResponseWriter, r *http.Request) {
+		params = away.Params(r.Context())
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1/friends/2", nil))
+	assert.Equal(t, map[string][]string{"id": {"1", "2"}}, params)
+	assert.Equal(t, "2", id)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/about", nil))
+	assert.Equal(t, map[string][]string{}, params)
+}
+
+func TestParamList(t *testing.T) {
+	r := away.NewRouter()
+	var params []away.PathParam
+	r.HandleFunc("GET", "/orgs/:org/repos/:repo/issues/:number", func(w http.ResponseWriter, r *http.Request) {
+		params = away.ParamList(r.Context())
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orgs/ambientkit/repos/away/issues/7", nil))
+	assert.Equal(t, []away.PathParam{
+		{Name: "org", Value: "ambientkit"},
+		{Name: "repo", Value: "away"},
+		{Name: "number", Value: "7"},
+	}, params)
+
+	assert.Equal(t, []away.PathParam{}, away.ParamList(context.Background()))
+}
+
+func TestParamKeyCollision(t *testing.T) {
+	type stringKey string
+
+	r := away.NewRouter()
+	var id, other interface{}
+	var param string
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), stringKey("id"), "middleware")
+			ctx = context.WithValue(ctx, "id", "untyped")
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+	r.HandleFunc("GET", "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		id = r.Context().Value(stringKey("id"))
+		other = r.Context().Value("id")
+		param = away.Param(r.Context(), "id")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	assert.Equal(t, "middleware", id)
+	assert.Equal(t, "untyped", other)
+	assert.Equal(t, "1", param)
+	assert.Equal(t, "", away.Param(context.WithValue(context.Background(), "id", "1"), "id"))
+}
+
+func TestServeHTTPAllocs(t *testing.T) {
+	r := away.NewRouter()
+	for _, pattern := range []string{"/", "/about", "/users/:id", "/users/:id/posts", "/a/b/c"} {
+		r.HandleFunc("GET", pattern, testHandler)
+	}
+	w := httptest.NewRecorder()
+
+	// A static hit only allocates the context and request copy for
+	// CurrentRoute.
+	req := httptest.NewRequest("GET", "/a/b/c", nil)
+	assert.LessOrEqual(t, testing.AllocsPerRun(100, func() { r.ServeHTTP(w, req) }), 2.0)
+
+	req = httptest.NewRequest("get", "/about", nil)
+	assert.LessOrEqual(t, testing.AllocsPerRun(100, func() { r.ServeHTTP(w, req) }), 2.0)
+
+	req = httptest.NewRequest("GET", "/users/1", nil)
+	assert.LessOrEqual(t, testing.AllocsPerRun(100, func() { r.ServeHTTP(w, req) }), 5.0)
+}
+
+func TestValidate(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/users/:id", testHandler).Name("getUser")
+	r.HandleFunc("GET", "/users/:name", testHandler)
+	r.HandleFunc("GET", "/users/me", testHandler)
+	r.HandleFunc("POST", "/users/:id", testHandler).Name("getUser")
+	r.HandleFunc("*", "/static/", testHandler)
+	r.HandleFunc("GET", "/static/css/site.css", testHandler)
+	r.HandleFunc("GET", "/flag/:id", testHandler)
+	r.HandleFunc("GET", "/flag/:id", testHandler).When(func(r *http.Request) bool { return true })
+	r.HandleFunc("GET", "/files/:a/:a", testHandler)
+
+	issues := make([]string, 0)
+	for _, issue := range r.Validate() {
+		issues = append(issues, string(issue.Kind)+" "+issue.Route.Method+" "+issue.Route.Pattern+" "+issue.Other.Pattern)
+	}
+	assert.ElementsMatch(t, []string{
+		"conflict GET /users/:name /users/:id",
+		"duplicate-name POST /users/:id /users/:id",
+		"shadowed GET /static/css/site.css /static/",
+		"invalid-pattern GET /files/:a/:a ",
+	}, issues)
+}
+
+func TestMidPatternWildcard(t *testing.T) {
+	r := away.NewRouter()
+	err := r.ValidatePattern("/images.../:id")
+	assert.True(t, errors.Is(err, away.ErrMidPatternWildcard))
+	assert.True(t, errors.Is(err, away.ErrInvalidPattern))
+
+	// Panics without strict mode.
+	assert.Panics(t, func() {
+		r.HandleFunc("GET", "/images.../:id", testHandler)
+	})
+	assert.Panics(t, func() {
+		r.Replace("GET", "/a.../b", http.HandlerFunc(testHandler))
+	})
+	assert.Equal(t, 0, r.Count())
+
+	var b string
+	r.HandleFunc("GET", "/a/:b/c...", func(w http.ResponseWriter, req *http.Request) {
+		b = away.Param(req.Context(), "b")
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/a/1/cat/x", nil))
+	assert.Equal(t, "1", b)
+}
+
+func TestWildcardTrailingSlash(t *testing.T) {
+	r := away.NewRouter()
+	assert.NoError(t, r.ValidatePattern("/images.../"))
+	assert.Error(t, r.ValidatePattern("/images.../x/"))
+
+	served := false
+	assert.NotPanics(t, func() {
+		r.HandleFunc("GET", "/images.../", func(w http.ResponseWriter, req *http.Request) {
+			served = true
+		})
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/images/cat.png", nil))
+	assert.True(t, served)
+}
+
+func TestRootRoute(t *testing.T) {
+	r := away.NewRouter()
+	var served string
+	handle := func(pattern string) *away.Route {
+		return r.HandleFunc("GET", pattern, func(w http.ResponseWriter, req *http.Request) {
+			served = pattern + " " + away.Param(req.Context(), "slug")
+		})
+	}
+	serve := func(path string) string {
+		served = ""
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		return served
+	}
+
+	root := handle("")
+	assert.Equal(t, "/", root.Pattern())
+	assert.False(t, root.Prefix())
+	assert.Equal(t, " ", serve("/"))
+	assert.Equal(t, "", serve("/missing"))
+
+	// A parameter doesn't match the root path.
+	r = away.NewRouter()
+	handle("/:slug")
+	assert.Equal(t, "", serve("/"))
+	assert.Equal(t, "/:slug about", serve("/about"))
+
+	// /... matches every path.
+	r = away.NewRouter()
+	handle("/...")
+	assert.Equal(t, "/... ", serve("/"))
+	assert.Equal(t, "/... ", serve("/a/b"))
+}
+
+func TestIndex(t *testing.T) {
+	r := away.NewRouter()
+	var served string
+	r.HandleFunc("GET", "/docs/", func(w http.ResponseWriter, req *http.Request) {
+		served = "page"
+	}).Index(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served = "index"
+	}))
+	r.HandleFunc("GET", "/images...", func(w http.ResponseWriter, req *http.Request) {
+		served = "image"
+	}).Index(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served = "gallery"
+	}))
+
+	for path, want := range map[string]string{
+		"/docs":          "index",
+		"/docs/":         "index",
+		"/docs/intro":    "page",
+		"/images":        "gallery",
+		"/images/":       "gallery",
+		"/images/a.jpg":  "image",
+		"/imagesets/one": "image",
+	} {
+		served = ""
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, want, served, path)
+	}
+}
+
+func TestNotFoundInfo(t *testing.T) {
+	r := away.NewRouter()
+	r.HandleFunc("GET", "/users/:id", testHandler)
+	r.HandleFunc("GET", "/about", testHandler)
+
+	var details away.NotFoundDetails
+	var ok bool
+	r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		details, ok = away.NotFoundInfo(req.Context())
+		http.NotFound(w, req)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "//abuot/", nil))
+	assert.True(t, ok)
+	assert.Equal(t, "POST", details.Method)
+	assert.Equal(t, "/abuot", details.Path)
+	if assert.NotEmpty(t, details.Candidates) {
+		assert.Equal(t, "/about", details.Candidates[0].Pattern)
+	}
+
+	_, ok = away.NotFoundInfo(httptest.NewRequest("GET", "/", nil).Context())
+	assert.False(t, ok)
+}
+
+func FuzzMatch(f *testing.F) {
+	seeds := [][2]string{
+		{"/", "/"},
+		{"/users/:id", "/users/1"},
+		{"/users/:id", "/users"},
+		{"/users/:id/", "/users/1/posts"},
+		{"/images...", "/images/a/b"},
+		{"/images...", "/"},
+		{"/a/:b/c...", "/a/1/cat"},
+		{"/a.../b", "/a"},
+		{"/:a/:b", "//"},
+		{"/files/:name", "/files/a%2Fb"},
+		{"/...", "/x"},
+		{"/a/", "/"},
+	}
+	for _, seed := range seeds {
+		f.Add(seed[0], seed[1])
+	}
+
+	f.Fuzz(func(t *testing.T, pattern, path string) {
+		if err := away.FuzzMatch(pattern, path); err != nil {
+			t.Fatal(err)
+		}
+	})
+}